@@ -645,7 +645,7 @@ func (y *Cloud189PC) FastUpload(ctx context.Context, dstDir model.Obj, file mode
 	safeName := y.sanitizeName(file.GetName())
 	size := file.GetSize()
 	if _, ok := cache.(io.ReaderAt); !ok && size > 0 {
-		tmpF, err = os.CreateTemp(conf.Conf.TempDir, "file-*")
+		tmpF, err = os.CreateTemp(conf.Conf.StreamTempDir, "file-*")
 		if err != nil {
 			return nil, err
 		}
@@ -3,6 +3,7 @@ package alias
 import (
 	"context"
 	"errors"
+	"fmt"
 	stdpath "path"
 	"strings"
 
@@ -40,6 +41,10 @@ func (d *Alias) Init(ctx context.Context) error {
 			continue
 		}
 		k, v := getPair(path)
+		// 映射到自身会导致无限递归
+		if utils.IsSubPath(d.MountPath, utils.FixAndCleanPath(v)) {
+			return fmt.Errorf("path [%s] is in the alias itself", v)
+		}
 		d.pathMap[k] = append(d.pathMap[k], v)
 	}
 	if len(d.pathMap) == 1 {
@@ -60,6 +65,10 @@ func (d *Alias) Drop(ctx context.Context) error {
 }
 
 func (d *Alias) Get(ctx context.Context, path string) (model.Obj, error) {
+	ctx, err := enter(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if utils.PathEqual(path, "/") {
 		return &model.Object{
 			Name:     "Root",
@@ -82,6 +91,10 @@ func (d *Alias) Get(ctx context.Context, path string) (model.Obj, error) {
 }
 
 func (d *Alias) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	ctx, err := enter(ctx)
+	if err != nil {
+		return nil, err
+	}
 	path := dir.GetPath()
 	if utils.PathEqual(path, "/") && !d.autoFlatten {
 		return d.listRoot(), nil
@@ -103,6 +116,10 @@ func (d *Alias) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 }
 
 func (d *Alias) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	ctx, err := enter(ctx)
+	if err != nil {
+		return nil, err
+	}
 	root, sub := d.getRootAndPath(file.GetPath())
 	dsts, ok := d.pathMap[root]
 	if !ok {
@@ -159,6 +176,10 @@ func (d *Alias) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
 	if err != nil {
 		return err
 	}
+	if !isSameStorage(*srcPath, *dstPath) {
+		// 不同存储之间无法直接移动，复制并校验全部文件后才删除源文件
+		return fs.MoveBetween(ctx, *srcPath, *dstPath, &fs.MoveBetweenArgs{CleanFailed: d.CleanFailedMove})
+	}
 	return fs.Move(ctx, *srcPath, *dstPath)
 }
 
@@ -14,6 +14,7 @@ type Addition struct {
 	DownloadConcurrency int    `json:"download_concurrency" default:"0" required:"false" type:"number" help:"Need to enable proxy"`
 	DownloadPartSize    int    `json:"download_part_size" default:"0" type:"number" required:"false" help:"Need to enable proxy. Unit: KB"`
 	Writable            bool   `json:"writable" type:"bool" default:"false"`
+	CleanFailedMove     bool   `json:"clean_failed_move" type:"bool" default:"false" help:"Remove the copied files from the destination if a move between two storages fails, the sources are always kept"`
 }
 
 var config = driver.Config{
@@ -2,6 +2,7 @@ package alias
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	stdpath "path"
@@ -17,6 +18,21 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 )
 
+// maxDepth 是alias套娃的最大层数，超过则认为存在循环映射
+const maxDepth = 16
+
+// depthKey 是ctx中套娃层数的key，使用未导出的类型避免与其他包的key冲突
+type depthKey struct{}
+
+// enter 记录alias套娃的层数，防止 A->B->A 这样的循环映射导致无限递归
+func enter(ctx context.Context) (context.Context, error) {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	if depth >= maxDepth {
+		return nil, errors.New("too many nested aliases, maybe an alias is mapped into itself")
+	}
+	return context.WithValue(ctx, depthKey{}, depth+1), nil
+}
+
 func (d *Alias) listRoot() []model.Obj {
 	var objs []model.Obj
 	for k := range d.pathMap {
@@ -127,6 +143,12 @@ func (d *Alias) link(ctx context.Context, dst, sub string, args model.LinkArgs)
 	return link, err
 }
 
+func isSameStorage(path1, path2 string) bool {
+	storage1, _, err1 := op.GetStorageAndActualPath(path1)
+	storage2, _, err2 := op.GetStorageAndActualPath(path2)
+	return err1 == nil && err2 == nil && storage1.GetStorage() == storage2.GetStorage()
+}
+
 func (d *Alias) getReqPath(ctx context.Context, obj model.Obj, isParent bool) (*string, error) {
 	root, sub := d.getRootAndPath(obj.GetPath())
 	if sub == "" && !isParent {
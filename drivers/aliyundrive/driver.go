@@ -237,7 +237,7 @@ func (d *AliDrive) Put(ctx context.Context, dstDir model.Obj, streamer model.Fil
 				return err
 			}
 		} else {
-			tempFile, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+			tempFile, err := os.CreateTemp(conf.Conf.StreamTempDir, "file-*")
 			if err != nil {
 				return err
 			}
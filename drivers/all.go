@@ -19,6 +19,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive_share"
 	_ "github.com/alist-org/alist/v3/drivers/azure_blob"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_netdisk"
+	_ "github.com/alist-org/alist/v3/drivers/baidu_netdisk_multi"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_photo"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_share"
 	_ "github.com/alist-org/alist/v3/drivers/bitqiu"
@@ -0,0 +1,90 @@
+package baidu_netdisk
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// filemanager 单次请求最多操作的文件数
+const manageBatchSize = 100
+
+// manageBatch 按 manageBatchSize 分批调用 filemanager
+func manageBatch[T any](ctx context.Context, d *BaiduNetdisk, opera string, filelist []T) error {
+	for start := 0; start < len(filelist); start += manageBatchSize {
+		end := min(start+manageBatchSize, len(filelist))
+		if _, err := d.manage(ctx, opera, filelist[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *BaiduNetdisk) moveOrCopyList(srcObjs []model.Obj, dstDir model.Obj) ([]base.Json, error) {
+	if d.isTrashDir(dstDir.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	data := make([]base.Json, 0, len(srcObjs))
+	for _, srcObj := range srcObjs {
+		if d.isTrashDir(srcObj.GetPath()) || d.isInTrash(srcObj.GetPath()) {
+			return nil, errs.NotSupport
+		}
+		newName, err := d.checkName(srcObj.GetName())
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, base.Json{
+			"path":    srcObj.GetPath(),
+			"dest":    dstDir.GetPath(),
+			"newname": newName,
+		})
+	}
+	return data, nil
+}
+
+func (d *BaiduNetdisk) BatchMove(ctx context.Context, srcObjs []model.Obj, dstDir model.Obj) error {
+	data, err := d.moveOrCopyList(srcObjs, dstDir)
+	if err != nil {
+		return err
+	}
+	return manageBatch(ctx, d, "move", data)
+}
+
+func (d *BaiduNetdisk) BatchCopy(ctx context.Context, srcObjs []model.Obj, dstDir model.Obj) error {
+	data, err := d.moveOrCopyList(srcObjs, dstDir)
+	if err != nil {
+		return err
+	}
+	return manageBatch(ctx, d, "copy", data)
+}
+
+func (d *BaiduNetdisk) BatchRename(ctx context.Context, srcObjs []model.Obj, newNames []string) error {
+	data := make([]base.Json, 0, len(srcObjs))
+	for i, srcObj := range srcObjs {
+		if d.isTrashDir(srcObj.GetPath()) || d.isInTrash(srcObj.GetPath()) {
+			return errs.NotSupport
+		}
+		newName, err := d.checkName(newNames[i])
+		if err != nil {
+			return err
+		}
+		data = append(data, base.Json{
+			"path":    srcObj.GetPath(),
+			"newname": newName,
+		})
+	}
+	return manageBatch(ctx, d, "rename", data)
+}
+
+func (d *BaiduNetdisk) BatchRemove(ctx context.Context, objs []model.Obj) error {
+	data := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		if d.isTrashDir(obj.GetPath()) || d.isInTrash(obj.GetPath()) {
+			return errs.NotSupport
+		}
+		data = append(data, obj.GetPath())
+	}
+	return manageBatch(ctx, d, "delete", data)
+}
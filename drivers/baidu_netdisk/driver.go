@@ -1,31 +1,37 @@
 package baidu_netdisk
 
 import (
+	"cmp"
 	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
-	"os"
 	stdpath "path"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/drivers/base"
-	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	streamPkg "github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/errgroup"
+	retryPkg "github.com/alist-org/alist/v3/pkg/retry"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/avast/retry-go"
 	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 type BaiduNetdisk struct {
@@ -33,17 +39,54 @@ type BaiduNetdisk struct {
 	Addition
 
 	uploadThread int
-	vipType      int // 会员类型，0普通用户(4G/4M)、1普通会员(10G/16M)、2超级会员(20G/32M)
+	vipType      int   // 会员类型，0普通用户(4G/4M)、1普通会员(10G/16M)、2超级会员(20G/32M)
+	uk           int64 // 用户 id，用于判断两个存储是否为同一账号
 
+	client              *resty.Client // 请求接口使用的http客户端
 	upClient            *resty.Client // 上传文件使用的http客户端
+	noRedirectClient    *resty.Client // 解析下载地址跳转使用的http客户端
 	uploadUrlG          singleflight.Group[string]
 	uploadUrlMu         sync.RWMutex
 	uploadUrl           string    // 上传域名
 	uploadUrlUpdateTime time.Time // 上传域名上次更新时间
+
+	tokenG       singleflight.Group[string] // 合并并发的 token 刷新
+	tokenMu      sync.Mutex
+	tokenInvalid error // refresh_token 失效后不再重试，需要重新授权
+
+	rootMu   sync.RWMutex
+	rootPath string // 按 RootFsID 解析出的根目录路径
+
+	limiter         *rate.Limiter         // API 请求限速，每个存储独立
+	adaptiveLimiter *base.AdaptiveLimiter // 触发频率限制后自动降速，上限为 APIRateLimit
+
+	// 上传和代理下载的速度限制，由所有上传线程和代理连接共享
+	uploadLimiter   *rate.Limiter
+	downloadLimiter *rate.Limiter
+
+	customHeaders http.Header // 附加到所有请求和下载链接的请求头
+
+	downloadAPIRules map[string]string   // 扩展名 => 下载接口
+	onlyListExts     map[string]struct{} // 只列出视频时额外列出的扩展名
+
+	linkCache  cache.ICache[*model.Link] // 按 fs_id 缓存的下载链接，重命名和移动后仍然有效
+	videoCache cache.ICache[*crackVideo] // 按 fs_id 缓存的 crack_video 链接及其播放列表
+
+	quotaMu         sync.Mutex
+	quota           *model.StorageDetails // 容量信息缓存
+	quotaUpdateTime time.Time
+
+	warmupCancel context.CancelFunc // 取消后台的目录缓存预热
+
+	tokenRenewed       chan struct{}      // token 刷新后通知后台刷新重新计算时间
+	tokenRefresherStop context.CancelFunc // 停止后台提前刷新 token
 }
 
 var ErrUploadIDExpired = errors.New("uploadid expired")
 
+// errUploadDomain 上传域名不可用（网络错误或 5xx），只有这种错误才换用其他上传域名继续
+var errUploadDomain = errors.New("upload domain unavailable")
+
 func (d *BaiduNetdisk) Config() driver.Config {
 	return config
 }
@@ -53,23 +96,84 @@ func (d *BaiduNetdisk) GetAddition() driver.Additional {
 }
 
 func (d *BaiduNetdisk) Init(ctx context.Context) error {
+	d.tokenMu.Lock()
+	d.tokenInvalid = nil
+	d.tokenMu.Unlock()
+	d.stopTokenRefresher()
+	d.tokenRenewed = make(chan struct{}, 1)
+	d.linkCache = cache.NewMemCache[*model.Link]()
+	d.videoCache = cache.NewMemCache[*crackVideo]()
+	// 每个存储使用独立的客户端，代理和证书设置不影响其他存储
+	if err := d.initUploadThread(); err != nil {
+		return err
+	}
+	clientOptions := base.ClientOptions{
+		Proxy:               d.HttpProxy,
+		CACert:              d.CACert,
+		InsecureSkipVerify:  d.TlsInsecureSkipVerify,
+		MaxIdleConnsPerHost: d.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(d.IdleConnTimeout) * time.Second,
+		DisableHTTP2:        d.DisableHTTP2,
+	}
+	// 上传并发连接同一个上传域名，保持足够的空闲连接避免每个分片重新建立连接
+	upClientOptions := clientOptions
+	upClientOptions.MaxIdleConnsPerHost = max(d.MaxIdleConnsPerHost, d.uploadThread)
+	d.client = base.NewRestyClient()
+	d.noRedirectClient = base.NewNoRedirectClient()
+	// 分片上传的重试由 uploadSlice 处理
 	d.upClient = base.NewRestyClient().
 		SetTimeout(UPLOAD_TIMEOUT).
-		SetRetryCount(UPLOAD_RETRY_COUNT).
-		SetRetryWaitTime(UPLOAD_RETRY_WAIT_TIME).
-		SetRetryMaxWaitTime(UPLOAD_RETRY_MAX_WAIT_TIME)
-	d.uploadThread, _ = strconv.Atoi(d.UploadThread)
-	if d.uploadThread < 1 {
-		d.uploadThread, d.UploadThread = 1, "1"
-	} else if d.uploadThread > 32 {
-		d.uploadThread, d.UploadThread = 32, "32"
+		SetRetryCount(0)
+	for client, options := range map[*resty.Client]base.ClientOptions{
+		d.client:           clientOptions,
+		d.noRedirectClient: clientOptions,
+		d.upClient:         upClientOptions,
+	} {
+		if err := options.Apply(client); err != nil {
+			return err
+		}
+		if d.Debug {
+			base.EnableDebugLog(client, "baidu_netdisk "+d.MountPath)
+		}
+	}
+	customHeaders, err := base.ParseHeaders(d.CustomHeaders)
+	if err != nil {
+		return err
+	}
+	d.customHeaders = customHeaders
+	d.downloadAPIRules, err = parseDownloadAPIRules(d.DownloadAPIRules)
+	if err != nil {
+		return err
+	}
+	d.onlyListExts = parseExtensions(d.OnlyListExtensions)
+	if d.RootFsID != "" && !utils.PathEqual(d.RootFolderPath, "/") {
+		return errors.New("root_folder_path and root_fs_id are mutually exclusive, keep root_folder_path as /")
+	}
+	if d.NamePolicy == base.NamePolicyReplace {
+		if err = d.nameRule().CheckSubstitute(d.NameSubstitute); err != nil {
+			return err
+		}
 	}
 
 	if _, err := url.Parse(d.UploadAPI); d.UploadAPI == "" || err != nil {
 		d.UploadAPI = UPLOAD_FALLBACK_API
 	}
 
-	res, err := d.get("/xpan/nas", map[string]string{
+	d.limiter, d.adaptiveLimiter = nil, nil
+	if d.AdaptiveRateLimit {
+		d.adaptiveLimiter = d.newAdaptiveLimiter()
+	} else if d.APIRateLimit > 0 {
+		d.limiter = rate.NewLimiter(rate.Limit(d.APIRateLimit), 1)
+	}
+	d.uploadLimiter = streamPkg.NewSpeedLimiter(d.UploadSpeedLimit)
+	d.downloadLimiter = streamPkg.NewSpeedLimiter(d.DownloadSpeedLimit)
+
+	if d.VerifyTokenAtInit {
+		if err = d.verifyToken(ctx); err != nil {
+			return err
+		}
+	}
+	res, err := d.get(ctx, "/xpan/nas", map[string]string{
 		"method": "uinfo",
 	}, nil)
 	log.Debugf("[baidu_netdisk] get uinfo: %s", string(res))
@@ -77,35 +181,372 @@ func (d *BaiduNetdisk) Init(ctx context.Context) error {
 		return err
 	}
 	d.vipType = utils.Json.Get(res, "vip_type").ToInt()
+	d.uk = utils.Json.Get(res, "uk").ToInt64()
+	if d.RootFsID != "" {
+		if _, err = d.resolveRoot(ctx); err != nil {
+			return fmt.Errorf("failed resolve root_fs_id: %w", err)
+		}
+	}
+	d.startTokenRefresher()
+	d.startWarmup()
+	if d.UseDynamicUploadAPI && d.ProbeUploadAPI {
+		go func() {
+			if _, _, err := d.remeasureUploadUrl(ctx, d.GetRootPath()); err != nil {
+				log.Warnf("[baidu_netdisk] failed probe the upload domains of [%s]: %v", d.MountPath, err)
+			}
+		}()
+	}
 	return nil
 }
 
+// SameBackend 同一账号的两个存储之间可以直接使用 filemanager copy
+func (d *BaiduNetdisk) SameBackend(other driver.Driver) bool {
+	o, ok := other.(*BaiduNetdisk)
+	return ok && d.uk != 0 && d.uk == o.uk
+}
+
 func (d *BaiduNetdisk) Drop(ctx context.Context) error {
+	d.stopWarmup()
+	d.stopTokenRefresher()
 	return nil
 }
 
 func (d *BaiduNetdisk) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
-	files, err := d.getFiles(dir.GetPath())
+	if d.isTrashDir(dir.GetPath()) {
+		return d.getTrashFiles(ctx)
+	}
+	files, err := d.getFiles(ctx, dir.GetPath())
+	if errs.IsObjectNotFound(err) && d.RootFsID != "" {
+		// 根目录的上级目录被重命名或移动时，重新解析根目录后再列出
+		if old, rerr := d.resolveRoot(ctx); rerr == nil && old != d.GetRootPath() {
+			op.ClearCache(d, "/")
+			files, err = d.getFiles(ctx, stdpath.Join(d.GetRootPath(), utils.FixAndCleanPath(strings.TrimPrefix(dir.GetPath(), old))))
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	return utils.SliceConvert(files, func(src File) (model.Obj, error) {
-		return fileToObj(src), nil
-	})
+	objs := d.filesToObjs(files)
+	if d.trashEnabled() && utils.PathEqual(dir.GetPath(), d.GetRootPath()) {
+		objs = append(objs, d.trashDirObj())
+	}
+	d.sortObjs(objs)
+	return objs, nil
+}
+
+// ListModifiedSince 百度网盘没有按修改时间筛选的接口，列出全部后按 server_mtime 过滤文件，文件夹全部保留
+func (d *BaiduNetdisk) ListModifiedSince(ctx context.Context, dir model.Obj, since time.Time) ([]model.Obj, error) {
+	if d.isTrashDir(dir.GetPath()) {
+		objs, err := d.getTrashFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return op.FilterModifiedSince(objs, since), nil
+	}
+	files, err := d.getFiles(ctx, dir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	cutoff := since.Unix()
+	filtered := make([]File, 0, len(files))
+	for _, f := range files {
+		mtime := f.ServerMtime
+		if mtime == 0 {
+			mtime = f.Mtime
+		}
+		if f.Isdir == 1 || mtime >= cutoff {
+			filtered = append(filtered, f)
+		}
+	}
+	objs := d.filesToObjs(filtered)
+	if d.trashEnabled() && utils.PathEqual(dir.GetPath(), d.GetRootPath()) {
+		objs = append(objs, d.trashDirObj())
+	}
+	d.sortObjs(objs)
+	return objs, nil
+}
+
+// ListPage 使用 list 接口的 start/limit 分页，cursor 为下一页的 start
+func (d *BaiduNetdisk) ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) ([]model.Obj, string, error) {
+	if d.isTrashDir(dir.GetPath()) {
+		objs, err := d.getTrashFiles(ctx)
+		return objs, "", err
+	}
+	start := 0
+	if args.Cursor != "" {
+		var err error
+		start, err = strconv.Atoi(args.Cursor)
+		if err != nil || start < 0 {
+			return nil, "", fmt.Errorf("invalid cursor: %s", args.Cursor)
+		}
+	}
+	limit := args.Limit
+	if limit <= 0 || limit > LIST_PAGE_MAX {
+		limit = LIST_PAGE_MAX
+	}
+	files, n, err := d.getFilesPage(ctx, dir.GetPath(), start, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	objs := d.filesToObjs(files)
+	if start == 0 && d.trashEnabled() && utils.PathEqual(dir.GetPath(), d.GetRootPath()) {
+		objs = append(objs, d.trashDirObj())
+	}
+	next := ""
+	if n == limit {
+		next = strconv.Itoa(start + limit)
+	}
+	return objs, next, nil
+}
+
+// ListRecursive 使用 listall 接口递归列出 dir 下的全部文件，按 cursor 分页。
+// depth 为 0 时不限制深度，否则只保留相对路径中 "/" 少于 depth 个的文件
+func (d *BaiduNetdisk) ListRecursive(ctx context.Context, dir model.Obj, depth int, fn func(path string, obj model.Obj) error) error {
+	if d.isTrashDir(dir.GetPath()) {
+		return errs.NotImplement
+	}
+	cursor := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var resp ListAllResp
+		callCtx, cancel := driver.CallContext(ctx)
+		_, err := d.get(callCtx, "/xpan/multimedia", map[string]string{
+			"method":    "listall",
+			"path":      dir.GetPath(),
+			"recursion": "1",
+			"web":       "1",
+			"start":     strconv.Itoa(cursor),
+			"limit":     strconv.Itoa(LIST_PAGE_MAX),
+		}, &resp)
+		cancel()
+		if err != nil {
+			return err
+		}
+		files := make([]File, 0, len(resp.List))
+		for _, file := range resp.List {
+			if !d.listed(file) {
+				continue
+			}
+			rel := strings.TrimPrefix(strings.TrimPrefix(file.Path, dir.GetPath()), "/")
+			if rel == "" || depth > 0 && strings.Count(rel, "/") >= depth {
+				continue
+			}
+			files = append(files, file)
+		}
+		for _, obj := range d.filesToObjs(files) {
+			rel := strings.TrimPrefix(strings.TrimPrefix(obj.GetPath(), dir.GetPath()), "/")
+			if err := fn(rel, obj); err != nil {
+				return err
+			}
+		}
+		if resp.HasMore != 1 || resp.Cursor <= cursor {
+			return nil
+		}
+		cursor = resp.Cursor
+	}
+}
+
+// SearchName 使用 search 接口按文件名搜索，百度按关键词模糊匹配，由调用方按名称再筛选
+func (d *BaiduNetdisk) SearchName(ctx context.Context, dir model.Obj, keyword string, recursive bool) ([]model.Obj, error) {
+	if d.isTrashDir(dir.GetPath()) {
+		return nil, errs.NotImplement
+	}
+	recursion := "0"
+	if recursive {
+		recursion = "1"
+	}
+	var files []File
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var resp SearchResp
+		_, err := d.get(ctx, "/xpan/file", map[string]string{
+			"method":    "search",
+			"key":       keyword,
+			"dir":       dir.GetPath(),
+			"recursion": recursion,
+			"web":       "1",
+			"page":      strconv.Itoa(page),
+			"num":       strconv.Itoa(LIST_PAGE_MAX),
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range resp.List {
+			if d.listed(file) {
+				files = append(files, file)
+			}
+		}
+		if resp.HasMore != 1 || len(resp.List) == 0 {
+			break
+		}
+	}
+	return d.filesToObjs(files), nil
+}
+
+func (d *BaiduNetdisk) filesToObjs(files []File) []model.Obj {
+	thumbSize := d.ThumbnailSize
+	if thumbSize <= 0 {
+		thumbSize = 850
+	}
+	objs := make([]model.Obj, 0, len(files))
+	for _, src := range files {
+		obj := fileToObj(src)
+		obj.Thumbnail.Thumbnail = src.thumbnail(thumbSize)
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// GetByID 通过 filemetas 按 fs_id 获取文件信息，不需要解析路径，根目录之外的文件视为不存在
+func (d *BaiduNetdisk) GetByID(ctx context.Context, id string) (model.Obj, string, error) {
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return nil, "", fmt.Errorf("%w: invalid fs_id [%s]", errs.ObjectNotFound, id)
+	}
+	var resp struct {
+		List []File `json:"list"`
+	}
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/xpan/multimedia", http.MethodGet, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"method": "filemetas",
+			"fsids":  fmt.Sprintf("[%s]", id),
+			"thumb":  "1",
+		})
+	}, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(resp.List) == 0 {
+		return nil, "", fmt.Errorf("%w: fs_id [%s]", errs.ObjectNotFound, id)
+	}
+	file := resp.List[0]
+	root := d.GetRootPath()
+	if !utils.IsSubPath(root, file.Path) {
+		return nil, "", fmt.Errorf("%w: fs_id [%s] is out of the root", errs.ObjectNotFound, id)
+	}
+	return d.filesToObjs([]File{file})[0], strings.TrimPrefix(file.Path, root), nil
+}
+
+// SetsMtime 上传时通过 local_mtime 保留文件的修改时间
+func (d *BaiduNetdisk) SetsMtime() bool {
+	return true
+}
+
+func (d *BaiduNetdisk) ListFilterKey() string {
+	if !d.OnlyListVideoFile {
+		return ""
+	}
+	if len(d.onlyListExts) == 0 {
+		return "only_list_video_file"
+	}
+	// 扩展名修改后筛选结果不同，不能复用之前的缓存
+	return "only_list_video_file:" + d.OnlyListExtensions
 }
 
 func (d *BaiduNetdisk) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
-	if d.DownloadAPI == "crack" {
-		return d.linkCrack(file, args)
-	} else if d.DownloadAPI == "crack_video" {
-		return d.linkCrackVideo(file, args)
+	if d.isInTrash(file.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	if historyId, ok := strings.CutPrefix(args.Type, VERSION_LINK_TYPE); ok {
+		if !d.FileHistory {
+			return nil, errs.NotSupport
+		}
+		return d.versionLink(ctx, file, historyId)
+	}
+	api := d.downloadAPI(file)
+	if api == "crack_video" {
+		link, err := d.linkVideo(ctx, file, args)
+		if err != nil {
+			return nil, err
+		}
+		link.DownloadLimiter = d.downloadLimiter
+		return link, nil
+	}
+	key := file.GetID() + ":" + api
+	if link, ok := d.linkCache.Get(key); ok {
+		return link, nil
+	}
+	link, err := d.link(ctx, file, api, args)
+	if err == nil && link.Expiration != nil {
+		// 提前过期，保证取出的链接在使用时仍然有效
+		d.linkCache.Set(key, link, cache.WithEx[*model.Link](*link.Expiration-min(time.Minute, *link.Expiration/10)))
+	}
+	return link, err
+}
+
+func (d *BaiduNetdisk) link(ctx context.Context, file model.Obj, api string, args model.LinkArgs) (*model.Link, error) {
+	var (
+		link *model.Link
+		err  error
+	)
+	switch api {
+	case "crack":
+		link, err = d.linkCrack(ctx, file, args)
+	default:
+		link, err = d.linkOfficial(ctx, file, args)
+		if err != nil {
+			return nil, err
+		}
+		link.Header = base.MergeHeaders(link.Header, d.customHeaders)
+		link.DownloadLimiter = d.downloadLimiter
+		d.setLinkExpiration(link)
+		return link, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	link.Header = base.MergeHeaders(link.Header, d.customHeaders)
+	link.DownloadLimiter = d.downloadLimiter
+	d.setLinkExpiration(link)
+	// crack 链接单连接限速严重，远端支持 Range 时使用多线程分段下载
+	if d.DownloadConcurrency > 1 && d.supportRange(ctx, link) {
+		link.Concurrency = d.DownloadConcurrency
+		link.PartSize = int(DOWNLOAD_PART_SIZE)
 	}
-	return d.linkOfficial(file, args)
+	return link, nil
 }
 
 func (d *BaiduNetdisk) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) (model.Obj, error) {
+	if d.isTrashDir(parentDir.GetPath()) || d.isInTrash(parentDir.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	dirName, err := d.checkName(dirName)
+	if err != nil {
+		return nil, err
+	}
+	var newDir File
+	// 文件夹已存在时返回 -8，即 errs.ObjectExists，而不是覆盖或重命名
+	_, err = d.create(ctx, stdpath.Join(parentDir.GetPath(), dirName), 0, 1, RTYPE_FAIL, "", "", &newDir, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return fileToObj(newDir), nil
+}
+
+// MakeDirAll 创建多级文件夹，百度的 create 接口会自动创建缺少的上级文件夹，所以只需一次请求
+func (d *BaiduNetdisk) MakeDirAll(ctx context.Context, dirPath string) (model.Obj, error) {
+	if utils.PathEqual(dirPath, "/") {
+		// 根目录总是存在
+		return nil, nil
+	}
+	var names []string
+	for _, name := range strings.Split(strings.Trim(dirPath, "/"), "/") {
+		name, err := d.checkName(name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	path := stdpath.Join(d.GetRootPath(), stdpath.Join(names...))
+	if d.trashEnabled() && utils.IsSubPath(d.trashDir(), path) {
+		return nil, errs.NotSupport
+	}
 	var newDir File
-	_, err := d.create(stdpath.Join(parentDir.GetPath(), dirName), 0, 1, "", "", &newDir, 0, 0)
+	// 已存在时返回 -8，即 errs.ObjectExists
+	_, err := d.create(ctx, path, 0, 1, RTYPE_FAIL, "", "", &newDir, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -113,19 +554,27 @@ func (d *BaiduNetdisk) MakeDir(ctx context.Context, parentDir model.Obj, dirName
 }
 
 func (d *BaiduNetdisk) Move(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	if d.isTrashDir(srcObj.GetPath()) || d.isInTrash(srcObj.GetPath()) || d.isTrashDir(dstDir.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	newName, err := d.checkName(srcObj.GetName())
+	if err != nil {
+		return nil, err
+	}
 	data := []base.Json{
 		{
 			"path":    srcObj.GetPath(),
 			"dest":    dstDir.GetPath(),
-			"newname": srcObj.GetName(),
+			"newname": newName,
 		},
 	}
-	_, err := d.manage("move", data)
+	_, err = d.manage(ctx, "move", data)
 	if err != nil {
 		return nil, err
 	}
 	if srcObj, ok := srcObj.(*model.ObjThumb); ok {
-		srcObj.SetPath(stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+		srcObj.SetPath(stdpath.Join(dstDir.GetPath(), newName))
+		srcObj.Name = newName
 		srcObj.Modified = time.Now()
 		return srcObj, nil
 	}
@@ -133,17 +582,25 @@ func (d *BaiduNetdisk) Move(ctx context.Context, srcObj, dstDir model.Obj) (mode
 }
 
 func (d *BaiduNetdisk) Rename(ctx context.Context, srcObj model.Obj, newName string) (model.Obj, error) {
+	if d.isTrashDir(srcObj.GetPath()) || d.isInTrash(srcObj.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	newName, err := d.checkName(newName)
+	if err != nil {
+		return nil, err
+	}
 	data := []base.Json{
 		{
 			"path":    srcObj.GetPath(),
 			"newname": newName,
 		},
 	}
-	_, err := d.manage("rename", data)
+	_, err = d.manage(ctx, "rename", data)
 	if err != nil {
 		return nil, err
 	}
 
+	// 重命名不改变 fs_id，直接更新缓存中的对象，按 fs_id 缓存的链接也仍然有效
 	if srcObj, ok := srcObj.(*model.ObjThumb); ok {
 		srcObj.SetPath(stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName))
 		srcObj.Name = newName
@@ -153,44 +610,98 @@ func (d *BaiduNetdisk) Rename(ctx context.Context, srcObj model.Obj, newName str
 	return nil, nil
 }
 
+// ListOrder 列表按 order_by 排序（见 sortObjs），重命名后据此调整对象在缓存列表中的位置
+func (d *BaiduNetdisk) ListOrder() (string, string) {
+	orderBy := d.OrderBy
+	if orderBy == "time" {
+		orderBy = "modified"
+	}
+	return orderBy, d.OrderDirection
+}
+
 func (d *BaiduNetdisk) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if d.isTrashDir(srcObj.GetPath()) || d.isInTrash(srcObj.GetPath()) || d.isTrashDir(dstDir.GetPath()) {
+		return errs.NotSupport
+	}
+	newName, err := d.checkName(srcObj.GetName())
+	if err != nil {
+		return err
+	}
 	data := []base.Json{
 		{
 			"path":    srcObj.GetPath(),
 			"dest":    dstDir.GetPath(),
-			"newname": srcObj.GetName(),
+			"newname": newName,
 		},
 	}
-	_, err := d.manage("copy", data)
+	_, err = d.manage(ctx, "copy", data)
 	return err
 }
 
 func (d *BaiduNetdisk) Remove(ctx context.Context, obj model.Obj) error {
+	if d.isTrashDir(obj.GetPath()) || d.isInTrash(obj.GetPath()) {
+		return errs.NotSupport
+	}
 	data := []string{obj.GetPath()}
-	_, err := d.manage("delete", data)
+	_, err := d.manage(ctx, "delete", data)
 	return err
 }
 
+// RemovePermanently 删除后再从回收站中删除，回收站中的条目直接删除
+func (d *BaiduNetdisk) RemovePermanently(ctx context.Context, obj model.Obj) error {
+	if d.isTrashDir(obj.GetPath()) {
+		return errs.NotSupport
+	}
+	if !d.isInTrash(obj.GetPath()) {
+		if _, err := d.manage(ctx, "delete", []string{obj.GetPath()}); err != nil {
+			return err
+		}
+	}
+	return d.deleteTrash(ctx, []string{obj.GetID()})
+}
+
+func (d *BaiduNetdisk) RapidHashTypes() []*utils.HashType {
+	return []*utils.HashType{utils.MD5}
+}
+
+// PutRapid 使用已知的 md5 秒传，不读取文件内容。op.Put 在流带有 md5 时先调用，失败后再调用 Put，
+// 例如从其他存储复制时使用源文件的 md5。
+// 只有一个分片的文件 block_list 就是 content-md5；更大的文件需要真实的分片 md5，
+// 由 Put 计算后在唯一的一次 precreate 中秒传
 func (d *BaiduNetdisk) PutRapid(ctx context.Context, dstDir model.Obj, stream model.FileStreamer) (model.Obj, error) {
+	if d.isTrashDir(dstDir.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	if stream.GetSize() > d.getSliceSize(stream.GetSize()) {
+		return nil, errs.NotSupport
+	}
+	stream, err := d.renameStream(stream)
+	if err != nil {
+		return nil, err
+	}
 	contentMd5 := stream.GetHash().GetHash(utils.MD5)
 	if len(contentMd5) < utils.MD5.Width {
 		return nil, errors.New("invalid hash")
 	}
+	return d.createByMd5(ctx, dstDir, stream, contentMd5)
+}
 
+// createByMd5 以 content-md5 作为唯一的 block_list 调用 create，不上传分片，只适用于不超过一个分片的文件
+func (d *BaiduNetdisk) createByMd5(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, contentMd5 string) (model.Obj, error) {
 	streamSize := stream.GetSize()
 	path := stdpath.Join(dstDir.GetPath(), stream.GetName())
-	mtime := stream.ModTime().Unix()
-	ctime := stream.CreateTime().Unix()
+	ctime, mtime := streamTime(stream)
 	blockList, _ := utils.Json.MarshalToString([]string{contentMd5})
 
 	var newFile File
-	_, err := d.create(path, streamSize, 0, "", blockList, &newFile, mtime, ctime)
+	_, err := d.create(ctx, path, streamSize, 0, d.uploadRtype(stream), "", blockList, &newFile, mtime, ctime)
 	if err != nil {
 		return nil, err
 	}
 	// 修复时间，具体原因见 Put 方法注释的 **注意**
-	newFile.Ctime = stream.CreateTime().Unix()
-	newFile.Mtime = stream.ModTime().Unix()
+	newFile.Ctime = ctime
+	newFile.Mtime = mtime
+	newFile.Size = streamSize
 	return fileToObj(newFile), nil
 }
 
@@ -199,31 +710,24 @@ func (d *BaiduNetdisk) PutRapid(ctx context.Context, dstDir model.Obj, stream mo
 // **注意**: 截至 2024/04/20 百度云盘 api 接口返回的时间永远是当前时间，而不是文件时间。
 // 而实际上云盘存储的时间是文件时间，所以此处需要覆盖时间，保证缓存与云盘的数据一致
 func (d *BaiduNetdisk) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) (model.Obj, error) {
-	// 百度网盘不允许上传空文件
-	if stream.GetSize() < 1 {
-		return nil, ErrBaiduEmptyFilesNotAllowed
+	if d.isTrashDir(dstDir.GetPath()) {
+		return nil, errs.NotSupport
 	}
-
-	// rapid upload
-	if newObj, err := d.PutRapid(ctx, dstDir, stream); err == nil {
-		return newObj, nil
+	// 包装后的流不再提供来源，需要在改名前取得
+	source := streamPkg.Source(stream)
+	stream, err := d.renameStream(stream)
+	if err != nil {
+		return nil, err
 	}
-
-	var (
-		cache = stream.GetFile()
-		tmpF  *os.File
-		err   error
-	)
-	if _, ok := cache.(io.ReaderAt); !ok {
-		tmpF, err = os.CreateTemp(conf.Conf.TempDir, "file-*")
-		if err != nil {
+	// 大小未知的流需要先缓存到临时文件，才能计算 content-md5 与分片 md5
+	if stream.GetSize() < 0 {
+		if _, err := stream.CacheFullInTempFile(); err != nil {
 			return nil, err
 		}
-		defer func() {
-			_ = tmpF.Close()
-			_ = os.Remove(tmpF.Name())
-		}()
-		cache = tmpF
+	}
+	// 空文件没有分片，不能走 precreate 和上传分片，以空内容的 md5 直接 create
+	if stream.GetSize() == 0 {
+		return d.createByMd5(ctx, dstDir, stream, EMPTY_FILE_MD5)
 	}
 
 	streamSize := stream.GetSize()
@@ -236,57 +740,58 @@ func (d *BaiduNetdisk) Put(ctx context.Context, dstDir model.Obj, stream model.F
 		lastBlockSize = sliceSize
 	}
 
-	//cal md5 for first 256k data
-	const SliceSize int64 = 256 * utils.KB
-	// cal md5
-	blockList := make([]string, 0, count)
-	byteSize := sliceSize
-	fileMd5H := md5.New()
-	sliceMd5H := md5.New()
-	sliceMd5H2 := md5.New()
-	slicemd5H2Write := utils.LimitWriter(sliceMd5H2, SliceSize)
-	writers := []io.Writer{fileMd5H, sliceMd5H, slicemd5H2Write}
-	if tmpF != nil {
-		writers = append(writers, tmpF)
-	}
-	written := int64(0)
-
-	for i := 1; i <= count; i++ {
-		if utils.IsCanceled(ctx) {
-			return nil, ctx.Err()
-		}
-		if i == count {
-			byteSize = lastBlockSize
-		}
-		n, err := utils.CopyWithBufferN(io.MultiWriter(writers...), stream, byteSize)
-		written += n
-		if err != nil && err != io.EOF {
+	// cal md5，已缓存时只需把流缓存到临时文件
+	blockHash, hashed := d.getBlockHash(source, stream, sliceSize)
+	var cache model.File
+	if hashed {
+		if cache, err = stream.CacheFullInTempFile(); err != nil {
 			return nil, err
 		}
-		blockList = append(blockList, hex.EncodeToString(sliceMd5H.Sum(nil)))
-		sliceMd5H.Reset()
-	}
-	if tmpF != nil {
-		if written != streamSize {
-			return nil, errs.NewErr(err, "CreateTempFile failed, size mismatch: %d != %d ", written, streamSize)
+	} else {
+		// 缓存流的同一次读取中计算 content-md5、分片 md5 和 slice-md5，
+		// 流已有 content-md5 时（如复制校验已计算）不再重复计算
+		knownMd5 := streamPkg.KnownHashes(stream)[utils.MD5]
+		var types []*utils.HashType
+		if knownMd5 == "" {
+			types = []*utils.HashType{utils.MD5}
 		}
-		_, err = tmpF.Seek(0, io.SeekStart)
+		blocks := newBlockHasher(sliceSize, count)
+		sliceMd5H := md5.New()
+		var h utils.HashInfo
+		cache, h, err = streamPkg.CacheFullInTempFileAndHashes(stream, types, blocks, utils.LimitWriter(sliceMd5H, SliceMd5Size))
 		if err != nil {
-			return nil, errs.NewErr(err, "CreateTempFile failed, can't seek to 0 ")
+			return nil, err
+		}
+		blockHash = BlockHash{
+			ContentMd5: cmp.Or(knownMd5, h.GetHash(utils.MD5)),
+			SliceMd5:   hex.EncodeToString(sliceMd5H.Sum(nil)),
+			BlockList:  blocks.blockList(),
 		}
+		d.saveBlockHash(source, stream, sliceSize, blockHash)
+	}
+	contentMd5, sliceMd5, blockList := blockHash.ContentMd5, blockHash.SliceMd5, blockHash.BlockList
+	// 目标文件已存在且内容相同时跳过上传，复用秒传计算的 content-md5
+	if exist := stream.GetExist(); exist != nil && streamPkg.SkipIfMatch(stream) && exist.GetSize() == streamSize &&
+		strings.EqualFold(exist.GetHash().GetHash(utils.MD5), contentMd5) {
+		return exist, nil
 	}
-	contentMd5 := hex.EncodeToString(fileMd5H.Sum(nil))
-	sliceMd5 := hex.EncodeToString(sliceMd5H2.Sum(nil))
 	blockListStr, _ := utils.Json.MarshalToString(blockList)
 	path := stdpath.Join(dstDir.GetPath(), stream.GetName())
-	mtime := stream.ModTime().Unix()
-	ctime := stream.CreateTime().Unix()
+	ctime, mtime := streamTime(stream)
+	rtype := d.uploadRtype(stream)
 
-	// step.1 尝试读取已保存进度
+	// step.1 尝试读取已保存进度，内存中没有时读取重启前持久化的进度
+	stateKeys := []string{strconv.FormatUint(uint64(d.ID), 10), path, contentMd5, strconv.FormatInt(streamSize, 10)}
 	precreateResp, ok := base.GetUploadProgress[*PrecreateResp](d, d.AccessToken, contentMd5)
+	if !ok {
+		precreateResp, ok = base.GetPersistentUploadProgress[*PrecreateResp](d, stateKeys...)
+		if ok {
+			log.Infof("[baidu_netdisk] resume upload of %s, %d slices left", path, len(precreateResp.BlockList))
+		}
+	}
 	if !ok {
 		// 没有进度，走预上传
-		precreateResp, err = d.precreate(ctx, path, streamSize, blockListStr, contentMd5, sliceMd5, ctime, mtime)
+		precreateResp, err = d.precreate(ctx, path, rtype, streamSize, blockListStr, contentMd5, sliceMd5, ctime, mtime)
 		if err != nil {
 			return nil, err
 		}
@@ -295,13 +800,19 @@ func (d *BaiduNetdisk) Put(ctx context.Context, dstDir model.Obj, stream model.F
 			// 修复时间，具体原因见 Put 方法注释的 **注意**
 			return fileToObj(precreateResp.File), nil
 		}
+		d.savePersistentProgress(precreateResp, stateKeys)
 	}
+	// 每个分片完成后持久化剩余的分片，分片并发完成，需要加锁
+	var stateMu sync.Mutex
 
 	// step.2 上传分片
+	// uploadid 与上传域名无关，域名失败时剩余的分片换一个域名继续上传
+	uploadUrl := d.getUploadUrl(ctx, path, precreateResp.Uploadid)
+	var failedUrls []string
+	restarted := false
 uploadLoop:
-	for attempt := 0; attempt < 2; attempt++ {
-		// 获取上传域名
-		uploadUrl := d.getUploadUrl(path, precreateResp.Uploadid)
+	for {
+		log.Debugf("[baidu_netdisk] upload slices of [%s] to %s", path, uploadUrl)
 		// 并发上传
 		threadG, upCtx := errgroup.NewGroupWithContext(ctx, d.uploadThread,
 			retry.Attempts(1),
@@ -313,7 +824,17 @@ uploadLoop:
 			return nil, fmt.Errorf("cache object must implement io.ReaderAt interface for upload operations")
 		}
 
-		totalParts := len(precreateResp.BlockList)
+		// 按已完成的字节数上报进度，续传时已上传的分片直接计入
+		progress := driver.NewChunkProgress(streamSize, up)
+		remaining := int64(0)
+		for _, partseq := range precreateResp.BlockList {
+			if partseq+1 == count {
+				remaining += lastBlockSize
+			} else {
+				remaining += sliceSize
+			}
+		}
+		progress.Add(streamSize - remaining)
 		for i, partseq := range precreateResp.BlockList {
 			if utils.IsCanceled(upCtx) || partseq < 0 {
 				continue
@@ -334,15 +855,15 @@ uploadLoop:
 					"partseq":      strconv.Itoa(partseq),
 				}
 				section := io.NewSectionReader(cacheReaderAt, offset, size)
-				err := d.uploadSlice(ctx, uploadUrl, params, stream.GetName(), driver.NewLimitedUploadStream(ctx, section))
+				_, err := d.uploadSlice(ctx, uploadUrl, params, stream.GetName(), section, blockList[partseq])
 				if err != nil {
 					return err
 				}
+				stateMu.Lock()
 				precreateResp.BlockList[i] = -1
-				// 当前goroutine还没退出，+1才是真正成功的数量
-				success := threadG.Success() + 1
-				progress := float64(success) * 100 / float64(totalParts)
-				up(progress)
+				d.savePersistentProgress(precreateResp, stateKeys)
+				stateMu.Unlock()
+				progress.Add(size)
 				return nil
 			})
 		}
@@ -351,18 +872,21 @@ uploadLoop:
 		if err == nil {
 			break uploadLoop
 		}
+		// 取消时正在上传的分片请求也会随 ctx 中止
+		if ctx.Err() != nil {
+			d.dropUploadProgress(contentMd5, stateKeys)
+			return nil, ctx.Err()
+		}
 
-		// 保存进度（所有错误都会保存）
+		// 保存进度（取消以外的错误都会保存）
 		precreateResp.BlockList = utils.SliceFilter(precreateResp.BlockList, func(s int) bool { return s >= 0 })
 		base.SaveUploadProgress(d, precreateResp, d.AccessToken, contentMd5)
 
-		if errors.Is(err, context.Canceled) {
-			return nil, err
-		}
-		if errors.Is(err, ErrUploadIDExpired) {
+		if errors.Is(err, ErrUploadIDExpired) && !restarted {
+			restarted = true
 			log.Warn("[baidu_netdisk] uploadid expired, will restart from scratch")
 			// 重新 precreate（所有分片都要重传）
-			newPre, err2 := d.precreate(ctx, path, streamSize, blockListStr, "", "", ctime, mtime)
+			newPre, err2 := d.precreate(ctx, path, rtype, streamSize, blockListStr, contentMd5, sliceMd5, ctime, mtime)
 			if err2 != nil {
 				return nil, err2
 			}
@@ -372,14 +896,28 @@ uploadLoop:
 			precreateResp = newPre
 			// 覆盖掉旧的进度
 			base.SaveUploadProgress(d, precreateResp, d.AccessToken, contentMd5)
+			d.savePersistentProgress(precreateResp, stateKeys)
 			continue uploadLoop
 		}
+		if errors.Is(err, errUploadDomain) {
+			failedUrls = append(failedUrls, uploadUrl)
+			if next := d.nextUploadUrl(ctx, path, precreateResp.Uploadid, failedUrls); next != "" {
+				log.Warnf("[baidu_netdisk] upload slices of [%s] to %s failed: %v, continue the remaining slices on %s",
+					path, uploadUrl, err, next)
+				uploadUrl = next
+				continue uploadLoop
+			}
+		}
 		return nil, err
 	}
 
-	// step.3 创建文件
+	// step.3 创建文件，已取消时不再 create，网盘中不会出现不完整的文件
+	if err = ctx.Err(); err != nil {
+		d.dropUploadProgress(contentMd5, stateKeys)
+		return nil, err
+	}
 	var newFile File
-	_, err = d.create(path, streamSize, 0, precreateResp.Uploadid, blockListStr, &newFile, mtime, ctime)
+	_, err = d.create(ctx, path, streamSize, 0, rtype, precreateResp.Uploadid, blockListStr, &newFile, mtime, ctime)
 	if err != nil {
 		return nil, err
 	}
@@ -387,23 +925,32 @@ uploadLoop:
 	newFile.Ctime = ctime
 	newFile.Mtime = mtime
 	// 上传成功清理进度
-	base.SaveUploadProgress(d, nil, d.AccessToken, contentMd5)
+	d.dropUploadProgress(contentMd5, stateKeys)
 	return fileToObj(newFile), nil
 }
 
-// precreate 执行预上传操作，支持首次上传和 uploadid 过期重试
-func (d *BaiduNetdisk) precreate(ctx context.Context, path string, streamSize int64, blockListStr, contentMd5, sliceMd5 string, ctime, mtime int64) (*PrecreateResp, error) {
+// dropUploadProgress 删除内存中和持久化的上传进度，不再续传这个 uploadid。
+// 百度没有取消 uploadid 的接口，未 create 的分片会在 uploadid 过期后被清理
+func (d *BaiduNetdisk) dropUploadProgress(contentMd5 string, stateKeys []string) {
+	base.SaveUploadProgress(d, nil, d.AccessToken, contentMd5)
+	if err := base.SavePersistentUploadProgress(d, nil, 0, stateKeys...); err != nil {
+		log.Warnf("[baidu_netdisk] failed remove upload state of %s: %+v", stateKeys[1], err)
+	}
+}
+
+// precreate 执行预上传操作，支持首次上传和 uploadid 过期重试。
+// block_list 必须是真实的分片 md5，百度按 content-md5 和 slice-md5 判断能否秒传
+func (d *BaiduNetdisk) precreate(ctx context.Context, path, rtype string, streamSize int64, blockListStr, contentMd5, sliceMd5 string, ctime, mtime int64) (*PrecreateResp, error) {
 	params := map[string]string{"method": "precreate"}
 	form := map[string]string{
 		"path":       path,
 		"size":       strconv.FormatInt(streamSize, 10),
 		"isdir":      "0",
 		"autoinit":   "1",
-		"rtype":      "3",
+		"rtype":      rtype,
 		"block_list": blockListStr,
 	}
 
-	// 只有在首次上传时才包含 content-md5 和 slice-md5
 	if contentMd5 != "" && sliceMd5 != "" {
 		form["content-md5"] = contentMd5
 		form["slice-md5"] = sliceMd5
@@ -412,7 +959,10 @@ func (d *BaiduNetdisk) precreate(ctx context.Context, path string, streamSize in
 	joinTime(form, ctime, mtime)
 
 	var precreateResp PrecreateResp
-	_, err := d.postForm("/xpan/file", params, form, &precreateResp)
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/xpan/file", http.MethodPost, func(req *resty.Request) {
+		req.SetQueryParams(params)
+		req.SetFormData(form)
+	}, &precreateResp)
 	if err != nil {
 		return nil, err
 	}
@@ -426,29 +976,186 @@ func (d *BaiduNetdisk) precreate(ctx context.Context, path string, streamSize in
 	return &precreateResp, nil
 }
 
-func (d *BaiduNetdisk) uploadSlice(ctx context.Context, uploadUrl string, params map[string]string, fileName string, file io.Reader) error {
-	res, err := d.upClient.R().
+// uploadSlice 上传分片，返回百度计算的分片 md5，与 block_list 中的 expectedMd5 不一致时重传，expectedMd5 为空时由调用方校验。
+// 失败时指数退避并加入随机抖动重试，避免并发分片同时重试触发限流
+func (d *BaiduNetdisk) uploadSlice(ctx context.Context, uploadUrl string, params map[string]string, fileName string, section *io.SectionReader, expectedMd5 string) (string, error) {
+	var sliceMd5 string
+	err := retryPkg.Do(ctx, retryPkg.Options{
+		Attempts:  UPLOAD_RETRY_COUNT + 1,
+		BaseDelay: UPLOAD_RETRY_WAIT_TIME,
+		MaxDelay:  UPLOAD_RETRY_MAX_WAIT_TIME,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, ErrUploadIDExpired)
+		},
+		OnRetry: func(int, error) {
+			metrics.IncRetry(d)
+		},
+	}, func() error {
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		// 先按存储限速，再按服务器的上传限速
+		var err error
+		sliceMd5, err = d._uploadSlice(ctx, uploadUrl, params, fileName, driver.NewLimitedUploadStream(ctx, &driver.RateLimitReader{
+			Reader:  section,
+			Limiter: streamPkg.SpeedLimiter(d.uploadLimiter),
+			Ctx:     ctx,
+		}))
+		if err == nil && expectedMd5 != "" && sliceMd5 != "" && !strings.EqualFold(sliceMd5, expectedMd5) {
+			return errs.NewErr(errs.StreamIncomplete, "md5 of the uploaded slice is %s, expected %s", sliceMd5, expectedMd5)
+		}
+		return err
+	})
+	return sliceMd5, err
+}
+
+func (d *BaiduNetdisk) _uploadSlice(ctx context.Context, uploadUrl string, params map[string]string, fileName string, file io.Reader) (string, error) {
+	res, err := base.SetHeaders(d.upClient.R(), d.customHeaders).
 		SetContext(ctx).
 		SetQueryParams(params).
 		SetFileReader("file", fileName, file).
 		Post(uploadUrl + "/rest/2.0/pcs/superfile2")
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %w", errUploadDomain, err)
 	}
 	log.Debugln(res.RawResponse.Status + res.String())
+	if res.StatusCode() == http.StatusTooManyRequests {
+		return "", retryPkg.NewAfterError(res.RawResponse, nil)
+	}
+	if res.StatusCode() >= http.StatusInternalServerError {
+		return "", fmt.Errorf("%w: %w", errUploadDomain, retryPkg.NewAfterError(res.RawResponse, errors.New(res.RawResponse.Status)))
+	}
 	errCode := utils.Json.Get(res.Body(), "error_code").ToInt()
 	errNo := utils.Json.Get(res.Body(), "errno").ToInt()
 	respStr := res.String()
 	lower := strings.ToLower(respStr)
 	if strings.Contains(lower, "uploadid") &&
 		(strings.Contains(lower, "invalid") || strings.Contains(lower, "expired") || strings.Contains(lower, "not found")) {
-		return ErrUploadIDExpired
+		return "", ErrUploadIDExpired
 	}
 
 	if errCode != 0 || errNo != 0 {
-		return errs.NewErr(errs.StreamIncomplete, "error uploading to baidu, response=%s", res.String())
+		return "", errs.NewErr(errs.StreamIncomplete, "error uploading to baidu, response=%s", res.String())
+	}
+	return utils.Json.Get(res.Body(), "md5").ToString(), nil
+}
+
+func (d *BaiduNetdisk) WaitLimit(ctx context.Context) error {
+	if d.adaptiveLimiter != nil {
+		return d.adaptiveLimiter.Wait(ctx)
+	}
+	if d.limiter != nil {
+		return d.limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// GetDetails 获取网盘容量，结果缓存 QUOTA_CACHE_TIME
+func (d *BaiduNetdisk) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	d.quotaMu.Lock()
+	defer d.quotaMu.Unlock()
+	if d.quota != nil && time.Since(d.quotaUpdateTime) < QUOTA_CACHE_TIME {
+		return d.quota, nil
+	}
+	var resp QuotaResp
+	_, err := d.request(ctx, "https://pan.baidu.com/api/quota", http.MethodGet, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"checkfree":   "1",
+			"checkexpire": "1",
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	d.quota = &model.StorageDetails{
+		TotalSpace: resp.Total,
+		UsedSpace:  resp.Used,
+	}
+	d.quotaUpdateTime = time.Now()
+	return d.quota, nil
+}
+
+// OfflineDownload 添加云端离线下载任务，由百度服务器下载到 dstDir，返回任务 id
+func (d *BaiduNetdisk) OfflineDownload(ctx context.Context, uri string, dstDir model.Obj) (string, error) {
+	var resp CloudDlAddResp
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/services/cloud_dl", http.MethodPost, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"method": "add_task",
+			"app_id": "250528",
+		})
+		req.SetFormData(map[string]string{
+			"source_url": uri,
+			"save_path":  dstDir.GetPath(),
+		})
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.TaskId == 0 {
+		return "", errors.New("baidu returned empty task id")
+	}
+	return strconv.FormatInt(resp.TaskId, 10), nil
+}
+
+// OfflineTaskInfo 查询云端离线下载任务进度
+func (d *BaiduNetdisk) OfflineTaskInfo(ctx context.Context, taskIds []string) (map[string]CloudDlTaskInfo, error) {
+	var resp CloudDlQueryResp
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/services/cloud_dl", http.MethodPost, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"method": "query_task",
+			"app_id": "250528",
+		})
+		req.SetFormData(map[string]string{
+			"task_ids": strings.Join(taskIds, ","),
+			"op_type":  "1",
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.TaskInfo, nil
+}
+
+// DeleteOfflineTask 取消并删除云端离线下载任务，已下载的文件不会被删除
+func (d *BaiduNetdisk) DeleteOfflineTask(ctx context.Context, taskId string) error {
+	for _, method := range []string{"cancel_task", "delete_task"} {
+		_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/services/cloud_dl", http.MethodPost, func(req *resty.Request) {
+			req.SetQueryParams(map[string]string{
+				"method": method,
+				"app_id": "250528",
+			})
+			req.SetFormData(map[string]string{
+				"task_id": taskId,
+			})
+		}, nil)
+		// 已完成的任务无法取消，忽略 cancel_task 的错误
+		if err != nil && method == "delete_task" {
+			return err
+		}
 	}
 	return nil
 }
 
 var _ driver.Driver = (*BaiduNetdisk)(nil)
+var _ driver.Other = (*BaiduNetdisk)(nil)
+var _ driver.WithDetails = (*BaiduNetdisk)(nil)
+var _ driver.ListFilter = (*BaiduNetdisk)(nil)
+var _ driver.SameBackend = (*BaiduNetdisk)(nil)
+var _ driver.RemovePermanently = (*BaiduNetdisk)(nil)
+var _ driver.ListPager = (*BaiduNetdisk)(nil)
+var _ driver.BatchMove = (*BaiduNetdisk)(nil)
+var _ driver.BatchCopy = (*BaiduNetdisk)(nil)
+var _ driver.BatchRename = (*BaiduNetdisk)(nil)
+var _ driver.BatchRemove = (*BaiduNetdisk)(nil)
+var _ driver.ListModifiedSince = (*BaiduNetdisk)(nil)
+var _ driver.ListOrder = (*BaiduNetdisk)(nil)
+var _ driver.PutRapid = (*BaiduNetdisk)(nil)
+var _ driver.NameRestricted = (*BaiduNetdisk)(nil)
+var _ driver.ListRecursive = (*BaiduNetdisk)(nil)
+var _ driver.MtimeSetter = (*BaiduNetdisk)(nil)
+var _ driver.SearchName = (*BaiduNetdisk)(nil)
+var _ driver.MkdirAll = (*BaiduNetdisk)(nil)
+var _ driver.GetByID = (*BaiduNetdisk)(nil)
@@ -0,0 +1,80 @@
+package baidu_netdisk
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// errnoInfo 百度接口 errno 的说明和分类
+type errnoInfo struct {
+	msg   string
+	class error // errs.Unauthorized 等，nil 表示不分类
+}
+
+// errnoInfos 已知的 errno，参考 https://pan.baidu.com/union/doc/
+var errnoInfos = map[int]errnoInfo{
+	2:     {"invalid parameter", errs.InvalidParameter},
+	-6:    {"access token is invalid", errs.Unauthorized},
+	-7:    {"invalid name, or no access to the file", errs.InvalidName},
+	-8:    {"file or folder already exists", errs.ObjectExists},
+	-9:    {"file or folder doesn't exist", errs.ObjectNotFound},
+	-10:   {"not enough space in the netdisk", errs.QuotaExceeded},
+	6:     {"the app isn't allowed to access the user data", errs.Unauthorized},
+	111:   {"access token has expired", errs.Unauthorized},
+	31023: {"invalid parameter", errs.InvalidParameter},
+	31024: {"no permission to access the file", errs.Forbidden},
+	31034: {"hit the api rate limit", errs.RateLimited},
+	31061: {"file already exists", errs.FileExists},
+	31062: {"invalid file name", errs.InvalidName},
+	31064: {"no permission to upload to the path", errs.Forbidden},
+	31066: {"file doesn't exist", errs.ObjectNotFound},
+	31299: {"the size of the first block is less than 4MB", nil},
+	31363: {"a block of the upload is missing", nil},
+	31364: {"the block of the upload is too large", nil},
+	31365: {"the file is too large for the account", errs.UploadTooLarge},
+}
+
+// ErrnoError 百度接口返回的非 0 errno，errors.Is 可以判断其分类，例如 errs.RateLimited
+type ErrnoError struct {
+	URL   string
+	Errno int
+	Msg   string // 百度返回的错误信息，可能为空
+	info  errnoInfo
+	known bool
+}
+
+func (e *ErrnoError) Error() string {
+	msg := ""
+	if e.Msg != "" {
+		msg = ", msg: " + e.Msg
+	}
+	if !e.known {
+		return fmt.Sprintf("req: [%s], errno: %d%s, refer to https://pan.baidu.com/union/doc/", e.URL, e.Errno, msg)
+	}
+	return fmt.Sprintf("%s (errno %d%s), req: [%s]", e.info.msg, e.Errno, msg, e.URL)
+}
+
+func (e *ErrnoError) Unwrap() error {
+	return e.info.class
+}
+
+// retryable 刷新 token 后重试，触发频率限制的退避后重试，未分类的也重试。
+// 其余已分类的错误重试没有意义，例如文件不存在、容量不足、参数错误
+func (e *ErrnoError) retryable() bool {
+	return e.Errno == 111 || e.Errno == -6 || e.info.class == nil || e.info.class == errs.RateLimited
+}
+
+// newErrnoError 按 errnoInfos 转换响应中的 errno，未知的 errno 保留原始的错误码和信息
+func newErrnoError(furl string, errno int, body []byte) *ErrnoError {
+	e := &ErrnoError{URL: furl, Errno: errno}
+	e.info, e.known = errnoInfos[errno]
+	for _, key := range []string{"errmsg", "show_msg", "error_msg"} {
+		if msg := utils.Json.Get(body, key).ToString(); msg != "" {
+			e.Msg = msg
+			break
+		}
+	}
+	return e
+}
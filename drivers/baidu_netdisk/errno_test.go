@@ -0,0 +1,50 @@
+package baidu_netdisk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+)
+
+func TestNewErrnoError(t *testing.T) {
+	tests := []struct {
+		errno int
+		body  string
+		class error
+		msg   string
+		retry bool
+	}{
+		{-9, `{"errno":-9,"errmsg":"file does not exist"}`, errs.ObjectNotFound, "file does not exist", false},
+		{-6, `{"errno":-6}`, errs.Unauthorized, "", true},
+		{31034, `{"errno":31034,"show_msg":"too fast"}`, errs.RateLimited, "too fast", true},
+		{2, `{"errno":2}`, errs.InvalidParameter, "", false},
+		{31023, `{"errno":31023,"error_msg":"param error"}`, errs.InvalidParameter, "param error", false},
+		{31024, `{"errno":31024}`, errs.Forbidden, "", false},
+		{31064, `{"errno":31064}`, errs.Forbidden, "", false},
+		{31363, `{"errno":31363}`, nil, "", true},
+		{12345, `{"errno":12345,"errmsg":"unknown"}`, nil, "unknown", true},
+	}
+	for _, tt := range tests {
+		e := newErrnoError("https://pan.baidu.com/rest/2.0/xpan/file", tt.errno, []byte(tt.body))
+		if e.Errno != tt.errno || e.Msg != tt.msg {
+			t.Errorf("errno %d: got errno %d, msg %q, expect msg %q", tt.errno, e.Errno, e.Msg, tt.msg)
+		}
+		if tt.class != nil && !errors.Is(e, tt.class) {
+			t.Errorf("errno %d: %v should be %v", tt.errno, e, tt.class)
+		}
+		if tt.class == nil && e.Unwrap() != nil {
+			t.Errorf("errno %d: expect no class, got %v", tt.errno, e.Unwrap())
+		}
+		if e.retryable() != tt.retry {
+			t.Errorf("errno %d: expect retryable %v", tt.errno, tt.retry)
+		}
+		if errors.Is(e, errs.PermissionDenied) {
+			t.Errorf("errno %d: a denial of baidu isn't the permission of alist", tt.errno)
+		}
+		if !strings.Contains(e.Error(), "errno") {
+			t.Errorf("errno %d: the errno is missing in %q", tt.errno, e.Error())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/go-resty/resty/v2"
+)
+
+// 历史版本只有会员账号可用，开放平台没有对应接口，使用网页版接口。网页版接口不保证接受开放平台的
+// access_token，所以需要开启 FileHistory 才能使用
+
+// VERSION_LINK_TYPE 下载历史版本时 Link 的 type，后接 history_id，例如 /p/a.txt?type=version:123
+const VERSION_LINK_TYPE = "version:"
+
+var (
+	ErrVersionUnsupported = errors.New("the account has no file version history")
+	ErrVersionTokenDenied = errors.New("the web api of the file history rejects the access token of the open platform")
+)
+
+// versionErr 把历史版本接口的 errno 转换为错误，非会员、文件类型不支持或接口不接受 token 时返回 errs.NotSupport
+func versionErr(errno int) error {
+	switch errno {
+	case 2, 9019, 31088:
+		return fmt.Errorf("%w: %w", errs.NotSupport, ErrVersionUnsupported)
+	case -6, 111:
+		return fmt.Errorf("%w: %w", errs.NotSupport, ErrVersionTokenDenied)
+	}
+	return nil
+}
+
+// listVersions 获取文件的历史版本，按时间从新到旧
+func (d *BaiduNetdisk) listVersions(ctx context.Context, file model.Obj) ([]FileVersion, error) {
+	page := 1
+	limit := 100
+	var res []FileVersion
+	for {
+		var resp FileVersionListResp
+		_, err := d.request(ctx, "https://pan.baidu.com/api/filehistory/list", http.MethodGet, func(req *resty.Request) {
+			req.SetQueryParams(map[string]string{
+				"fs_id": file.GetID(),
+				"page":  strconv.Itoa(page),
+				"num":   strconv.Itoa(limit),
+				"web":   "1",
+			})
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.List...)
+		if resp.HasMore == 0 || len(resp.List) < limit {
+			break
+		}
+		page++
+	}
+	return res, nil
+}
+
+// versionLink 获取历史版本的下载链接，与 linkOfficial 一样需要 pan.baidu.com 的 UA。
+// 链接带有 access_token，只能由 alist 代理下载
+func (d *BaiduNetdisk) versionLink(ctx context.Context, file model.Obj, historyId string) (*model.Link, error) {
+	var resp FileVersionDownloadResp
+	_, err := d.request(ctx, "https://pan.baidu.com/api/filehistory/download", http.MethodGet, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"fs_id":      file.GetID(),
+			"history_id": historyId,
+			"web":        "1",
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Dlink == "" {
+		return nil, fmt.Errorf("empty dlink of the version [%s] of [%s]", historyId, file.GetPath())
+	}
+	return &model.Link{
+		URL: fmt.Sprintf("%s&access_token=%s", resp.Dlink, d.AccessToken),
+		Header: http.Header{
+			"User-Agent": []string{"pan.baidu.com"},
+		},
+		MustProxy: true,
+	}, nil
+}
+
+// restoreVersion 把文件恢复为历史版本，当前内容会成为新的历史版本
+func (d *BaiduNetdisk) restoreVersion(ctx context.Context, file model.Obj, historyId string) error {
+	_, err := d.request(ctx, "https://pan.baidu.com/api/filehistory/recover", http.MethodPost, func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"fs_id":      file.GetID(),
+			"history_id": historyId,
+		})
+	}, nil)
+	if err != nil {
+		return err
+	}
+	d.linkCache.Del(file.GetID() + ":" + d.downloadAPI(file))
+	op.ClearCache(d, stdpath.Dir(strings.TrimPrefix(file.GetPath(), d.GetRootPath())))
+	return nil
+}
+
+func versionToResp(v FileVersion) VersionResponse {
+	return VersionResponse{
+		HistoryId: v.HistoryId,
+		Size:      v.Size,
+		Modified:  time.Unix(v.ServerMtime, 0),
+		Md5:       DecryptMd5(v.Md5),
+	}
+}
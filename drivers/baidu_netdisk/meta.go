@@ -5,38 +5,98 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Addition struct {
 	RefreshToken string `json:"refresh_token" required:"true"`
 	driver.RootPath
+	RootFsID              string `json:"root_fs_id" help:"fs_id of the root folder, used instead of the root folder path which must be / then. Keeps working after a parent folder is renamed or moved"`
 	OrderBy               string `json:"order_by" type:"select" options:"name,time,size" default:"name"`
 	OrderDirection        string `json:"order_direction" type:"select" options:"asc,desc" default:"asc"`
 	DownloadAPI           string `json:"download_api" type:"select" options:"official,crack,crack_video" default:"official"`
+	DownloadAPIRules      string `json:"download_api_rules" type:"text" help:"use another download api for some extensions, one 'ext1,ext2:api' per line, e.g. 'mp4,mkv:crack_video', others use the download api above"`
 	ClientID              string `json:"client_id" required:"true" default:"hq9yQ9w9kR4YHj1kyYafLygVocobh7Sf"`
 	ClientSecret          string `json:"client_secret" required:"true" default:"YH2VpZcFJHYNnV6vLfHQXDBhcE7ZChyE"`
 	CustomCrackUA         string `json:"custom_crack_ua" required:"true" default:"netdisk"`
+	CustomHeaders         string `json:"custom_headers" type:"text" help:"one 'Key: Value' per line, added to all requests and download links, overrides the User-Agent above"`
+	DownloadConcurrency   int    `json:"download_concurrency" type:"number" default:"1" help:"parallel range connections when proxying crack/crack_video links, only used if the remote honors Range"`
+	LinkCacheTTL          int    `json:"link_cache_ttl" type:"number" default:"0" help:"max seconds a download link is cached, never beyond the expiry of the link, 0 to cache it until it's about to expire"`
 	AccessToken           string
-	UploadThread          string `json:"upload_thread" default:"3" help:"1<=thread<=32"`
+	AccessTokenExpiresAt  int64  `json:"access_token_expires_at" ignore:"true"` // access_token 的过期时间戳，0 表示未知，不是选项
+	VerifyTokenAtInit     bool   `json:"verify_token_at_init" default:"false" help:"refresh the token once at init to check the refresh token, the storage is marked as authentication required if baidu rejects it. Gives up after 10 seconds without failing the init"`
+	TokenRefreshAhead     int    `json:"token_refresh_ahead" type:"number" default:"600" help:"seconds before the access token expires to refresh it in the background, 0 to refresh only after a request fails"`
+	UploadThread          string `json:"upload_thread" default:"3" help:"1<=thread<=32, only 1 thread is used in low bandwith upload mode"`
 	UploadAPI             string `json:"upload_api" default:"https://d.pcs.baidu.com"`
 	UseDynamicUploadAPI   bool   `json:"use_dynamic_upload_api" default:"true" help:"dynamically get upload api domain, when enabled, the 'Upload API' setting will be used as a fallback if failed to get"`
+	ProbeUploadAPI        bool   `json:"probe_upload_api" default:"false" help:"measure the latency to each upload domain given by the dynamic upload api and use the fastest, costs a request to each domain"`
+	ProbeUploadInterval   int    `json:"probe_upload_interval" type:"number" default:"3600" help:"seconds to keep the fastest upload domain before measuring again"`
 	CustomUploadPartSize  int64  `json:"custom_upload_part_size" type:"number" default:"0" help:"0 for auto"`
 	LowBandwithUploadMode bool   `json:"low_bandwith_upload_mode" default:"false"`
+	CacheBlockHash        bool   `json:"cache_block_hash" default:"false" help:"keep the md5s computed for the upload of a copied file in the database, so that uploading the same file again, e.g. by a retried copy or a sync, skips hashing it while its size and mtime are unchanged"`
+	UploadConflict        string `json:"upload_conflict" type:"select" options:"overwrite,rename,rename_if_changed,fail" default:"overwrite" help:"what baidu does if the uploaded file exists: overwrite it, keep both with a new name, keep both only if the content differs, or fail. The Conflict header of an upload overrides it"`
+	UploadSpeedLimit      int64  `json:"upload_speed_limit" type:"number" default:"0" help:"bytes/sec shared by all the upload threads, also applies in low bandwith upload mode, 0 for unlimited"`
+	DownloadSpeedLimit    int64  `json:"download_speed_limit" type:"number" default:"0" help:"bytes/sec shared by all the proxied downloads of this storage, 0 for unlimited"`
 	OnlyListVideoFile     bool   `json:"only_list_video_file" default:"false"`
+	OnlyListExtensions    string `json:"only_list_extensions" default:"srt,ass,ssa,vtt,sub,idx,sup,nfo,jpg,jpeg,png,webp" help:"extensions also listed besides videos and folders if only list video file is on, comma separated, e.g. the subtitles, nfo and posters for media servers. Empty for videos only"`
+	ThumbnailSize         int    `json:"thumbnail_size" type:"number" default:"850" help:"preferred thumbnail width, the closest of 140/360/850 provided by baidu is used"`
+	FileHistory           bool   `json:"file_history" default:"false" help:"list, download and restore the history versions of the files by the other methods, needs a vip account. It uses the web api of baidu which may reject the token of the open platform, the methods fail as not supported then"`
+	TrashPath             string `json:"trash_path" help:"virtual directory under the root to list and restore the recycle bin, e.g. .trash, empty to disable"`
+	NamePolicy            string `json:"name_policy" type:"select" options:"reject,replace" default:"reject" help:"what to do with a name baidu doesn't allow on upload, mkdir, rename, move and copy: reject it, or replace the illegal characters and truncate it"`
+	NameSubstitute        string `json:"name_substitute" default:"_" help:"replaces each illegal character if the name policy is replace, can be empty to remove them"`
+	MaxNameLength         int    `json:"max_name_length" type:"number" default:"255" help:"max characters of a file or folder name, 0 for unlimited"`
+	WarmupDepth           int    `json:"warmup_depth" type:"number" default:"0" help:"levels of folders listed in the background after init to fill the list cache, 1 for the root only, 0 to disable"`
+	WarmupMaxEntries      int    `json:"warmup_max_entries" type:"number" default:"1000" help:"stop the warmup after this many entries are listed, 0 for unlimited"`
+
+	HttpProxy             string `json:"http_proxy" help:"http(s) or socks5 proxy url used by the api requests and uploads of this storage, e.g. http://127.0.0.1:7890, empty to use the proxy of the environment"`
+	CACert                string `json:"ca_cert" type:"text" help:"PEM encoded CA certificates trusted besides the system ones, e.g. of a TLS intercepting proxy"`
+	TlsInsecureSkipVerify bool   `json:"tls_insecure_skip_verify" default:"false" help:"don't verify the certificates of baidu's servers, not recommended"`
+	MaxIdleConnsPerHost   int    `json:"max_idle_conns_per_host" type:"number" default:"16" help:"keep-alive connections kept to each of baidu's api hosts, the upload client keeps at least upload_thread ones, 0 for the default"`
+	IdleConnTimeout       int    `json:"idle_conn_timeout" type:"number" default:"90" help:"seconds an idle keep-alive connection is kept, 0 for the default"`
+	DisableHTTP2          bool   `json:"disable_http2" default:"false" help:"only use HTTP/1.1 with baidu's servers, e.g. for a proxy which doesn't support HTTP/2, HTTP/2 is negotiated by default"`
+
+	APIRateLimit      float64 `json:"api_rate_limit" type:"float" default:"0" help:"limit all api request rate ([limit]r/1s), 0 for unlimited"`
+	AdaptiveRateLimit bool    `json:"adaptive_rate_limit" default:"true" help:"halve the api request rate each time baidu rejects a request for its rate limit (errno 31034), and recover it slowly after the cooldown, up to the api rate limit above"`
+	RateLimitCooldown int     `json:"rate_limit_cooldown" type:"number" default:"60" help:"seconds the halved rate is kept before it recovers, the recovery takes as long again"`
+	Debug             bool    `json:"debug" default:"false" help:"log the api requests and responses of this storage at debug level, secrets are masked"`
 }
 
 const (
-	UPLOAD_FALLBACK_API        = "https://d.pcs.baidu.com" // 备用上传地址
-	UPLOAD_URL_EXPIRE_TIME     = time.Minute * 60          // 上传地址有效期(分钟)
-	UPLOAD_TIMEOUT             = time.Minute * 30          // 上传请求超时时间
-	UPLOAD_RETRY_COUNT         = 3
-	UPLOAD_RETRY_WAIT_TIME     = time.Second * 1
-	UPLOAD_RETRY_MAX_WAIT_TIME = time.Second * 5
+	UPLOAD_FALLBACK_API         = "https://d.pcs.baidu.com" // 备用上传地址
+	UPLOAD_URL_EXPIRE_TIME      = time.Minute * 60          // 上传地址有效期(分钟)
+	UPLOAD_TIMEOUT              = time.Minute * 30          // 上传请求超时时间
+	UPLOAD_PROBE_TIMEOUT        = time.Second * 5           // 上传域名测速的超时时间
+	DEFAULT_UPLOAD_THREAD       = 3
+	MAX_UPLOAD_THREAD           = 32
+	LOW_BANDWIDTH_UPLOAD_THREAD = 1 // 低带宽模式下的上传并发数
+	UPLOAD_RETRY_COUNT          = 3
+	UPLOAD_RETRY_WAIT_TIME      = time.Second * 1
+	UPLOAD_RETRY_MAX_WAIT_TIME  = time.Second * 5
+	QUOTA_CACHE_TIME            = time.Minute * 5  // 容量信息缓存时间
+	RATE_LIMIT_COOLDOWN         = time.Minute      // 触发频率限制后降速的默认持续时间
+	TOKEN_REFRESH_RETRY_WAIT    = time.Minute      // 后台刷新 token 的最短间隔，也是失败后的重试间隔
+	TOKEN_VERIFY_TIMEOUT        = time.Second * 10 // 初始化时检查 refresh_token 的超时时间
+	STATUS_AUTH_REQUIRED        = "authentication required"
+	DOWNLOAD_PART_SIZE          = 10 * utils.MB // 多线程下载分段大小
+	LIST_PAGE_MAX               = 1000          // list 接口单页最多条数
+	ILLEGAL_NAME_CHARS          = `\/:*?"<>|`   // 百度网盘不允许出现在文件名中的字符
+	EMPTY_FILE_MD5              = "d41d8cd98f00b204e9800998ecf8427e"
+)
+
+// create 和 precreate 的 rtype，同名文件已存在时的处理方式
+const (
+	RTYPE_FAIL              = "0" // 返回 -8
+	RTYPE_RENAME            = "1" // 重命名上传的文件
+	RTYPE_RENAME_IF_CHANGED = "2" // block_list 不同时才重命名，相同时不重复保存
+	RTYPE_OVERWRITE         = "3" // 覆盖
 )
 
 var config = driver.Config{
-	Name:        "BaiduNetdisk",
-	DefaultRoot: "/",
+	Name:             "BaiduNetdisk",
+	DefaultRoot:      "/",
+	ProxyRangeOption: true, // crack 链接可能不支持 Range，开启后由 alist 读取并跳过偏移量以支持拖动进度
+	IdempotentMkdir:  true, // 文件夹已存在时 MakeDir 返回 errs.ObjectExists
+	RenameUpload:     true, // rtype 为 1 时百度为上传的文件重命名，返回的对象是实际的文件名
 }
 
 func init() {
@@ -0,0 +1,335 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+)
+
+const (
+	OtherMethodRestore     = "restore"
+	OtherMethodShare       = "share"
+	OtherMethodTransfer    = "transfer"
+	OtherMethodClearTrash  = "clear_trash"
+	OtherMethodProbeUpload = "probe_upload"
+	// OtherMethodVersions lists the history versions of a file
+	OtherMethodVersions = "versions"
+	// OtherMethodVersionLink returns the download link of a history version
+	OtherMethodVersionLink = "version_link"
+	// OtherMethodRestoreVersion replaces the file with a history version
+	OtherMethodRestoreVersion = "restore_version"
+)
+
+type RestoreRequest struct {
+	FsIds []string `json:"fs_ids"`
+}
+
+type ClearTrashRequest struct {
+	// Confirm must be true, the cleared files can't be restored
+	Confirm bool `json:"confirm"`
+	// FsIds files to purge, the whole recycle bin is emptied if empty
+	FsIds []string `json:"fs_ids"`
+}
+
+type ClearTrashResponse struct {
+	Cleared int `json:"cleared"`
+}
+
+type VersionRequest struct {
+	HistoryId string `json:"history_id"`
+}
+
+type VersionResponse struct {
+	HistoryId string    `json:"history_id"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	Md5       string    `json:"md5"`
+}
+
+// VersionLinkResponse the version is downloaded by /d or /p of the file with the type query,
+// the link carries the access token so it's proxied by alist
+type VersionLinkResponse struct {
+	Type string `json:"type"`
+}
+
+type ProbeUploadResponse struct {
+	UploadUrl string        `json:"upload_url"`
+	Probes    []UploadProbe `json:"probes"`
+}
+
+type ShareRequest struct {
+	// FsIds extra files to share together with the requested object
+	FsIds []string `json:"fs_ids"`
+	// Pwd 4-char extraction code, generated if empty
+	Pwd string `json:"pwd"`
+	// Period expiry in days: 1, 7, 30, or 0 for permanent
+	Period int `json:"period"`
+}
+
+type ShareResponse struct {
+	ShareId  int64  `json:"share_id"`
+	Url      string `json:"url"`
+	Pwd      string `json:"pwd"`
+	Period   int    `json:"period"`
+	ExpireAt int64  `json:"expire_at,omitempty"`
+}
+
+type TransferRequest struct {
+	// Url share link, e.g. https://pan.baidu.com/s/1xxxx?pwd=abcd
+	Url string `json:"url"`
+	// Pwd extraction code, taken from the pwd query of the url if empty
+	Pwd string `json:"pwd"`
+	// FsIds files of the share to transfer, all the top level files if empty
+	FsIds []string `json:"fs_ids"`
+}
+
+type TransferResponse struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// OtherKind 还原、分享、转存和恢复历史版本会修改网盘，清空回收站会彻底删除文件
+func (d *BaiduNetdisk) OtherKind(method string) int {
+	switch method {
+	case OtherMethodRestore, OtherMethodShare, OtherMethodTransfer, OtherMethodRestoreVersion:
+		return driver.OtherWrite
+	case OtherMethodClearTrash:
+		return driver.OtherRemove
+	default:
+		return driver.OtherRead
+	}
+}
+
+func (d *BaiduNetdisk) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	if args.Obj == nil {
+		return nil, fmt.Errorf("missing object reference")
+	}
+	switch strings.ToLower(strings.TrimSpace(args.Method)) {
+	case OtherMethodRestore:
+		return d.otherRestore(ctx, args)
+	case OtherMethodShare:
+		return d.otherShare(ctx, args)
+	case OtherMethodTransfer:
+		return d.otherTransfer(ctx, args)
+	case OtherMethodClearTrash:
+		return d.otherClearTrash(ctx, args)
+	case OtherMethodProbeUpload:
+		return d.otherProbeUpload(ctx, args)
+	case OtherMethodVersions, OtherMethodVersionLink, OtherMethodRestoreVersion:
+		return d.otherVersion(ctx, strings.ToLower(strings.TrimSpace(args.Method)), args)
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+// otherRestore 还原回收站中的文件，可直接对回收站中的条目调用，
+// 也可以对回收站目录调用并传入 fs_ids
+func (d *BaiduNetdisk) otherRestore(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	if !d.trashEnabled() {
+		return nil, errs.NotSupport
+	}
+	var fsIds []string
+	if d.isInTrash(args.Obj.GetPath()) {
+		fsIds = []string{args.Obj.GetID()}
+	} else if d.isTrashDir(args.Obj.GetPath()) {
+		var req RestoreRequest
+		if err := decodeOtherArgs(args.Data, &req); err != nil {
+			return nil, fmt.Errorf("parse restore request: %w", err)
+		}
+		fsIds = req.FsIds
+	} else {
+		return nil, errs.NotSupport
+	}
+	if err := d.restoreTrash(ctx, fsIds); err != nil {
+		return nil, err
+	}
+	return fsIds, nil
+}
+
+// otherClearTrash 清空回收站，或彻底删除回收站中的指定文件，需要传入 confirm。
+// 可直接对回收站中的条目调用，也可以对回收站目录调用并传入 fs_ids，不传时清空整个回收站
+func (d *BaiduNetdisk) otherClearTrash(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	if !d.trashEnabled() {
+		return nil, errs.NotSupport
+	}
+	var req ClearTrashRequest
+	if err := decodeOtherArgs(args.Data, &req); err != nil {
+		return nil, fmt.Errorf("parse clear trash request: %w", err)
+	}
+	if !req.Confirm {
+		return nil, fmt.Errorf("the cleared files can't be restored, set confirm to true to continue")
+	}
+	var cleared int
+	if d.isInTrash(args.Obj.GetPath()) {
+		if err := d.deleteTrash(ctx, []string{args.Obj.GetID()}); err != nil {
+			return nil, err
+		}
+		cleared = 1
+	} else if !d.isTrashDir(args.Obj.GetPath()) {
+		return nil, errs.NotSupport
+	} else if len(req.FsIds) > 0 {
+		if err := d.deleteTrash(ctx, req.FsIds); err != nil {
+			return nil, err
+		}
+		cleared = len(req.FsIds)
+	} else {
+		n, err := d.clearTrash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cleared = n
+	}
+	op.ClearCache(d, strings.TrimPrefix(d.trashDir(), d.GetRootPath()))
+	return ClearTrashResponse{Cleared: cleared}, nil
+}
+
+// otherVersion 列出文件的历史版本，或获取、恢复 history_id 指定的版本。账号没有历史版本时返回 errs.NotSupport
+func (d *BaiduNetdisk) otherVersion(ctx context.Context, method string, args model.OtherArgs) (interface{}, error) {
+	if !d.FileHistory || args.Obj.IsDir() || d.isTrashDir(args.Obj.GetPath()) || d.isInTrash(args.Obj.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	if method == OtherMethodVersions {
+		versions, err := d.listVersions(ctx, args.Obj)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]VersionResponse, 0, len(versions))
+		for _, v := range versions {
+			res = append(res, versionToResp(v))
+		}
+		return res, nil
+	}
+	var req VersionRequest
+	if err := decodeOtherArgs(args.Data, &req); err != nil {
+		return nil, fmt.Errorf("parse version request: %w", err)
+	}
+	if req.HistoryId == "" {
+		return nil, fmt.Errorf("missing history_id")
+	}
+	if method == OtherMethodVersionLink {
+		// 先获取一次，确认版本存在
+		if _, err := d.versionLink(ctx, args.Obj, req.HistoryId); err != nil {
+			return nil, err
+		}
+		return VersionLinkResponse{Type: VERSION_LINK_TYPE + req.HistoryId}, nil
+	}
+	if err := d.restoreVersion(ctx, args.Obj, req.HistoryId); err != nil {
+		return nil, err
+	}
+	return req.HistoryId, nil
+}
+
+// otherProbeUpload 重新测速各上传域名并使用最快的域名，返回各域名的测速结果
+func (d *BaiduNetdisk) otherProbeUpload(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	if !d.UseDynamicUploadAPI {
+		return nil, errs.NotSupport
+	}
+	uploadUrl, probes, err := d.remeasureUploadUrl(ctx, args.Obj.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	return ProbeUploadResponse{UploadUrl: uploadUrl, Probes: probes}, nil
+}
+
+// otherShare 为对象创建分享链接，返回短链与提取码
+func (d *BaiduNetdisk) otherShare(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	if d.isTrashDir(args.Obj.GetPath()) || d.isInTrash(args.Obj.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	req := ShareRequest{Period: 7}
+	if err := decodeOtherArgs(args.Data, &req); err != nil {
+		return nil, fmt.Errorf("parse share request: %w", err)
+	}
+	if !slices.Contains([]int{0, 1, 7, 30}, req.Period) {
+		return nil, fmt.Errorf("invalid period: %d, must be one of 0, 1, 7, 30", req.Period)
+	}
+	if req.Pwd == "" {
+		req.Pwd = strings.ToLower(random.String(4))
+	} else if len(req.Pwd) != 4 || !isAlnum(req.Pwd) {
+		return nil, fmt.Errorf("pwd must be 4 letters or digits")
+	}
+	fsIds := []string{args.Obj.GetID()}
+	for _, id := range req.FsIds {
+		if !slices.Contains(fsIds, id) {
+			fsIds = append(fsIds, id)
+		}
+	}
+	resp, err := d.createShare(ctx, fsIds, req.Pwd, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	url := resp.ShortUrl
+	if url == "" {
+		url = resp.Link
+	}
+	return ShareResponse{
+		ShareId:  resp.ShareId,
+		Url:      url,
+		Pwd:      req.Pwd,
+		Period:   req.Period,
+		ExpireAt: resp.Expiretime,
+	}, nil
+}
+
+// otherTransfer 将分享链接中的文件转存到目录中，由百度网盘服务端完成，不经过 alist 传输
+func (d *BaiduNetdisk) otherTransfer(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	dst := args.Obj
+	if !dst.IsDir() || d.isTrashDir(dst.GetPath()) || d.isInTrash(dst.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	var req TransferRequest
+	if err := decodeOtherArgs(args.Data, &req); err != nil {
+		return nil, fmt.Errorf("parse transfer request: %w", err)
+	}
+	surl, pwd, err := parseShareUrl(req.Url)
+	if err != nil {
+		return nil, err
+	}
+	if req.Pwd != "" {
+		pwd = req.Pwd
+	}
+	share, err := d.openShare(ctx, surl, pwd)
+	if err != nil {
+		return nil, err
+	}
+	fsIds := req.FsIds
+	if len(fsIds) == 0 {
+		if fsIds, err = share.fsIds(); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.transferShare(ctx, share, fsIds, dst.GetPath()); err != nil {
+		return nil, err
+	}
+	op.ClearCache(d, strings.TrimPrefix(dst.GetPath(), d.GetRootPath()))
+	return TransferResponse{Path: dst.GetPath(), Count: len(fsIds)}, nil
+}
+
+func isAlnum(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeOtherArgs(data interface{}, v interface{}) error {
+	if data == nil {
+		return nil
+	}
+	raw, err := utils.Json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return utils.Json.Unmarshal(raw, v)
+}
@@ -0,0 +1,74 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// UploadProbe 一个上传域名的测速结果
+type UploadProbe struct {
+	Url     string `json:"url"`
+	Latency int64  `json:"latency_ms"`
+	Error   string `json:"error,omitempty"`
+}
+
+// probeUploadUrls 并发请求各上传域名，按延迟从低到高排序，请求失败的排在最后。
+// 只测量建立连接并收到响应的时间，不上传数据。ctx 取消时所有测速随之中止
+func (d *BaiduNetdisk) probeUploadUrls(ctx context.Context, uploadUrls []string) []UploadProbe {
+	res := make([]UploadProbe, len(uploadUrls))
+	var wg sync.WaitGroup
+	for i, uploadUrl := range uploadUrls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, UPLOAD_PROBE_TIMEOUT)
+			defer cancel()
+			start := time.Now()
+			_, err := d.upClient.R().SetContext(ctx).Get(uploadUrl + "/rest/2.0/pcs/superfile2")
+			res[i] = UploadProbe{Url: uploadUrl, Latency: time.Since(start).Milliseconds()}
+			if err != nil {
+				res[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	slices.SortStableFunc(res, func(a, b UploadProbe) int {
+		if (a.Error == "") != (b.Error == "") {
+			if a.Error == "" {
+				return -1
+			}
+			return 1
+		}
+		return int(a.Latency - b.Latency)
+	})
+	log.Debugf("[baidu_netdisk] upload domains probed: %+v", res)
+	return res
+}
+
+// fastestUploadUrl 返回测速结果中最快的域名，都失败时返回 fallback
+func fastestUploadUrl(probes []UploadProbe, fallback string) string {
+	if len(probes) == 0 || probes[0].Error != "" {
+		return fallback
+	}
+	return probes[0].Url
+}
+
+// remeasureUploadUrl 重新获取上传域名并测速，选出最快的域名并缓存
+func (d *BaiduNetdisk) remeasureUploadUrl(ctx context.Context, path string) (string, []UploadProbe, error) {
+	uploadUrls, err := d.requestForUploadUrls(ctx, path, "")
+	if err != nil {
+		return "", nil, err
+	}
+	probes := d.probeUploadUrls(ctx, uploadUrls)
+	// 中止的测速都失败，不缓存其结果
+	if err = ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	uploadUrl := fastestUploadUrl(probes, uploadUrls[0])
+	d.setUploadUrl(uploadUrl)
+	return uploadUrl, probes, nil
+}
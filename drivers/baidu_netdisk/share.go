@@ -0,0 +1,137 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+const SHARE_LIST_PAGE_SIZE = 100
+
+// share 验证提取码后打开的分享链接
+type share struct {
+	shortUrl string // 链接 /s/ 后的部分，以 1 开头
+	sekey    string
+	shareId  int64
+	uk       int64
+	files    []File // 分享的顶层文件
+}
+
+// parseShareUrl 解析分享链接，支持 https://pan.baidu.com/s/1xxxx?pwd=abcd
+// 与 https://pan.baidu.com/share/init?surl=xxxx 两种形式，返回 surl（不带开头的 1）和链接中的提取码
+func parseShareUrl(raw string) (string, string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", "", fmt.Errorf("share url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid share url: %w", err)
+	}
+	pwd := u.Query().Get("pwd")
+	if surl := u.Query().Get("surl"); surl != "" {
+		return surl, pwd, nil
+	}
+	if s, ok := strings.CutPrefix(u.Path, "/s/"); ok && len(s) > 1 && s[0] == '1' {
+		return strings.TrimSuffix(s[1:], "/"), pwd, nil
+	}
+	return "", "", fmt.Errorf("invalid share url: %s", raw)
+}
+
+// shareErr 将分享接口的 errno 转为可读的错误，其他 errno 返回 nil
+func shareErr(errno int) error {
+	switch errno {
+	case -12:
+		return ErrSharePwdRequired
+	case -9, -62:
+		return ErrSharePwdWrong
+	case -21, 105, 115, 145:
+		return ErrShareExpired
+	}
+	return nil
+}
+
+// openShare 验证提取码并获取分享的顶层文件
+func (d *BaiduNetdisk) openShare(ctx context.Context, surl, pwd string) (*share, error) {
+	var verify ShareVerifyResp
+	_, err := d.postForm(ctx, "/xpan/share", map[string]string{
+		"method": "verify",
+		"surl":   surl,
+	}, map[string]string{
+		"pwd": pwd,
+	}, &verify)
+	if err != nil {
+		// 未填写提取码时接口同样返回提取码错误
+		if pwd == "" && errors.Is(err, ErrSharePwdWrong) {
+			return nil, fmt.Errorf("%w: %w", ErrSharePwdRequired, err)
+		}
+		return nil, err
+	}
+	s := &share{shortUrl: "1" + surl, sekey: verify.Randsk}
+	for page := 1; ; page++ {
+		var resp ShareListResp
+		_, err := d.get(ctx, "/xpan/share", map[string]string{
+			"method":   "list",
+			"shorturl": s.shortUrl,
+			"sekey":    s.sekey,
+			"root":     "1",
+			"page":     strconv.Itoa(page),
+			"num":      strconv.Itoa(SHARE_LIST_PAGE_SIZE),
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		s.shareId, s.uk = resp.ShareId, resp.Uk
+		s.files = append(s.files, resp.List...)
+		if len(resp.List) < SHARE_LIST_PAGE_SIZE {
+			break
+		}
+	}
+	return s, nil
+}
+
+func (s *share) fsIds() ([]string, error) {
+	if len(s.files) == 0 {
+		return nil, fmt.Errorf("the share link has no file")
+	}
+	ids := make([]string, 0, len(s.files))
+	for _, f := range s.files {
+		ids = append(ids, strconv.FormatInt(f.FsId, 10))
+	}
+	return ids, nil
+}
+
+// transferShare 将分享中的文件转存到 dst，按 SHARE_LIST_PAGE_SIZE 分批提交
+func (d *BaiduNetdisk) transferShare(ctx context.Context, s *share, fsIds []string, dst string) error {
+	ids := make([]int64, 0, len(fsIds))
+	for _, id := range fsIds {
+		fsId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid fs_id: %s", id)
+		}
+		ids = append(ids, fsId)
+	}
+	for start := 0; start < len(ids); start += SHARE_LIST_PAGE_SIZE {
+		end := min(start+SHARE_LIST_PAGE_SIZE, len(ids))
+		fsIdList, _ := utils.Json.MarshalToString(ids[start:end])
+		var resp ShareTransferResp
+		_, err := d.postForm(ctx, "/xpan/share", map[string]string{
+			"method":  "transfer",
+			"shareid": strconv.FormatInt(s.shareId, 10),
+			"from":    strconv.FormatInt(s.uk, 10),
+		}, map[string]string{
+			"sekey":    s.sekey,
+			"fsidlist": fsIdList,
+			"path":     dst,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	streamPkg "github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/errgroup"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/avast/retry-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// errSuperfileRejected 百度不接受调用方给出的分片，需要完整上传
+var errSuperfileRejected = errors.New("slices rejected by baidu")
+
+// SuperfilePart 调用方已切好的分片，Md5 为分片内容的 md5
+type SuperfilePart struct {
+	Md5  string
+	Size int64
+	Data io.ReaderAt
+}
+
+// PutSuperfile 上传已切好分片并已知分片 md5 的文件，不重新计算 md5。
+// 以分片 md5 作为 block_list 调用 precreate，只上传百度返回的缺少的分片，再调用 create 合并。
+// file 提供文件名、大小、时间和 content-md5，已知 content-md5 时可以秒传。
+// 分片大小不被百度接受、上传后百度计算的分片 md5 不一致或合并失败时，读取全部分片以 Put 完整上传
+func (d *BaiduNetdisk) PutSuperfile(ctx context.Context, dstDir model.Obj, file model.Obj, parts []SuperfilePart, up driver.UpdateProgress) (model.Obj, error) {
+	if d.isTrashDir(dstDir.GetPath()) {
+		return nil, errs.NotSupport
+	}
+	if err := checkSuperfileParts(file, parts); err != nil {
+		return nil, err
+	}
+	readers := make([]io.Reader, len(parts))
+	for i, p := range parts {
+		readers[i] = io.NewSectionReader(p.Data, 0, p.Size)
+	}
+	stream, err := d.renameStream(&streamPkg.FileStream{Ctx: ctx, Obj: file, Reader: io.MultiReader(readers...)})
+	if err != nil {
+		return nil, err
+	}
+	if stream.GetSize() == 0 {
+		return d.createByMd5(ctx, dstDir, stream, EMPTY_FILE_MD5)
+	}
+	newObj, err := d.putSuperfile(ctx, dstDir, stream, parts, up, false)
+	if !errors.Is(err, errSuperfileRejected) {
+		return newObj, err
+	}
+	log.Warnf("[baidu_netdisk] %v, upload [%s] in full", err, stdpath.Join(dstDir.GetPath(), stream.GetName()))
+	return d.Put(ctx, dstDir, stream, up)
+}
+
+// checkSuperfileParts 检查分片与文件大小一致，分片 md5 格式正确
+func checkSuperfileParts(file model.Obj, parts []SuperfilePart) error {
+	if len(parts) > MaxSliceNum {
+		return fmt.Errorf("too many slices: %d > %d", len(parts), MaxSliceNum)
+	}
+	total := int64(0)
+	for i, p := range parts {
+		if p.Size <= 0 || p.Data == nil {
+			return fmt.Errorf("slice %d is empty", i)
+		}
+		if _, err := hex.DecodeString(p.Md5); err != nil || len(p.Md5) != utils.MD5.Width {
+			return fmt.Errorf("invalid md5 of slice %d: %s", i, p.Md5)
+		}
+		total += p.Size
+	}
+	if total != file.GetSize() {
+		return fmt.Errorf("size of the slices mismatch: %d != %d", total, file.GetSize())
+	}
+	return nil
+}
+
+// checkSuperfileSliceSize 检查分片大小，除最后一个外所有分片大小相同，且在会员等级允许的范围内
+func (d *BaiduNetdisk) checkSuperfileSliceSize(parts []SuperfilePart) error {
+	sliceSize := parts[0].Size
+	for i, p := range parts {
+		if (i < len(parts)-1 && p.Size != sliceSize) || p.Size > sliceSize {
+			return fmt.Errorf("%w: slice %d is %d bytes, the slices must be %d bytes except the last one",
+				errSuperfileRejected, i, p.Size, sliceSize)
+		}
+	}
+	if len(parts) > 1 && (sliceSize < DefaultSliceSize || sliceSize > d.maxSliceSize()) {
+		return fmt.Errorf("%w: slice size %d is out of [%d, %d]", errSuperfileRejected, sliceSize, DefaultSliceSize, d.maxSliceSize())
+	}
+	return nil
+}
+
+func (d *BaiduNetdisk) putSuperfile(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, parts []SuperfilePart, up driver.UpdateProgress, restarted bool) (model.Obj, error) {
+	if err := d.checkSuperfileSliceSize(parts); err != nil {
+		return nil, err
+	}
+	streamSize := stream.GetSize()
+	blockList := make([]string, len(parts))
+	for i, p := range parts {
+		blockList[i] = strings.ToLower(p.Md5)
+	}
+	blockListStr, _ := utils.Json.MarshalToString(blockList)
+	contentMd5 := stream.GetHash().GetHash(utils.MD5)
+	sliceMd5 := ""
+	if len(contentMd5) == utils.MD5.Width && !restarted {
+		// 第一个分片不小于 256KB，或者只有一个分片
+		h := md5.New()
+		if _, err := utils.CopyWithBuffer(h, io.NewSectionReader(parts[0].Data, 0, min(parts[0].Size, SliceMd5Size))); err != nil {
+			return nil, err
+		}
+		sliceMd5 = hex.EncodeToString(h.Sum(nil))
+	}
+	path := stdpath.Join(dstDir.GetPath(), stream.GetName())
+	ctime, mtime := streamTime(stream)
+	rtype := d.uploadRtype(stream)
+
+	// 分片大小可能与 Put 不同，进度与 Put 分开保存，content-md5 可能未知，以 block_list 区分文件
+	stateKeys := []string{strconv.FormatUint(uint64(d.ID), 10), path, "superfile", utils.GetMD5EncodeStr(blockListStr), strconv.FormatInt(streamSize, 10)}
+	dropProgress := func() {
+		if err := base.SavePersistentUploadProgress(d, nil, 0, stateKeys...); err != nil {
+			log.Warnf("[baidu_netdisk] failed remove upload state of %s: %+v", path, err)
+		}
+	}
+
+	// step.1 读取已保存的进度，没有时以分片 md5 预上传，百度只返回缺少的分片
+	precreateResp, ok := base.GetPersistentUploadProgress[*PrecreateResp](d, stateKeys...)
+	if ok {
+		log.Infof("[baidu_netdisk] resume upload of %s, %d slices left", path, len(precreateResp.BlockList))
+	} else {
+		var err error
+		precreateResp, err = d.precreate(ctx, path, rtype, streamSize, blockListStr, contentMd5, sliceMd5, ctime, mtime)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: precreate failed: %v", errSuperfileRejected, err)
+		}
+		if precreateResp.ReturnType == 2 {
+			//rapid upload, since got md5 match from baidu server
+			return fileToObj(precreateResp.File), nil
+		}
+		d.savePersistentProgress(precreateResp, stateKeys)
+	}
+	for _, partseq := range precreateResp.BlockList {
+		if partseq >= len(parts) {
+			dropProgress()
+			return nil, fmt.Errorf("%w: baidu asks for slice %d of %d", errSuperfileRejected, partseq, len(parts))
+		}
+	}
+	log.Debugf("[baidu_netdisk] %d of %d slices of [%s] are missing in baidu", len(precreateResp.BlockList), len(parts), path)
+
+	// step.2 上传缺少的分片，分片并发完成，需要加锁
+	var (
+		stateMu  sync.Mutex
+		rejected []int
+	)
+	uploadUrl := d.getUploadUrl(ctx, path, precreateResp.Uploadid)
+	var failedUrls []string
+	for {
+		threadG, upCtx := errgroup.NewGroupWithContext(ctx, d.uploadThread,
+			retry.Attempts(1),
+			retry.Delay(time.Second),
+			retry.DelayType(retry.BackOffDelay))
+		// 百度已有的分片直接计入进度
+		progress := driver.NewChunkProgress(streamSize, up)
+		remaining := int64(0)
+		for _, partseq := range precreateResp.BlockList {
+			remaining += parts[partseq].Size
+		}
+		progress.Add(streamSize - remaining)
+		for i, partseq := range precreateResp.BlockList {
+			if utils.IsCanceled(upCtx) || partseq < 0 {
+				continue
+			}
+			part := parts[partseq]
+			threadG.Go(func(ctx context.Context) error {
+				params := map[string]string{
+					"method":       "upload",
+					"access_token": d.AccessToken,
+					"type":         "tmpfile",
+					"path":         path,
+					"uploadid":     precreateResp.Uploadid,
+					"partseq":      strconv.Itoa(partseq),
+				}
+				sliceMd5, err := d.uploadSlice(ctx, uploadUrl, params, stream.GetName(), io.NewSectionReader(part.Data, 0, part.Size), "")
+				if err != nil {
+					return err
+				}
+				stateMu.Lock()
+				defer stateMu.Unlock()
+				if sliceMd5 != "" && !strings.EqualFold(sliceMd5, part.Md5) {
+					rejected = append(rejected, partseq)
+				}
+				precreateResp.BlockList[i] = -1
+				d.savePersistentProgress(precreateResp, stateKeys)
+				progress.Add(part.Size)
+				return nil
+			})
+		}
+		err := threadG.Wait()
+		if err == nil {
+			break
+		}
+		// 取消时不再续传，与 Put 一致
+		if ctx.Err() != nil {
+			dropProgress()
+			return nil, ctx.Err()
+		}
+		precreateResp.BlockList = utils.SliceFilter(precreateResp.BlockList, func(s int) bool { return s >= 0 })
+		if errors.Is(err, ErrUploadIDExpired) && !restarted {
+			log.Warn("[baidu_netdisk] uploadid expired, will restart from scratch")
+			dropProgress()
+			return d.putSuperfile(ctx, dstDir, stream, parts, up, true)
+		}
+		if errors.Is(err, errUploadDomain) {
+			failedUrls = append(failedUrls, uploadUrl)
+			if next := d.nextUploadUrl(ctx, path, precreateResp.Uploadid, failedUrls); next != "" {
+				log.Warnf("[baidu_netdisk] upload slices of [%s] to %s failed: %v, continue the remaining slices on %s",
+					path, uploadUrl, err, next)
+				uploadUrl = next
+				continue
+			}
+		}
+		return nil, err
+	}
+	// 给出的 md5 与分片内容不符，按 block_list 合并会得到错误的文件
+	if len(rejected) > 0 {
+		dropProgress()
+		return nil, fmt.Errorf("%w: md5 of slices %v mismatch", errSuperfileRejected, rejected)
+	}
+
+	// step.3 合并分片
+	if err := ctx.Err(); err != nil {
+		dropProgress()
+		return nil, err
+	}
+	var newFile File
+	_, err := d.create(ctx, path, streamSize, 0, rtype, precreateResp.Uploadid, blockListStr, &newFile, mtime, ctime)
+	dropProgress()
+	if err != nil {
+		return nil, fmt.Errorf("%w: create failed: %v", errSuperfileRejected, err)
+	}
+	// 修复时间，具体原因见 Put 方法注释的 **注意**
+	newFile.Ctime = ctime
+	newFile.Mtime = mtime
+	return fileToObj(newFile), nil
+}
@@ -0,0 +1,116 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// startTokenRefresher 在后台于 access_token 过期前 TokenRefreshAhead 秒刷新，避免请求先收到 token 过期再重试。
+// 与请求失败后的刷新共用 refreshToken，同一时间只会刷新一次
+func (d *BaiduNetdisk) startTokenRefresher() {
+	d.stopTokenRefresher()
+	if d.TokenRefreshAhead <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.tokenRefresherStop = cancel
+	go d.tokenRefresher(ctx, d.tokenRenewed)
+}
+
+func (d *BaiduNetdisk) stopTokenRefresher() {
+	if d.tokenRefresherStop != nil {
+		d.tokenRefresherStop()
+		d.tokenRefresherStop = nil
+	}
+}
+
+// notifyTokenRenewed 通知后台刷新按新的过期时间重新计时
+func (d *BaiduNetdisk) notifyTokenRenewed() {
+	select {
+	case d.tokenRenewed <- struct{}{}:
+	default:
+	}
+}
+
+// nextTokenRefresh 返回下一次刷新的时间，过期时间未知时返回零值，等到 token 被刷新后再计时
+func (d *BaiduNetdisk) nextTokenRefresh() (time.Time, string) {
+	d.tokenMu.Lock()
+	defer d.tokenMu.Unlock()
+	if d.AccessTokenExpiresAt <= 0 {
+		return time.Time{}, d.AccessToken
+	}
+	ahead := time.Duration(d.TokenRefreshAhead) * time.Second
+	return time.Unix(d.AccessTokenExpiresAt, 0).Add(-ahead), d.AccessToken
+}
+
+// tokenRefresher 每次刷新后至少间隔 TOKEN_REFRESH_RETRY_WAIT，避免有效期短于提前量时反复刷新
+func (d *BaiduNetdisk) tokenRefresher(ctx context.Context, renewed <-chan struct{}) {
+	var notBefore time.Time
+	for {
+		at, token := d.nextTokenRefresh()
+		if !at.IsZero() && at.Before(notBefore) {
+			at = notBefore
+		}
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !at.IsZero() {
+			timer = time.NewTimer(time.Until(at))
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-renewed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if timeout == nil || time.Now().Before(at) {
+			// token 已被刷新，按新的过期时间重新计时
+			continue
+		}
+		notBefore = time.Now().Add(TOKEN_REFRESH_RETRY_WAIT)
+		err := d.refreshToken(token)
+		if errors.Is(err, ErrTokenInvalid) {
+			return
+		}
+		if err != nil {
+			log.Warnf("[baidu_netdisk] failed refresh token of [%s] in the background: %v", d.MountPath, err)
+		}
+	}
+}
+
+// setTokenInvalid 标记 refresh_token 失效，存储状态显示为需要重新授权，之后的请求不再刷新
+func (d *BaiduNetdisk) setTokenInvalid(err error) {
+	d.tokenMu.Lock()
+	d.tokenInvalid = fmt.Errorf("%w: %w", ErrTokenInvalid, err)
+	d.tokenMu.Unlock()
+	d.GetStorage().SetStatus(fmt.Sprintf("%s: %v", STATUS_AUTH_REQUIRED, d.tokenInvalid))
+	op.MustSaveDriverStorage(d)
+}
+
+// verifyToken 初始化时刷新一次 token 以检查 refresh_token。百度拒绝时返回错误，存储状态显示为需要重新授权；
+// 超时或网络错误时只记录日志，不阻塞初始化，之后的请求失败时仍会刷新
+func (d *BaiduNetdisk) verifyToken(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, TOKEN_VERIFY_TIMEOUT)
+	defer cancel()
+	err := d._refreshToken(ctx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errTokenRejected) && !errors.Is(err, errs.EmptyToken) {
+		log.Warnf("[baidu_netdisk] failed verify the refresh token of [%s], skipped: %v", d.MountPath, err)
+		return nil
+	}
+	d.setTokenInvalid(err)
+	return fmt.Errorf("%s: %w", STATUS_AUTH_REQUIRED, d.tokenInvalid)
+}
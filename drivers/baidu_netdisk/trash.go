@@ -0,0 +1,146 @@
+package baidu_netdisk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-resty/resty/v2"
+)
+
+// 回收站以虚拟目录的形式挂在根目录下，只有设置了 TrashPath 才会启用
+
+func (d *BaiduNetdisk) trashEnabled() bool {
+	return strings.Trim(d.TrashPath, "/") != ""
+}
+
+// trashDir 回收站虚拟目录在网盘中的完整路径
+func (d *BaiduNetdisk) trashDir() string {
+	return stdpath.Join(d.GetRootPath(), strings.Trim(d.TrashPath, "/"))
+}
+
+func (d *BaiduNetdisk) isTrashDir(path string) bool {
+	return d.trashEnabled() && utils.PathEqual(path, d.trashDir())
+}
+
+// isInTrash 判断路径是否为回收站中的条目
+func (d *BaiduNetdisk) isInTrash(path string) bool {
+	return d.trashEnabled() && utils.PathEqual(stdpath.Dir(path), d.trashDir())
+}
+
+func (d *BaiduNetdisk) trashDirObj() model.Obj {
+	return &model.Object{
+		Path:     d.trashDir(),
+		Name:     stdpath.Base(d.trashDir()),
+		Modified: d.Modified,
+		IsFolder: true,
+	}
+}
+
+func (d *BaiduNetdisk) getTrashFiles(ctx context.Context) ([]model.Obj, error) {
+	page := 1
+	limit := 100
+	res := make([]model.Obj, 0)
+	for {
+		var resp RecycleListResp
+		_, err := d.request(ctx, "https://pan.baidu.com/api/recycle/list", http.MethodGet, func(req *resty.Request) {
+			req.SetQueryParams(map[string]string{
+				"page": strconv.Itoa(page),
+				"num":  strconv.Itoa(limit),
+				"web":  "1",
+			})
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range resp.List {
+			name := f.ServerFilename
+			if name == "" {
+				name = stdpath.Base(f.Path)
+			}
+			res = append(res, &model.Object{
+				ID:       strconv.FormatInt(f.FsId, 10),
+				Path:     stdpath.Join(d.trashDir(), name),
+				Name:     name,
+				Size:     f.Size,
+				Modified: time.Unix(f.ServerMtime, 0),
+				Ctime:    time.Unix(f.ServerCtime, 0),
+				IsFolder: f.Isdir == 1,
+				HashInfo: utils.NewHashInfo(utils.MD5, DecryptMd5(f.Md5)),
+			})
+		}
+		if len(resp.List) < limit {
+			break
+		}
+		page++
+	}
+	return res, nil
+}
+
+func toFidList(fsIds []string) (string, error) {
+	ids := make([]int64, 0, len(fsIds))
+	for _, id := range fsIds {
+		fsId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid fs_id: %s", id)
+		}
+		ids = append(ids, fsId)
+	}
+	return utils.Json.MarshalToString(ids)
+}
+
+// restoreTrash 从回收站还原文件到原路径
+func (d *BaiduNetdisk) restoreTrash(ctx context.Context, fsIds []string) error {
+	if len(fsIds) == 0 {
+		return fmt.Errorf("no fs_id to restore")
+	}
+	fidList, err := toFidList(fsIds)
+	if err != nil {
+		return err
+	}
+	_, err = d.request(ctx, "https://pan.baidu.com/api/recycle/restore", http.MethodPost, func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"fidlist": fidList,
+		})
+	}, nil)
+	return err
+}
+
+// clearTrash 清空回收站，返回清空前回收站中的条目数
+func (d *BaiduNetdisk) clearTrash(ctx context.Context) (int, error) {
+	files, err := d.getTrashFiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+	_, err = d.request(ctx, "https://pan.baidu.com/api/recycle/clear", http.MethodPost, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(files), nil
+}
+
+// deleteTrash 从回收站中彻底删除文件，删除后无法还原
+func (d *BaiduNetdisk) deleteTrash(ctx context.Context, fsIds []string) error {
+	if len(fsIds) == 0 {
+		return fmt.Errorf("no fs_id to delete")
+	}
+	fidList, err := toFidList(fsIds)
+	if err != nil {
+		return err
+	}
+	_, err = d.request(ctx, "https://pan.baidu.com/api/recycle/delete", http.MethodPost, func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"fidlist": fidList,
+		})
+	}, nil)
+	return err
+}
@@ -6,14 +6,28 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 var (
-	ErrBaiduEmptyFilesNotAllowed = errors.New("empty files are not allowed by baidu netdisk")
+	ErrTokenInvalid = errs.NewErr(errs.Unauthorized, "refresh token failed, please re-authorize")
+	// errTokenRejected 百度拒绝了 refresh_token，与网络错误区分
+	errTokenRejected = errs.NewErr(errs.Unauthorized, "refresh token rejected by baidu")
+	ErrObjectExists  = errors.New("file or folder already exists (errno -8)")
+
+	ErrSharePwdRequired = errors.New("the share link requires an extraction code")
+	ErrSharePwdWrong    = errors.New("wrong extraction code of the share link")
+	ErrShareExpired     = errors.New("the share link has expired or been cancelled")
 )
 
+type TokenResp struct {
+	base.TokenResp
+	ExpiresIn int64 `json:"expires_in"` // access_token 的有效期，单位秒
+}
+
 type TokenErrResp struct {
 	ErrorDescription string `json:"error_description"`
 	Error            string `json:"error"`
@@ -28,9 +42,9 @@ type File struct {
 	//OperId      int   `json:"oper_id"`
 	Thumbs struct {
 		//Icon string `json:"icon"`
-		Url3 string `json:"url3"`
-		//Url2 string `json:"url2"`
-		//Url1 string `json:"url1"`
+		Url3 string `json:"url3"` // c850_u580
+		Url2 string `json:"url2"` // c360_u270
+		Url1 string `json:"url1"` // c140_u90
 	} `json:"thumbs"`
 	//Wpfile         int    `json:"wpfile"`
 
@@ -80,8 +94,33 @@ func fileToObj(f File) *model.ObjThumb {
 			// 直接获取的MD5是错误的
 			HashInfo: utils.NewHashInfo(utils.MD5, DecryptMd5(f.Md5)),
 		},
-		Thumbnail: model.Thumbnail{Thumbnail: f.Thumbs.Url3},
+		Thumbnail: model.Thumbnail{Thumbnail: f.thumbnail(850)},
+	}
+}
+
+// 百度为图片和视频提供缩略图，视频的缩略图即封面帧，其他类型返回空以使用图标
+func (f File) thumbnail(size int) string {
+	if f.Category != 1 && f.Category != 3 {
+		return ""
 	}
+	thumbs := []struct {
+		width int
+		url   string
+	}{{140, f.Thumbs.Url1}, {360, f.Thumbs.Url2}, {850, f.Thumbs.Url3}}
+	res, diff := "", -1
+	for _, t := range thumbs {
+		if t.url == "" {
+			continue
+		}
+		d := t.width - size
+		if d < 0 {
+			d = -d
+		}
+		if diff < 0 || d < diff {
+			res, diff = t.url, d
+		}
+	}
+	return res
 }
 
 type ListResp struct {
@@ -92,6 +131,28 @@ type ListResp struct {
 	Guid      int    `json:"guid"`
 }
 
+type ListAllResp struct {
+	Errno   int    `json:"errno"`
+	HasMore int    `json:"has_more"`
+	Cursor  int    `json:"cursor"`
+	List    []File `json:"list"`
+}
+
+type SearchResp struct {
+	Errno   int    `json:"errno"`
+	HasMore int    `json:"has_more"`
+	List    []File `json:"list"`
+}
+
+type FileMetasResp struct {
+	Errno int `json:"errno"`
+	List  []struct {
+		FsId  int64  `json:"fs_id"`
+		Path  string `json:"path"`
+		Isdir int    `json:"isdir"`
+	} `json:"list"`
+}
+
 type DownloadResp struct {
 	Errmsg string `json:"errmsg"`
 	Errno  int    `json:"errno"`
@@ -195,26 +256,168 @@ type PrecreateResp struct {
 	File File `json:"info"`
 }
 
+type UploadServer struct {
+	Server string `json:"server"`
+}
+
 type UploadServerResp struct {
-	BakServer  []any `json:"bak_server"`
-	BakServers []struct {
-		Server string `json:"server"`
-	} `json:"bak_servers"`
-	ClientIP    string `json:"client_ip"`
-	ErrorCode   int    `json:"error_code"`
-	ErrorMsg    string `json:"error_msg"`
-	Expire      int    `json:"expire"`
-	Host        string `json:"host"`
-	Newno       string `json:"newno"`
-	QuicServer  []any  `json:"quic_server"`
+	BakServer   []any          `json:"bak_server"`
+	BakServers  []UploadServer `json:"bak_servers"`
+	ClientIP    string         `json:"client_ip"`
+	ErrorCode   int            `json:"error_code"`
+	ErrorMsg    string         `json:"error_msg"`
+	Expire      int            `json:"expire"`
+	Host        string         `json:"host"`
+	Newno       string         `json:"newno"`
+	QuicServer  []any          `json:"quic_server"`
 	QuicServers []struct {
 		Server string `json:"server"`
 	} `json:"quic_servers"`
-	RequestID  int64 `json:"request_id"`
-	Server     []any `json:"server"`
-	ServerTime int   `json:"server_time"`
-	Servers    []struct {
-		Server string `json:"server"`
-	} `json:"servers"`
-	Sl int `json:"sl"`
+	RequestID  int64          `json:"request_id"`
+	Server     []any          `json:"server"`
+	ServerTime int            `json:"server_time"`
+	Servers    []UploadServer `json:"servers"`
+	Sl         int            `json:"sl"`
+}
+
+type CloudDlAddResp struct {
+	TaskId        int64 `json:"task_id"`
+	RapidDownload int   `json:"rapid_download"`
+	RequestId     int64 `json:"request_id"`
+}
+
+// CloudDlTaskInfo 离线下载任务信息，status: 0 下载成功，1 下载进行中，2 系统错误，3 资源不存在，
+// 4 下载超时，5 资源存在但下载失败，6 存储空间不足，7 目标地址数据已存在，8 任务取消
+type CloudDlTaskInfo struct {
+	Status       string `json:"status"`
+	FileSize     string `json:"file_size"`
+	FinishedSize string `json:"finished_size"`
+	SavePath     string `json:"save_path"`
+	SourceUrl    string `json:"source_url"`
+	TaskName     string `json:"task_name"`
+	Result       int    `json:"result"`
+}
+
+type CloudDlQueryResp struct {
+	TaskInfo  map[string]CloudDlTaskInfo `json:"task_info"`
+	RequestId int64                      `json:"request_id"`
+}
+
+func (t CloudDlTaskInfo) IsDone() bool {
+	return t.Status == "0"
+}
+
+func (t CloudDlTaskInfo) IsRunning() bool {
+	return t.Status == "1"
+}
+
+func (t CloudDlTaskInfo) GetStatus() string {
+	switch t.Status {
+	case "0":
+		return "finished"
+	case "1":
+		return "downloading"
+	case "2":
+		return "system error"
+	case "3":
+		return "resource not found"
+	case "4":
+		return "download timeout"
+	case "5":
+		return "resource exists but download failed"
+	case "6":
+		return "insufficient storage space"
+	case "7":
+		return "target already exists"
+	case "8":
+		return "task canceled"
+	default:
+		return "unknown status: " + t.Status
+	}
+}
+
+type QuotaResp struct {
+	Errno     int   `json:"errno"`
+	Total     int64 `json:"total"`
+	Used      int64 `json:"used"`
+	Free      int64 `json:"free"`
+	Expire    bool  `json:"expire"`
+	RequestId int64 `json:"request_id"`
+}
+
+type ShareSetResp struct {
+	Errno      int    `json:"errno"`
+	ShareId    int64  `json:"shareid"`
+	Link       string `json:"link"`
+	ShortUrl   string `json:"shorturl"`
+	CreateTime int64  `json:"ctime"`
+	Expiretime int64  `json:"expiretime"`
+	RequestId  int64  `json:"request_id"`
+}
+
+type ShareVerifyResp struct {
+	Errno  int    `json:"errno"`
+	Randsk string `json:"randsk"`
+}
+
+type ShareListResp struct {
+	Errno   int    `json:"errno"`
+	ShareId int64  `json:"share_id"`
+	Uk      int64  `json:"uk"`
+	List    []File `json:"list"`
+}
+
+type ShareTransferResp struct {
+	Errno int `json:"errno"`
+	Extra struct {
+		List []struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"list"`
+	} `json:"extra"`
+}
+
+type RecycleFile struct {
+	FsId           int64  `json:"fs_id"`
+	Path           string `json:"path"`
+	ServerFilename string `json:"server_filename"`
+	Size           int64  `json:"size"`
+	Isdir          int    `json:"isdir"`
+	Md5            string `json:"md5"`
+	ServerCtime    int64  `json:"server_ctime"`
+	ServerMtime    int64  `json:"server_mtime"`
+	LeftTime       int64  `json:"leftTime"` // 剩余保留天数
+}
+
+type RecycleListResp struct {
+	Errno     int           `json:"errno"`
+	List      []RecycleFile `json:"list"`
+	RequestId int64         `json:"request_id"`
+}
+
+// FileVersion 文件的一个历史版本
+type FileVersion struct {
+	HistoryId   string `json:"history_id"`
+	Size        int64  `json:"size"`
+	Md5         string `json:"md5"`
+	ServerMtime int64  `json:"server_mtime"`
+}
+
+type FileVersionListResp struct {
+	Errno     int           `json:"errno"`
+	List      []FileVersion `json:"list"`
+	HasMore   int           `json:"has_more"`
+	RequestId int64         `json:"request_id"`
+}
+
+type FileVersionDownloadResp struct {
+	Errno int    `json:"errno"`
+	Dlink string `json:"dlink"`
+}
+
+// BlockHash 上传需要的 md5，开启 CacheBlockHash 时按来源文件保存
+type BlockHash struct {
+	ContentMd5 string   `json:"content_md5"`
+	SliceMd5   string   `json:"slice_md5"`
+	BlockList  []string `json:"block_list"`
 }
@@ -1,10 +1,15 @@
 package baidu_netdisk
 
 import (
+	"cmp"
+	"context"
+	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,29 +17,56 @@ import (
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	streamPkg "github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/avast/retry-go"
 	"github.com/go-resty/resty/v2"
+	"github.com/maruel/natural"
 	log "github.com/sirupsen/logrus"
 )
 
 // do others that not defined in Driver interface
 
-func (d *BaiduNetdisk) refreshToken() error {
-	err := d._refreshToken()
-	if err != nil && errors.Is(err, errs.EmptyToken) {
-		err = d._refreshToken()
+// refreshToken 刷新 access_token。并发请求同时发现 token 过期时只会刷新一次，
+// 其余请求等待并复用新的 token，因为百度可能会使短时间内重复使用的 refresh_token 失效。
+// expiredToken 为请求时使用的 access_token，如果已经被其他请求刷新过则直接返回
+func (d *BaiduNetdisk) refreshToken(expiredToken string) error {
+	d.tokenMu.Lock()
+	if d.tokenInvalid != nil {
+		d.tokenMu.Unlock()
+		return d.tokenInvalid
 	}
+	refreshed := d.AccessToken != expiredToken
+	d.tokenMu.Unlock()
+	if refreshed {
+		return nil
+	}
+	_, err, _ := d.tokenG.Do("refresh", func() (string, error) {
+		err := d._refreshToken(context.Background())
+		if err != nil && errors.Is(err, errs.EmptyToken) {
+			err = d._refreshToken(context.Background())
+		}
+		// 只有百度拒绝 refresh_token 时才标记失效，网络错误等由之后的请求再次刷新
+		if errors.Is(err, errTokenRejected) {
+			d.setTokenInvalid(err)
+			return "", d.tokenInvalid
+		}
+		if err != nil {
+			return "", err
+		}
+		return d.AccessToken, nil
+	})
 	return err
 }
 
-func (d *BaiduNetdisk) _refreshToken() error {
+func (d *BaiduNetdisk) _refreshToken(ctx context.Context) error {
 	u := "https://openapi.baidu.com/oauth/2.0/token"
-	var resp base.TokenResp
+	var resp TokenResp
 	var e TokenErrResp
-	_, err := base.RestyClient.R().SetResult(&resp).SetError(&e).SetQueryParams(map[string]string{
+	_, err := base.SetHeaders(d.client.R(), d.customHeaders).SetContext(ctx).SetResult(&resp).SetError(&e).SetQueryParams(map[string]string{
 		"grant_type":    "refresh_token",
 		"refresh_token": d.RefreshToken,
 		"client_id":     d.ClientID,
@@ -44,24 +76,80 @@ func (d *BaiduNetdisk) _refreshToken() error {
 		return err
 	}
 	if e.Error != "" {
-		return fmt.Errorf("%s : %s", e.Error, e.ErrorDescription)
+		return fmt.Errorf("%w: %s : %s", errTokenRejected, e.Error, e.ErrorDescription)
 	}
 	if resp.RefreshToken == "" {
 		return errs.EmptyToken
 	}
+	// 同时更新两个 token 并立即保存，避免新的 refresh_token 丢失
+	d.tokenMu.Lock()
 	d.AccessToken, d.RefreshToken = resp.AccessToken, resp.RefreshToken
+	d.AccessTokenExpiresAt = 0
+	if resp.ExpiresIn > 0 {
+		d.AccessTokenExpiresAt = time.Now().Unix() + resp.ExpiresIn
+	}
 	op.MustSaveDriverStorage(d)
+	d.tokenMu.Unlock()
+	d.notifyTokenRenewed()
 	return nil
 }
 
-func (d *BaiduNetdisk) request(furl string, method string, callback base.ReqCallback, resp interface{}) ([]byte, error) {
+// newAdaptiveLimiter 按 AIMD 调整 API 请求速率，每次变化都记录日志并更新 metrics
+func (d *BaiduNetdisk) newAdaptiveLimiter() *base.AdaptiveLimiter {
+	cooldown := time.Duration(d.RateLimitCooldown) * time.Second
+	if cooldown <= 0 {
+		cooldown = RATE_LIMIT_COOLDOWN
+	}
+	metrics.SetAdaptiveRate(d, d.APIRateLimit)
+	return base.NewAdaptiveLimiter(base.AdaptiveLimiterOptions{
+		Max:      d.APIRateLimit,
+		Cooldown: cooldown,
+		Hooks: []base.RateLimitHook{
+			func(ev base.RateLimitEvent) {
+				if ev.Limited {
+					log.Warnf("[baidu_netdisk] [%s] hit rate limit, api rate lowered from %s to %.2fr/s until %s",
+						d.MountPath, formatRate(ev.Prev), ev.Rate, ev.Until.Format(time.DateTime))
+				} else {
+					log.Infof("[baidu_netdisk] [%s] api rate recovered from %.2fr/s to %s",
+						d.MountPath, ev.Prev, formatRate(ev.Rate))
+				}
+			},
+			func(ev base.RateLimitEvent) {
+				metrics.SetAdaptiveRate(d, ev.Rate)
+			},
+		},
+	})
+}
+
+func formatRate(r float64) string {
+	if r <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%.2fr/s", r)
+}
+
+func (d *BaiduNetdisk) request(ctx context.Context, furl string, method string, callback base.ReqCallback, resp interface{}) ([]byte, error) {
 	var result []byte
 	err := retry.Do(func() error {
-		req := base.RestyClient.R()
-		req.SetQueryParam("access_token", d.AccessToken)
+		d.tokenMu.Lock()
+		accessToken, tokenInvalid := d.AccessToken, d.tokenInvalid
+		d.tokenMu.Unlock()
+		if tokenInvalid != nil {
+			return retry.Unrecoverable(tokenInvalid)
+		}
+		req := d.client.R().SetContext(ctx)
+		req.SetQueryParam("access_token", accessToken)
 		if callback != nil {
 			callback(req)
 		}
+		// 取消或超时后不再等待限速和重试
+		if err := ctx.Err(); err != nil {
+			return retry.Unrecoverable(err)
+		}
+		if err := d.WaitLimit(ctx); err != nil {
+			return retry.Unrecoverable(err)
+		}
+		base.SetHeaders(req, d.customHeaders)
 		if resp != nil {
 			req.SetResult(resp)
 		}
@@ -69,54 +157,158 @@ func (d *BaiduNetdisk) request(furl string, method string, callback base.ReqCall
 		if err != nil {
 			return err
 		}
-		log.Debugf("[baidu_netdisk] req: %s, resp: %s", furl, res.String())
+		if res.StatusCode() >= http.StatusInternalServerError {
+			return fmt.Errorf("%w: req: [%s], status: %s", errs.Unavailable, furl, res.Status())
+		}
 		errno := utils.Json.Get(res.Body(), "errno").ToInt()
 		if errno != 0 {
+			errnoErr := newErrnoError(furl, errno, res.Body())
+			// 历史版本是网页版接口，拒绝 token 时不刷新 token，避免每次调用都刷新
+			if strings.Contains(furl, "/api/filehistory") {
+				if err := versionErr(errno); err != nil {
+					return retry.Unrecoverable(fmt.Errorf("%w: req: [%s], errno: %d", err, furl, errno))
+				}
+			}
+
 			if utils.SliceContains([]int{111, -6}, errno) {
 				log.Info("[baidu_netdisk] refreshing baidu_netdisk token.")
-				err2 := d.refreshToken()
+				err2 := d.refreshToken(accessToken)
 				if err2 != nil {
 					return retry.Unrecoverable(err2)
 				}
 			}
 
+			// 触发频率限制，降低请求速率，由 retry 退避后重试
+			if errno == 31034 {
+				metrics.IncRateLimited(d)
+				if d.adaptiveLimiter != nil {
+					d.adaptiveLimiter.Limited()
+				} else {
+					log.Warnf("[baidu_netdisk] hit rate limit, req: %s, consider setting api_rate_limit or adaptive_rate_limit", furl)
+				}
+				return errnoErr
+			}
+
+			// 路径已被占用，重试没有意义
+			if errno == -8 {
+				return retry.Unrecoverable(fmt.Errorf("%w: %w", ErrObjectExists, errnoErr))
+			}
+
+			// 转存分享链接时 -9 表示提取码错误，不是文件不存在
+			if strings.Contains(furl, "/xpan/share") {
+				if err := shareErr(errno); err != nil {
+					return retry.Unrecoverable(fmt.Errorf("%w: req: [%s], errno: %d", err, furl, errno))
+				}
+			}
+
 			if 31023 == errno && d.DownloadAPI == "crack_video" {
 				result = res.Body()
 				return nil
 			}
 
-			return fmt.Errorf("req: [%s] ,errno: %d, refer to https://pan.baidu.com/union/doc/", furl, errno)
+			if errnoErr.retryable() {
+				return errnoErr
+			}
+			return retry.Unrecoverable(errnoErr)
 		}
 		result = res.Body()
 		return nil
 	},
 		retry.LastErrorOnly(true),
+		// 取消或超时后不再等待下一次重试
+		retry.Context(ctx),
+		retry.OnRetry(func(uint, error) {
+			metrics.IncRetry(d)
+		}),
 		retry.Attempts(3),
 		retry.Delay(time.Second),
 		retry.DelayType(retry.BackOffDelay))
 	return result, err
 }
 
-func (d *BaiduNetdisk) get(pathname string, params map[string]string, resp interface{}) ([]byte, error) {
-	return d.request("https://pan.baidu.com/rest/2.0"+pathname, http.MethodGet, func(req *resty.Request) {
+func (d *BaiduNetdisk) get(ctx context.Context, pathname string, params map[string]string, resp interface{}) ([]byte, error) {
+	return d.request(ctx, "https://pan.baidu.com/rest/2.0"+pathname, http.MethodGet, func(req *resty.Request) {
 		req.SetQueryParams(params)
 	}, resp)
 }
 
-func (d *BaiduNetdisk) postForm(pathname string, params map[string]string, form map[string]string, resp interface{}) ([]byte, error) {
-	return d.request("https://pan.baidu.com/rest/2.0"+pathname, http.MethodPost, func(req *resty.Request) {
+func (d *BaiduNetdisk) postForm(ctx context.Context, pathname string, params map[string]string, form map[string]string, resp interface{}) ([]byte, error) {
+	return d.request(ctx, "https://pan.baidu.com/rest/2.0"+pathname, http.MethodPost, func(req *resty.Request) {
 		req.SetQueryParams(params)
 		req.SetFormData(form)
 	}, resp)
 }
 
-func (d *BaiduNetdisk) getFiles(dir string) ([]File, error) {
+func (d *BaiduNetdisk) getFiles(ctx context.Context, dir string) ([]File, error) {
 	start := 0
 	limit := 200
+	res := make([]File, 0)
+	for {
+		files, n, err := d.getFilesPage(ctx, dir, start, limit)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		start += limit
+		res = append(res, files...)
+	}
+	return res, nil
+}
+
+// sortObjs 按 order_by 重新排序合并分页并过滤后的列表，保证返回的顺序与设置一致。
+// 排序字段相同时按名称升序，百度中文件夹的大小为 0，按大小排序时文件夹总在文件之前并按名称排序
+func (d *BaiduNetdisk) sortObjs(objs []model.Obj) {
+	if d.OrderBy == "" {
+		return
+	}
+	desc := d.OrderDirection == "desc"
+	sort.SliceStable(objs, func(i, j int) bool {
+		a, b := objs[i], objs[j]
+		if d.OrderBy == "size" && a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		var c int
+		switch d.OrderBy {
+		case "time":
+			c = a.ModTime().Compare(b.ModTime())
+		case "size":
+			if !a.IsDir() {
+				c = cmp.Compare(a.GetSize(), b.GetSize())
+			}
+		}
+		if c == 0 {
+			c = compareName(a.GetName(), b.GetName())
+			if d.OrderBy != "name" {
+				return c < 0
+			}
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func compareName(a, b string) int {
+	switch {
+	case natural.Less(a, b):
+		return -1
+	case natural.Less(b, a):
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// getFilesPage 获取一页文件，n 为接口返回的条数（过滤前），为 0 时表示没有更多
+func (d *BaiduNetdisk) getFilesPage(ctx context.Context, dir string, start, limit int) ([]File, int, error) {
 	params := map[string]string{
 		"method": "list",
 		"dir":    dir,
 		"web":    "web",
+		"start":  strconv.Itoa(start),
+		"limit":  strconv.Itoa(limit),
 	}
 	if d.OrderBy != "" {
 		params["order"] = d.OrderBy
@@ -124,62 +316,118 @@ func (d *BaiduNetdisk) getFiles(dir string) ([]File, error) {
 			params["desc"] = "1"
 		}
 	}
-	res := make([]File, 0)
-	for {
-		params["start"] = strconv.Itoa(start)
-		params["limit"] = strconv.Itoa(limit)
-		start += limit
-		var resp ListResp
-		_, err := d.get("/xpan/file", params, &resp)
-		if err != nil {
-			return nil, err
-		}
-		if len(resp.List) == 0 {
-			break
+	var resp ListResp
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/xpan/file", http.MethodGet, func(req *resty.Request) {
+		req.SetQueryParams(params)
+	}, &resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !d.OnlyListVideoFile {
+		return resp.List, len(resp.List), nil
+	}
+	res := make([]File, 0, len(resp.List))
+	for _, file := range resp.List {
+		if d.listed(file) {
+			res = append(res, file)
 		}
+	}
+	return res, len(resp.List), nil
+}
 
-		if d.OnlyListVideoFile {
-			for _, file := range resp.List {
-				if file.Isdir == 1 || file.Category == 1 {
-					res = append(res, file)
-				}
-			}
-		} else {
-			res = append(res, resp.List...)
-		}
+// listed 判断开启只列出视频时是否列出文件，文件夹、视频和 OnlyListExtensions 中的文件总是列出
+func (d *BaiduNetdisk) listed(file File) bool {
+	if !d.OnlyListVideoFile || file.Isdir == 1 || file.Category == 1 {
+		return true
 	}
-	return res, nil
+	_, ok := d.onlyListExts[utils.Ext(file.Path)]
+	return ok
+}
+
+// GetRootPath 设置了 RootFsID 时返回按 fs_id 解析出的根目录路径
+func (d *BaiduNetdisk) GetRootPath() string {
+	if d.RootFsID == "" {
+		return d.RootFolderPath
+	}
+	d.rootMu.RLock()
+	defer d.rootMu.RUnlock()
+	return d.rootPath
+}
+
+// resolveRoot 通过 filemetas 按 RootFsID 获取根目录的当前路径，返回之前的路径。
+// 百度网盘的 list 和 filemanager 接口只接受路径，所以只在初始化和根目录路径失效时解析一次
+func (d *BaiduNetdisk) resolveRoot(ctx context.Context) (string, error) {
+	var resp FileMetasResp
+	params := map[string]string{
+		"method": "filemetas",
+		"fsids":  fmt.Sprintf("[%s]", d.RootFsID),
+	}
+	if _, err := d.get(ctx, "/xpan/multimedia", params, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.List) == 0 {
+		return "", fmt.Errorf("%w: fs_id [%s]", errs.ObjectNotFound, d.RootFsID)
+	}
+	if resp.List[0].Isdir != 1 {
+		return "", fmt.Errorf("fs_id [%s] is not a folder", d.RootFsID)
+	}
+	d.rootMu.Lock()
+	defer d.rootMu.Unlock()
+	old := d.rootPath
+	d.rootPath = utils.FixAndCleanPath(resp.List[0].Path)
+	if old != d.rootPath {
+		log.Infof("[baidu_netdisk] root of fs_id [%s] is [%s]", d.RootFsID, d.rootPath)
+	}
+	return old, nil
 }
 
-func (d *BaiduNetdisk) linkOfficial(file model.Obj, _ model.LinkArgs) (*model.Link, error) {
+// getDlink 通过 filemetas 按 fs_id 获取 dlink，需要带上 access_token 和 pan.baidu.com 的 UA 访问。
+// 返回的地址带有 access_token，不能交给客户端
+func (d *BaiduNetdisk) getDlink(ctx context.Context, file model.Obj) (string, error) {
 	var resp DownloadResp
 	params := map[string]string{
 		"method": "filemetas",
 		"fsids":  fmt.Sprintf("[%s]", file.GetID()),
 		"dlink":  "1",
 	}
-	_, err := d.get("/xpan/multimedia", params, &resp)
+	_, err := d.request(ctx, "https://pan.baidu.com/rest/2.0/xpan/multimedia", http.MethodGet, func(req *resty.Request) {
+		req.SetQueryParams(params)
+	}, &resp)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	u := fmt.Sprintf("%s&access_token=%s", resp.List[0].Dlink, d.AccessToken)
-	res, err := base.NoRedirectClient.R().SetHeader("User-Agent", "pan.baidu.com").Head(u)
-	if err != nil {
-		return nil, err
+	if len(resp.List) == 0 || resp.List[0].Dlink == "" {
+		return "", fmt.Errorf("empty dlink of [%s]", file.GetPath())
 	}
-	//if res.StatusCode() == 302 {
-	u = res.Header().Get("location")
-	//}
+	return fmt.Sprintf("%s&access_token=%s", resp.List[0].Dlink, d.AccessToken), nil
+}
 
-	return &model.Link{
-		URL: u,
-		Header: http.Header{
-			"User-Agent": []string{"pan.baidu.com"},
-		},
-	}, nil
+func (d *BaiduNetdisk) linkOfficial(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	u, err := d.getDlink(ctx, file)
+	if err != nil {
+		// dlink 获取失败时回退到 crack 接口
+		log.Warnf("[baidu_netdisk] failed get dlink, fallback to crack api: %v", err)
+		return d.linkCrack(ctx, file, args)
+	}
+	header := http.Header{
+		"User-Agent": []string{"pan.baidu.com"},
+	}
+	// 解析出 302 的下载节点地址，浏览器直接访问时不需要特定的 UA
+	res, err := base.SetHeaders(d.noRedirectClient.R().SetContext(ctx).SetHeader("User-Agent", "pan.baidu.com"), d.customHeaders).Head(u)
+	if err == nil {
+		if location := res.Header().Get("location"); location != "" {
+			// 下载节点失效时回退到 dlink，重新跳转到其他节点
+			return &model.Link{URL: location, Header: header, Candidates: []model.LinkCandidate{
+				{URL: u, Header: header, SupportRange: true, NeedUA: true},
+			}}, nil
+		}
+	}
+	// 解析失败时直接使用 dlink，带有 access_token，只能由 alist 代理下载
+	log.Debugf("[baidu_netdisk] failed resolve dlink of [%s], proxy it: %v", file.GetPath(), err)
+	return &model.Link{URL: u, Header: header, MustProxy: true}, nil
 }
 
-func (d *BaiduNetdisk) linkCrack(file model.Obj, _ model.LinkArgs) (*model.Link, error) {
+func (d *BaiduNetdisk) linkCrack(ctx context.Context, file model.Obj, _ model.LinkArgs) (*model.Link, error) {
 	var resp DownloadResp2
 	param := map[string]string{
 		"target": fmt.Sprintf("[\"%s\"]", file.GetPath()),
@@ -187,7 +435,7 @@ func (d *BaiduNetdisk) linkCrack(file model.Obj, _ model.LinkArgs) (*model.Link,
 		"web":    "5",
 		"origin": "dlna",
 	}
-	_, err := d.request("https://pan.baidu.com/api/filemetas", http.MethodGet, func(req *resty.Request) {
+	_, err := d.request(ctx, "https://pan.baidu.com/api/filemetas", http.MethodGet, func(req *resty.Request) {
 		req.SetQueryParams(param)
 	}, &resp)
 	if err != nil {
@@ -202,7 +450,7 @@ func (d *BaiduNetdisk) linkCrack(file model.Obj, _ model.LinkArgs) (*model.Link,
 	}, nil
 }
 
-func (d *BaiduNetdisk) linkCrackVideo(file model.Obj, _ model.LinkArgs) (*model.Link, error) {
+func (d *BaiduNetdisk) linkCrackVideo(ctx context.Context, file model.Obj, _ model.LinkArgs) (*model.Link, error) {
 	param := map[string]string{
 		"type":       "VideoURL",
 		"path":       fmt.Sprintf("%s", file.GetPath()),
@@ -215,7 +463,7 @@ func (d *BaiduNetdisk) linkCrackVideo(file model.Obj, _ model.LinkArgs) (*model.
 		"media":      "1",
 		"origin":     "dlna",
 	}
-	resp, err := d.request("https://pan.baidu.com/api/mediainfo", http.MethodGet, func(req *resty.Request) {
+	resp, err := d.request(ctx, "https://pan.baidu.com/api/mediainfo", http.MethodGet, func(req *resty.Request) {
 		req.SetQueryParams(param)
 	}, nil)
 	if err != nil {
@@ -230,20 +478,233 @@ func (d *BaiduNetdisk) linkCrackVideo(file model.Obj, _ model.LinkArgs) (*model.
 	}, nil
 }
 
-func (d *BaiduNetdisk) manage(opera string, filelist any) ([]byte, error) {
+// savePersistentProgress 持久化 uploadid 和未上传的分片，alist 重启后可继续上传
+func (d *BaiduNetdisk) savePersistentProgress(pre *PrecreateResp, keys []string) {
+	state := *pre
+	state.BlockList = utils.SliceFilter(pre.BlockList, func(s int) bool { return s >= 0 })
+	if err := base.SavePersistentUploadProgress(d, &state, UPLOAD_URL_EXPIRE_TIME, keys...); err != nil {
+		log.Warnf("[baidu_netdisk] failed save upload state: %+v", err)
+	}
+}
+
+var downloadAPIs = []string{"official", "crack", "crack_video"}
+
+// parseDownloadAPIRules 解析 "mp4,mkv:crack_video" 格式的规则，每行一条，扩展名不区分大小写
+func parseDownloadAPIRules(s string) (map[string]string, error) {
+	rules := make(map[string]string)
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		exts, api, ok := strings.Cut(line, ":")
+		api = strings.TrimSpace(api)
+		if !ok {
+			return nil, fmt.Errorf("invalid download_api_rules at line %d: %q, expect 'ext1,ext2:api'", i+1, line)
+		}
+		if !utils.SliceContains(downloadAPIs, api) {
+			return nil, fmt.Errorf("invalid download_api_rules at line %d: unknown api %q, expect one of %s", i+1, api, strings.Join(downloadAPIs, ","))
+		}
+		for _, ext := range strings.Split(exts, ",") {
+			ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+			if ext == "" {
+				return nil, fmt.Errorf("invalid download_api_rules at line %d: empty extension", i+1)
+			}
+			rules[ext] = api
+		}
+	}
+	return rules, nil
+}
+
+// initUploadThread 解析并校验上传线程数，超出 [1, 32] 时修正并保存，低带宽模式下减少并发
+func (d *BaiduNetdisk) initUploadThread() error {
+	if strings.TrimSpace(d.UploadThread) == "" {
+		d.UploadThread = strconv.Itoa(DEFAULT_UPLOAD_THREAD)
+	}
+	thread, err := strconv.Atoi(strings.TrimSpace(d.UploadThread))
+	if err != nil {
+		return fmt.Errorf("invalid upload_thread [%s], it should be an integer between 1 and %d", d.UploadThread, MAX_UPLOAD_THREAD)
+	}
+	if clamped := min(max(thread, 1), MAX_UPLOAD_THREAD); clamped != thread {
+		log.Warnf("[baidu_netdisk] upload_thread %d is out of [1, %d], use %d", thread, MAX_UPLOAD_THREAD, clamped)
+		thread = clamped
+		d.UploadThread = strconv.Itoa(thread)
+		op.MustSaveDriverStorage(d)
+	}
+	d.uploadThread = thread
+	if d.LowBandwithUploadMode && d.uploadThread > LOW_BANDWIDTH_UPLOAD_THREAD {
+		log.Debugf("[baidu_netdisk] reduce upload threads from %d to %d in low bandwidth mode", d.uploadThread, LOW_BANDWIDTH_UPLOAD_THREAD)
+		d.uploadThread = LOW_BANDWIDTH_UPLOAD_THREAD
+	}
+	return nil
+}
+
+// parseExtensions 解析逗号分隔的扩展名，忽略大小写和开头的 "."
+func parseExtensions(s string) map[string]struct{} {
+	exts := make(map[string]struct{})
+	for _, ext := range strings.Split(s, ",") {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext != "" {
+			exts[ext] = struct{}{}
+		}
+	}
+	return exts
+}
+
+// downloadAPI 按扩展名选择下载接口，没有匹配的规则时使用 DownloadAPI
+func (d *BaiduNetdisk) downloadAPI(file model.Obj) string {
+	if api, ok := d.downloadAPIRules[utils.Ext(file.GetName())]; ok {
+		return api
+	}
+	return d.DownloadAPI
+}
+
+// IllegalNameChars 从其他存储复制过来的文件名中需要编码的字符
+func (d *BaiduNetdisk) IllegalNameChars() string {
+	return ILLEGAL_NAME_CHARS
+}
+
+func (d *BaiduNetdisk) nameRule() base.NameRule {
+	return base.NameRule{MaxLength: d.MaxNameLength, Illegal: ILLEGAL_NAME_CHARS}
+}
+
+// checkName 按百度网盘的命名规则检查文件名，按 NamePolicy 拒绝或替换为合法的文件名，
+// 否则百度只会返回含义不明的错误码
+func (d *BaiduNetdisk) checkName(name string) (string, error) {
+	return d.nameRule().Check(name, d.NamePolicy, d.NameSubstitute)
+}
+
+// renameStream 按命名规则检查上传流的文件名，需要替换时返回使用新文件名的流
+func (d *BaiduNetdisk) renameStream(stream model.FileStreamer) (model.FileStreamer, error) {
+	name, err := d.checkName(stream.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if name != stream.GetName() {
+		return &renamedStream{FileStreamer: stream, name: name}, nil
+	}
+	return stream, nil
+}
+
+// renamedStream 替换上传流的文件名
+type renamedStream struct {
+	model.FileStreamer
+	name string
+}
+
+func (s *renamedStream) GetName() string {
+	return s.name
+}
+
+// GetExist 已存在的对象是按原文件名查找的，与替换后的文件名无关
+func (s *renamedStream) GetExist() model.Obj {
+	return nil
+}
+
+func (s *renamedStream) IsSkipIfMatch() bool {
+	return streamPkg.SkipIfMatch(s.FileStreamer)
+}
+
+func (s *renamedStream) GetConflict() string {
+	return streamPkg.Conflict(s.FileStreamer)
+}
+
+// uploadRtype 返回上传时 precreate 和 create 的 rtype，优先使用本次上传指定的冲突处理方式。
+// skip 在 op 中已经跳过已存在的文件，此时对应 rtype 0，上传期间出现的同名文件返回 -8
+func (d *BaiduNetdisk) uploadRtype(stream model.FileStreamer) string {
+	switch streamPkg.Conflict(stream) {
+	case streamPkg.ConflictOverwrite:
+		return RTYPE_OVERWRITE
+	case streamPkg.ConflictRename:
+		return RTYPE_RENAME
+	case streamPkg.ConflictSkip:
+		return RTYPE_FAIL
+	}
+	switch d.UploadConflict {
+	case "rename":
+		return RTYPE_RENAME
+	case "rename_if_changed":
+		return RTYPE_RENAME_IF_CHANGED
+	case "fail":
+		return RTYPE_FAIL
+	default:
+		return RTYPE_OVERWRITE
+	}
+}
+
+// setLinkExpiration 从下载链接的签名参数（如 expires=8h&dstime=...）中解析有效期，供链接缓存使用，
+// 不超过存储设置的 link_cache_ttl
+func (d *BaiduNetdisk) setLinkExpiration(link *model.Link) {
+	if exp := base.GetURLExpiration(link.URL); exp > 0 {
+		if d.LinkCacheTTL > 0 {
+			exp = min(exp, time.Duration(d.LinkCacheTTL)*time.Second)
+		}
+		link.Expiration = &exp
+	}
+}
+
+// supportRange 通过请求第一个字节判断下载链接是否支持 Range
+func (d *BaiduNetdisk) supportRange(ctx context.Context, link *model.Link) bool {
+	res, err := d.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		SetHeaderMultiValues(link.Header).
+		SetHeader("Range", "bytes=0-0").
+		Get(link.URL)
+	if err != nil {
+		log.Debugf("[baidu_netdisk] range probe failed: %v", err)
+		return false
+	}
+	_ = res.RawBody().Close()
+	return res.StatusCode() == http.StatusPartialContent
+}
+
+// createShare 创建分享链接，period 为有效天数，0 表示永久
+func (d *BaiduNetdisk) createShare(ctx context.Context, fsIds []string, pwd string, period int) (*ShareSetResp, error) {
+	ids := make([]int64, 0, len(fsIds))
+	for _, id := range fsIds {
+		fsId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fs_id: %s", id)
+		}
+		ids = append(ids, fsId)
+	}
+	fidList, _ := utils.Json.MarshalToString(ids)
+	var resp ShareSetResp
+	_, err := d.request(ctx, "https://pan.baidu.com/share/set", http.MethodPost, func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"channel":    "chunlei",
+			"clienttype": "0",
+			"web":        "1",
+		})
+		req.SetFormData(map[string]string{
+			"fid_list":     fidList,
+			"schannel":     "4",
+			"channel_list": "[]",
+			"period":       strconv.Itoa(period),
+			"pwd":          pwd,
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (d *BaiduNetdisk) manage(ctx context.Context, opera string, filelist any) ([]byte, error) {
 	params := map[string]string{
 		"method": "filemanager",
 		"opera":  opera,
 	}
 	marshal, _ := utils.Json.MarshalToString(filelist)
-	return d.postForm("/xpan/file", params, map[string]string{
+	return d.postForm(ctx, "/xpan/file", params, map[string]string{
 		"async":    "0",
 		"filelist": marshal,
 		"ondup":    "fail",
 	}, nil)
 }
 
-func (d *BaiduNetdisk) create(path string, size int64, isdir int, uploadid, block_list string, resp any, mtime, ctime int64) ([]byte, error) {
+func (d *BaiduNetdisk) create(ctx context.Context, path string, size int64, isdir int, rtype, uploadid, block_list string, resp any, mtime, ctime int64) ([]byte, error) {
 	params := map[string]string{
 		"method": "create",
 	}
@@ -251,11 +712,9 @@ func (d *BaiduNetdisk) create(path string, size int64, isdir int, uploadid, bloc
 		"path":  path,
 		"size":  strconv.FormatInt(size, 10),
 		"isdir": strconv.Itoa(isdir),
-		"rtype": "3",
-	}
-	if mtime != 0 && ctime != 0 {
-		joinTime(form, ctime, mtime)
+		"rtype": rtype,
 	}
+	joinTime(form, ctime, mtime)
 
 	if uploadid != "" {
 		form["uploadid"] = uploadid
@@ -263,14 +722,42 @@ func (d *BaiduNetdisk) create(path string, size int64, isdir int, uploadid, bloc
 	if block_list != "" {
 		form["block_list"] = block_list
 	}
-	return d.postForm("/xpan/file", params, form, resp)
+	return d.postForm(ctx, "/xpan/file", params, form, resp)
 }
 
+// joinTime 设置文件的本地时间，百度以其作为文件时间，为 0 时使用上传时间。ctime 未知时与 mtime 相同
 func joinTime(form map[string]string, ctime, mtime int64) {
+	if mtime <= 0 {
+		return
+	}
+	if ctime <= 0 {
+		ctime = mtime
+	}
 	form["local_mtime"] = strconv.FormatInt(mtime, 10)
 	form["local_ctime"] = strconv.FormatInt(ctime, 10)
 }
 
+// unixTime 返回时间戳，零值返回 0，避免把未知的时间作为负数传给百度
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// streamTime 返回上传文件的 ctime 和 mtime，未知时与 joinTime 一致使用上传时间
+func streamTime(stream model.FileStreamer) (ctime, mtime int64) {
+	mtime = unixTime(stream.ModTime())
+	if mtime <= 0 {
+		mtime = time.Now().Unix()
+	}
+	ctime = unixTime(stream.CreateTime())
+	if ctime <= 0 {
+		ctime = mtime
+	}
+	return ctime, mtime
+}
+
 const (
 	DefaultSliceSize int64 = 4 * utils.MB
 	VipSliceSize     int64 = 16 * utils.MB
@@ -278,8 +765,22 @@ const (
 
 	MaxSliceNum       = 2048 // 文档写的是 1024/没写 ，但实际测试是 2048
 	SliceStep   int64 = 1 * utils.MB
+
+	SliceMd5Size    int64 = 256 * utils.KB // slice-md5 为文件前 256KB 的 md5
+	MaxUploadMemory int64 = 256 * utils.MB // 并发上传的分片总大小上限
 )
 
+// maxSliceSize 当前会员等级允许的最大分片大小
+func (d *BaiduNetdisk) maxSliceSize() int64 {
+	switch d.vipType {
+	case 1:
+		return VipSliceSize
+	case 2:
+		return SVipSliceSize
+	}
+	return DefaultSliceSize
+}
+
 func (d *BaiduNetdisk) getSliceSize(filesize int64) int64 {
 	// 非会员固定为 4MB
 	if d.vipType == 0 {
@@ -309,48 +810,62 @@ func (d *BaiduNetdisk) getSliceSize(filesize int64) int64 {
 			return SVipSliceSize
 		}
 
+		if d.CustomUploadPartSize*int64(d.uploadThread) > MaxUploadMemory {
+			log.Warnf("[baidu_netdisk] CustomUploadPartSize(%d) * UploadThread(%d) exceeds %d bytes, may use too much memory", d.CustomUploadPartSize, d.uploadThread, MaxUploadMemory)
+		}
 		return d.CustomUploadPartSize
 	}
 
-	maxSliceSize := DefaultSliceSize
-
-	switch d.vipType {
-	case 1:
-		maxSliceSize = VipSliceSize
-	case 2:
-		maxSliceSize = SVipSliceSize
-	}
+	maxSliceSize := d.maxSliceSize()
 
-	// upload on low bandwidth
+	// 自动模式: 按文件大小选择分片大小，低带宽模式从最小分片开始
+	size := autoSliceSize(filesize)
 	if d.LowBandwithUploadMode {
-		size := DefaultSliceSize
-
-		for size <= maxSliceSize {
-			if filesize <= MaxSliceNum*size {
-				return size
-			}
+		size = DefaultSliceSize
+	}
+	size = min(size, maxSliceSize)
+	// 分片数量不能超过 MaxSliceNum
+	for size < maxSliceSize && filesize > MaxSliceNum*size {
+		size = min(size+SliceStep, maxSliceSize)
+	}
+	if filesize > MaxSliceNum*size {
+		log.Warnf("[baidu_netdisk] File size(%d) is too large, may cause upload failure", filesize)
+		return size
+	}
 
-			size += SliceStep
+	// 并发上传时每个线程都会占用一个分片的内存
+	if threads := int64(d.uploadThread); threads > 0 && size*threads > MaxUploadMemory {
+		limit := max(MaxUploadMemory/threads/SliceStep*SliceStep, DefaultSliceSize)
+		if limit < size && filesize <= MaxSliceNum*limit {
+			log.Debugf("[baidu_netdisk] reduce slice size from %d to %d for %d upload threads", size, limit, threads)
+			size = limit
 		}
 	}
+	return size
+}
 
-	if filesize > MaxSliceNum*maxSliceSize {
-		log.Warnf("[baidu_netdisk] File size(%d) is too large, may cause upload failure", filesize)
+// autoSliceSize 按文件大小选择分片大小，避免大文件产生过多分片
+func autoSliceSize(filesize int64) int64 {
+	switch {
+	case filesize < 1*utils.GB:
+		return DefaultSliceSize
+	case filesize < 10*utils.GB:
+		return VipSliceSize
+	default:
+		return SVipSliceSize
 	}
-
-	return maxSliceSize
 }
 
 // getUploadUrl 从开放平台获取上传域名/地址，并发请求会被合并，结果会被缓存1h。
 // 如果获取失败，则返回 Upload API设置项。
-func (d *BaiduNetdisk) getUploadUrl(path, uploadId string) string {
+func (d *BaiduNetdisk) getUploadUrl(ctx context.Context, path, uploadId string) string {
 	if !d.UseDynamicUploadAPI {
 		return d.UploadAPI
 	}
 	getCachedUrlFunc := func() string {
 		d.uploadUrlMu.RLock()
 		defer d.uploadUrlMu.RUnlock()
-		if d.uploadUrl != "" && time.Since(d.uploadUrlUpdateTime) < UPLOAD_URL_EXPIRE_TIME {
+		if d.uploadUrl != "" && time.Since(d.uploadUrlUpdateTime) < d.uploadUrlExpireTime() {
 			return d.uploadUrl
 		}
 		return ""
@@ -366,15 +881,19 @@ func (d *BaiduNetdisk) getUploadUrl(path, uploadId string) string {
 			return uploadUrl, nil
 		}
 
-		uploadUrl, err := d.requestForUploadUrl(path, uploadId)
+		uploadUrls, err := d.requestForUploadUrls(ctx, path, uploadId)
 		if err != nil {
 			return "", err
 		}
-
-		d.uploadUrlMu.Lock()
-		defer d.uploadUrlMu.Unlock()
-		d.uploadUrl = uploadUrl
-		d.uploadUrlUpdateTime = time.Now()
+		uploadUrl := uploadUrls[0]
+		if d.ProbeUploadAPI && len(uploadUrls) > 1 {
+			uploadUrl = fastestUploadUrl(d.probeUploadUrls(ctx, uploadUrls), uploadUrl)
+			if err = ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		log.Debugf("[baidu_netdisk] located upload domain %s", uploadUrl)
+		d.setUploadUrl(uploadUrl)
 		return uploadUrl, nil
 	}
 
@@ -387,10 +906,47 @@ func (d *BaiduNetdisk) getUploadUrl(path, uploadId string) string {
 	return uploadUrl
 }
 
-// requestForUploadUrl 请求获取上传地址。
+// nextUploadUrl 返回还没有失败过的上传域名，依次为重新获取的动态域名、Upload API 设置项和 UPLOAD_FALLBACK_API，
+// 都失败过时返回空
+func (d *BaiduNetdisk) nextUploadUrl(ctx context.Context, path, uploadId string, failed []string) string {
+	if d.UseDynamicUploadAPI {
+		// 丢弃失败的缓存域名，重新获取
+		d.uploadUrlMu.Lock()
+		if utils.SliceContains(failed, d.uploadUrl) {
+			d.uploadUrl = ""
+		}
+		d.uploadUrlMu.Unlock()
+		if uploadUrl := d.getUploadUrl(ctx, path, uploadId); !utils.SliceContains(failed, uploadUrl) {
+			return uploadUrl
+		}
+	}
+	for _, uploadUrl := range []string{d.UploadAPI, UPLOAD_FALLBACK_API} {
+		if !utils.SliceContains(failed, uploadUrl) {
+			return uploadUrl
+		}
+	}
+	return ""
+}
+
+func (d *BaiduNetdisk) setUploadUrl(uploadUrl string) {
+	d.uploadUrlMu.Lock()
+	defer d.uploadUrlMu.Unlock()
+	d.uploadUrl = uploadUrl
+	d.uploadUrlUpdateTime = time.Now()
+}
+
+// uploadUrlExpireTime 缓存上传域名的时间，测速选出的域名按 ProbeUploadInterval 缓存
+func (d *BaiduNetdisk) uploadUrlExpireTime() time.Duration {
+	if d.ProbeUploadAPI && d.ProbeUploadInterval > 0 {
+		return time.Duration(d.ProbeUploadInterval) * time.Second
+	}
+	return UPLOAD_URL_EXPIRE_TIME
+}
+
+// requestForUploadUrls 请求获取上传地址，返回的候选地址按接口给出的顺序排列，备用地址在最后。
 // 实测此接口不需要认证，传method和upload_version就行，不过还是按文档规范调用。
 // https://pan.baidu.com/union/doc/Mlvw5hfnr
-func (d *BaiduNetdisk) requestForUploadUrl(path, uploadId string) (string, error) {
+func (d *BaiduNetdisk) requestForUploadUrls(ctx context.Context, path, uploadId string) ([]string, error) {
 	params := map[string]string{
 		"method":         "locateupload",
 		"appid":          "250528",
@@ -400,22 +956,22 @@ func (d *BaiduNetdisk) requestForUploadUrl(path, uploadId string) (string, error
 	}
 	apiUrl := "https://d.pcs.baidu.com/rest/2.0/pcs/file"
 	var resp UploadServerResp
-	_, err := d.request(apiUrl, http.MethodGet, func(req *resty.Request) {
+	_, err := d.request(ctx, apiUrl, http.MethodGet, func(req *resty.Request) {
 		req.SetQueryParams(params)
 	}, &resp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	var uploadUrl string
-	if len(resp.Servers) > 0 {
-		uploadUrl = resp.Servers[0].Server
-	} else if len(resp.BakServers) > 0 {
-		uploadUrl = resp.BakServers[0].Server
+	var uploadUrls []string
+	for _, s := range append(resp.Servers, resp.BakServers...) {
+		if s.Server != "" && !utils.SliceContains(uploadUrls, s.Server) {
+			uploadUrls = append(uploadUrls, s.Server)
+		}
 	}
-	if uploadUrl == "" {
-		return "", errors.New("upload URL is empty")
+	if len(uploadUrls) == 0 {
+		return nil, errors.New("upload URL is empty")
 	}
-	return uploadUrl, nil
+	return uploadUrls, nil
 }
 
 // func encodeURIComponent(str string) string {
@@ -460,3 +1016,75 @@ func EncryptMd5(originalMd5 string) string {
 	}
 	return out.String()
 }
+
+// blockHashScope 区分不同分片大小计算出的 block_list
+func blockHashScope(sliceSize int64) string {
+	return "baidu_netdisk:" + strconv.FormatInt(sliceSize, 10)
+}
+
+// blockHasher 按分片大小依次计算写入内容的每个分片的 md5
+type blockHasher struct {
+	sliceSize int64
+	written   int64
+	h         hash.Hash
+	list      []string
+}
+
+func newBlockHasher(sliceSize int64, count int) *blockHasher {
+	return &blockHasher{sliceSize: sliceSize, h: md5.New(), list: make([]string, 0, count)}
+}
+
+func (b *blockHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		m := min(int64(len(p)), b.sliceSize-b.written)
+		_, _ = b.h.Write(p[:m])
+		b.written += m
+		p = p[m:]
+		if b.written == b.sliceSize {
+			b.list = append(b.list, hex.EncodeToString(b.h.Sum(nil)))
+			b.h.Reset()
+			b.written = 0
+		}
+	}
+	return n, nil
+}
+
+// blockList 返回所有分片的 md5，写入结束后调用
+func (b *blockHasher) blockList() []string {
+	if b.written > 0 {
+		b.list = append(b.list, hex.EncodeToString(b.h.Sum(nil)))
+		b.h.Reset()
+		b.written = 0
+	}
+	return b.list
+}
+
+// getBlockHash 取出开启 CacheBlockHash 时保存的来源文件的 md5，来源未知或分片数不符时不使用
+func (d *BaiduNetdisk) getBlockHash(source string, stream model.FileStreamer, sliceSize int64) (BlockHash, bool) {
+	var h BlockHash
+	if !d.CacheBlockHash {
+		return h, false
+	}
+	raw, ok := op.GetUploadHash(blockHashScope(sliceSize), source, stream.GetSize(), stream.ModTime())
+	if !ok {
+		return h, false
+	}
+	count := (stream.GetSize() + sliceSize - 1) / sliceSize
+	if err := utils.Json.UnmarshalFromString(raw, &h); err != nil || int64(len(h.BlockList)) != count {
+		return h, false
+	}
+	log.Debugf("[baidu_netdisk] reuse the cached md5 of %s", source)
+	return h, true
+}
+
+func (d *BaiduNetdisk) saveBlockHash(source string, stream model.FileStreamer, sliceSize int64, h BlockHash) {
+	if !d.CacheBlockHash {
+		return
+	}
+	raw, err := utils.Json.MarshalToString(h)
+	if err != nil {
+		return
+	}
+	op.SaveUploadHash(blockHashScope(sliceSize), source, stream.GetSize(), stream.ModTime(), raw)
+}
@@ -0,0 +1,263 @@
+package baidu_netdisk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// crack_video 链接的返回方式，写入 model.Link.Mode
+const (
+	VIDEO_MODE_RANGE  = "range"  // 字节流，远端支持 Range，直接透传
+	VIDEO_MODE_STREAM = "stream" // 字节流，远端不支持 Range，需要开启代理 Range 才能拖动进度
+	VIDEO_MODE_M3U8   = "m3u8"   // 播放列表，代理时分段地址改写为经过 alist 代理
+
+	VIDEO_SEGMENT_QUERY = "bd_segment" // 代理分段时的参数，值为播放列表中 URI 的序号
+	VIDEO_PROBE_SIZE    = 1024
+	MAX_PLAYLIST_SIZE   = 4 * utils.MB
+)
+
+type crackVideo struct {
+	link     *model.Link
+	mode     string
+	playlist *playlist // m3u8 模式下解析出的播放列表
+}
+
+type playlistLine struct {
+	text string
+	uri  int  // 该行引用的 URI 序号，-1 表示没有
+	attr bool // URI 在标签的 URI="..." 属性中，例如 #EXT-X-KEY
+}
+
+type playlist struct {
+	lines []playlistLine
+	uris  []string // 解析为绝对地址的 URI
+}
+
+var uriAttrRe = regexp.MustCompile(`URI="([^"]*)"`)
+
+// parsePlaylist 解析 m3u8，相对地址按播放列表的地址 base 解析为绝对地址
+func parsePlaylist(base *url.URL, data []byte) (*playlist, error) {
+	data = bytes.TrimLeft(data, "\ufeff")
+	if !bytes.HasPrefix(data, []byte("#EXTM3U")) {
+		return nil, fmt.Errorf("not a m3u8 playlist")
+	}
+	pl := &playlist{}
+	add := func(text, ref string, attr bool) error {
+		u, err := base.Parse(ref)
+		if err != nil {
+			return fmt.Errorf("invalid uri in playlist: %s", ref)
+		}
+		pl.lines = append(pl.lines, playlistLine{text: text, uri: len(pl.uris), attr: attr})
+		pl.uris = append(pl.uris, u.String())
+		return nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*utils.KB), MAX_PLAYLIST_SIZE)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var err error
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if m := uriAttrRe.FindStringSubmatch(line); m != nil {
+				err = add(line, m[1], true)
+			} else {
+				pl.lines = append(pl.lines, playlistLine{text: line, uri: -1})
+			}
+		default:
+			err = add(line, line, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pl, scanner.Err()
+}
+
+// render 输出播放列表，第 i 个 URI 替换为 uri(i)
+func (pl *playlist) render(uri func(i int) string) []byte {
+	var buf bytes.Buffer
+	for _, l := range pl.lines {
+		switch {
+		case l.uri < 0:
+			buf.WriteString(l.text)
+		case l.attr:
+			buf.WriteString(uriAttrRe.ReplaceAllLiteralString(l.text, `URI="`+uri(l.uri)+`"`))
+		default:
+			buf.WriteString(uri(l.uri))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func isPlaylist(contentType string, head []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "mpegurl") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(head, "\ufeff \t\r\n"), []byte("#EXTM3U"))
+}
+
+// linkVideo 获取 crack_video 链接。接口可能返回转码后的字节流或 m3u8 播放列表：
+// 字节流时透传 Range；播放列表在代理时改写分段地址，使每个分段都经过 alist 代理并带上 UA 等请求头
+func (d *BaiduNetdisk) linkVideo(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	v, err := d.getVideo(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	pl := v.playlist
+	// 重定向时播放器直接访问百度，无法改写分段地址
+	if pl == nil || args.Redirect || args.HttpReq == nil {
+		link := *v.link
+		// 已由 videoCache 缓存，op 的链接缓存不区分播放列表和分段
+		link.Expiration = nil
+		link.Mode = v.mode
+		if v.mode == VIDEO_MODE_RANGE && link.Concurrency == 0 && d.ProxyRange {
+			link.RangeReadCloser = common.NoProxyRange
+		}
+		return &link, nil
+	}
+	if s := args.HttpReq.URL.Query().Get(VIDEO_SEGMENT_QUERY); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 0 || i >= len(pl.uris) {
+			return nil, fmt.Errorf("invalid segment: %s", s)
+		}
+		link := &model.Link{URL: pl.uris[i], Header: v.link.Header, Mode: VIDEO_MODE_M3U8}
+		// 分段大小未知，不能按文件大小处理 Range
+		if d.ProxyRange {
+			link.RangeReadCloser = common.NoProxyRange
+		}
+		return link, nil
+	}
+	rawPath := stdpath.Join(d.MountPath, strings.TrimPrefix(file.GetPath(), d.GetRootPath()))
+	prefix := fmt.Sprintf("%s/p%s?sign=%s&%s=", common.GetApiUrl(args.HttpReq),
+		utils.EncodePath(rawPath, true), sign.Sign(rawPath), VIDEO_SEGMENT_QUERY)
+	body := pl.render(func(i int) string {
+		return prefix + strconv.Itoa(i)
+	})
+	return &model.Link{
+		MFile:  model.NewNopMFile(bytes.NewReader(body)),
+		Header: http.Header{"Content-Type": []string{"application/vnd.apple.mpegurl"}},
+		Mode:   VIDEO_MODE_M3U8,
+	}, nil
+}
+
+// getVideo 获取 crack_video 链接并判断返回方式，结果按 fs_id 缓存到链接过期前
+func (d *BaiduNetdisk) getVideo(ctx context.Context, file model.Obj) (*crackVideo, error) {
+	if v, ok := d.videoCache.Get(file.GetID()); ok {
+		return v, nil
+	}
+	link, err := d.linkCrackVideo(ctx, file, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	link.Header = base.MergeHeaders(link.Header, d.customHeaders)
+	d.setLinkExpiration(link)
+	v, err := d.probeVideo(ctx, file, link)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("[baidu_netdisk] crack_video of [%s] is served in %s mode", file.GetPath(), v.mode)
+	if exp := link.Expiration; exp != nil {
+		d.videoCache.Set(file.GetID(), v, cache.WithEx[*crackVideo](*exp-min(time.Minute, *exp/10)))
+	}
+	return v, nil
+}
+
+// probeVideo 请求开头的一段内容，判断是播放列表还是字节流，以及字节流是否支持 Range
+func (d *BaiduNetdisk) probeVideo(ctx context.Context, file model.Obj, link *model.Link) (*crackVideo, error) {
+	res, err := d.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		SetHeaderMultiValues(link.Header).
+		SetHeader("Range", fmt.Sprintf("bytes=0-%d", VIDEO_PROBE_SIZE-1)).
+		Get(link.URL)
+	if err != nil {
+		// 探测失败时按不支持 Range 的字节流处理，不影响获取链接
+		log.Debugf("[baidu_netdisk] crack_video probe failed: %v", err)
+		return &crackVideo{link: link, mode: VIDEO_MODE_STREAM}, nil
+	}
+	body := res.RawBody()
+	defer body.Close()
+	if res.StatusCode() >= http.StatusBadRequest {
+		return nil, fmt.Errorf("crack_video link responds %s", res.Status())
+	}
+	head := make([]byte, VIDEO_PROBE_SIZE)
+	n, _ := io.ReadFull(body, head)
+	if isPlaylist(res.Header().Get("Content-Type"), head[:n]) {
+		pl, err := d.fetchPlaylist(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		return &crackVideo{link: link, mode: VIDEO_MODE_M3U8, playlist: pl}, nil
+	}
+	if res.StatusCode() != http.StatusPartialContent {
+		if !d.ProxyRange {
+			log.Warnf("[baidu_netdisk] crack_video of [%s] doesn't support range, enable proxy range to seek", file.GetPath())
+		}
+		return &crackVideo{link: link, mode: VIDEO_MODE_STREAM}, nil
+	}
+	// 转码后的大小可能与文件大小不同，此时只能透传 Range，不能按文件大小分段下载
+	if d.DownloadConcurrency > 1 && contentRangeSize(res.Header().Get("Content-Range")) == file.GetSize() {
+		link.Concurrency = d.DownloadConcurrency
+		link.PartSize = int(DOWNLOAD_PART_SIZE)
+	}
+	return &crackVideo{link: link, mode: VIDEO_MODE_RANGE}, nil
+}
+
+// fetchPlaylist 完整读取播放列表，相对地址按跳转后的地址解析
+func (d *BaiduNetdisk) fetchPlaylist(ctx context.Context, link *model.Link) (*playlist, error) {
+	res, err := d.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		SetHeaderMultiValues(link.Header).
+		Get(link.URL)
+	if err != nil {
+		return nil, err
+	}
+	body := res.RawBody()
+	defer body.Close()
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("failed get playlist: %s", res.Status())
+	}
+	data, err := io.ReadAll(io.LimitReader(body, MAX_PLAYLIST_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MAX_PLAYLIST_SIZE {
+		return nil, fmt.Errorf("playlist is larger than %d bytes", MAX_PLAYLIST_SIZE)
+	}
+	return parsePlaylist(res.RawResponse.Request.URL, data)
+}
+
+// contentRangeSize 解析 Content-Range 中的总大小，未知时返回 -1
+func contentRangeSize(contentRange string) int64 {
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,60 @@
+package baidu_netdisk
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// startWarmup 在后台按层列出根目录及其下 WarmupDepth-1 层的文件夹，填充 op 的目录缓存，
+// 请求经过 API 限速。重新初始化或删除存储时取消上一次预热
+func (d *BaiduNetdisk) startWarmup() {
+	d.stopWarmup()
+	if d.WarmupDepth <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.warmupCancel = cancel
+	go d.warmup(ctx)
+}
+
+func (d *BaiduNetdisk) stopWarmup() {
+	if d.warmupCancel != nil {
+		d.warmupCancel()
+		d.warmupCancel = nil
+	}
+}
+
+func (d *BaiduNetdisk) warmup(ctx context.Context) {
+	dirs := []string{"/"}
+	entries := 0
+	for depth := 0; depth < d.WarmupDepth && len(dirs) > 0; depth++ {
+		var next []string
+		for _, dir := range dirs {
+			if ctx.Err() != nil {
+				return
+			}
+			if d.WarmupMaxEntries > 0 && entries >= d.WarmupMaxEntries {
+				log.Debugf("[baidu_netdisk] warmup of [%s] stops after %d entries", d.MountPath, entries)
+				return
+			}
+			objs, err := op.List(ctx, d, dir, model.ListArgs{})
+			if err != nil {
+				log.Warnf("[baidu_netdisk] failed warmup [%s] of [%s]: %v", dir, d.MountPath, err)
+				continue
+			}
+			entries += len(objs)
+			for _, obj := range objs {
+				// 回收站不是普通目录，不预热
+				if obj.IsDir() && !d.isTrashDir(obj.GetPath()) {
+					next = append(next, stdpath.Join(dir, obj.GetName()))
+				}
+			}
+		}
+		dirs = next
+	}
+	log.Debugf("[baidu_netdisk] warmup of [%s] is done, %d entries listed", d.MountPath, entries)
+}
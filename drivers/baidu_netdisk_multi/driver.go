@@ -0,0 +1,93 @@
+package baidu_netdisk_multi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// BaiduNetdiskMulti 将多个内容相同的百度网盘存储组合为一个，轮流使用各账号列目录和获取链接，
+// 账号被限流或 token 失效时自动切换到其他账号，冷却时间后再恢复使用
+type BaiduNetdiskMulti struct {
+	model.Storage
+	Addition
+
+	accounts []*account
+	next     atomic.Uint32 // 下一次从哪个账号开始轮询
+}
+
+func (d *BaiduNetdiskMulti) Config() driver.Config {
+	return config
+}
+
+func (d *BaiduNetdiskMulti) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *BaiduNetdiskMulti) Init(ctx context.Context) error {
+	d.accounts = nil
+	for _, mountPath := range strings.Split(d.Accounts, "\n") {
+		mountPath = strings.TrimSpace(mountPath)
+		if mountPath == "" {
+			continue
+		}
+		mountPath = utils.FixAndCleanPath(mountPath)
+		if utils.IsSubPath(d.MountPath, mountPath) {
+			return errors.New("an account can't be the storage itself")
+		}
+		d.accounts = append(d.accounts, &account{mountPath: mountPath})
+	}
+	if len(d.accounts) == 0 {
+		return errors.New("accounts is required")
+	}
+	return nil
+}
+
+func (d *BaiduNetdiskMulti) Drop(ctx context.Context) error {
+	d.accounts = nil
+	return nil
+}
+
+func (d *BaiduNetdiskMulti) Get(ctx context.Context, path string) (model.Obj, error) {
+	var obj model.Obj
+	err := d.do(path, func(storage driver.Driver) (err error) {
+		obj, err = op.Get(ctx, storage, path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyObj(obj, path), nil
+}
+
+func (d *BaiduNetdiskMulti) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var objs []model.Obj
+	err := d.do(dir.GetPath(), func(storage driver.Driver) (err error) {
+		objs, err = op.List(ctx, storage, dir.GetPath(), model.ListArgs{Refresh: args.Refresh})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(objs, func(obj model.Obj) (model.Obj, error) {
+		return copyObj(obj, ""), nil
+	})
+}
+
+func (d *BaiduNetdiskMulti) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	var link *model.Link
+	err := d.do(file.GetPath(), func(storage driver.Driver) (err error) {
+		link, _, err = op.Link(ctx, storage, file.GetPath(), args)
+		return err
+	})
+	return link, err
+}
+
+var _ driver.Driver = (*BaiduNetdiskMulti)(nil)
+var _ driver.Getter = (*BaiduNetdiskMulti)(nil)
@@ -0,0 +1,27 @@
+package baidu_netdisk_multi
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	// 各账号需要有完全相同的文件，同一路径在所有账号中都能找到
+	Accounts string `json:"accounts" type:"text" required:"true" help:"mount paths of the BaiduNetdisk storages with the same files, one per line. The paths must resolve identically across the accounts"`
+	Cooldown int    `json:"cooldown" type:"number" default:"300" help:"seconds before an account which is throttled or whose token is dead is used again"`
+}
+
+var config = driver.Config{
+	Name:        "BaiduNetdiskMulti",
+	LocalSort:   true,
+	NoCache:     true,
+	NoUpload:    true,
+	DefaultRoot: "/",
+	Alert:       "info|Only list and download are supported, List and Link are distributed to the accounts round-robin. All the accounts must have the same files under the same paths.",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &BaiduNetdiskMulti{}
+	})
+}
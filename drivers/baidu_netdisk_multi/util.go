@@ -0,0 +1,113 @@
+package baidu_netdisk_multi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/baidu_netdisk"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type account struct {
+	mountPath string
+
+	mu             sync.Mutex
+	unhealthyUntil time.Time
+	err            error // 最近一次使账号不可用的错误
+}
+
+func (a *account) healthy() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return time.Now().After(a.unhealthyUntil)
+}
+
+func (a *account) markUnhealthy(cooldown time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.unhealthyUntil = time.Now().Add(cooldown)
+	a.err = err
+}
+
+func (a *account) lastErr() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+func (a *account) storage() (driver.Driver, error) {
+	storage, err := op.GetStorageByMountPath(a.mountPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := storage.(*baidu_netdisk.BaiduNetdisk); !ok {
+		return nil, fmt.Errorf("[%s] is not a BaiduNetdisk storage", a.mountPath)
+	}
+	if status := storage.GetStorage().Status; status != op.WORK {
+		return nil, fmt.Errorf("account [%s] is not working: %s", a.mountPath, status)
+	}
+	return storage, nil
+}
+
+// isAccountError 判断错误是否由账号本身导致（限流、token 失效），此时应换一个账号重试
+func isAccountError(err error) bool {
+	return errors.Is(err, errs.RateLimited) || errors.Is(err, errs.Unauthorized) || errors.Is(err, errs.EmptyToken)
+}
+
+// do 从下一个账号开始轮流调用 fn，跳过冷却中的账号，账号出错时标记为不可用并换下一个账号。
+// 文件不存在不会换账号重试，因为各账号的文件应当完全相同
+func (d *BaiduNetdiskMulti) do(path string, fn func(storage driver.Driver) error) error {
+	n := len(d.accounts)
+	start := int((d.next.Add(1) - 1) % uint32(n))
+	cooldown := time.Duration(d.Cooldown) * time.Second
+	var lastErr error
+	for i := 0; i < n; i++ {
+		a := d.accounts[(start+i)%n]
+		if !a.healthy() {
+			lastErr = a.lastErr()
+			continue
+		}
+		storage, err := a.storage()
+		if err == nil {
+			err = fn(storage)
+			if err == nil {
+				return nil
+			}
+			if errs.IsObjectNotFound(err) {
+				return fmt.Errorf("[%s] is not found in account [%s], all the accounts must have the same files: %w", path, a.mountPath, err)
+			}
+			if !isAccountError(err) {
+				return err
+			}
+		}
+		a.markUnhealthy(cooldown, err)
+		lastErr = err
+	}
+	return fmt.Errorf("no account is available: %w", lastErr)
+}
+
+// copyObj 复制账号中的对象，不修改账号存储中缓存的对象，path 为空时由 op.List 设置
+func copyObj(obj model.Obj, path string) model.Obj {
+	o := model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &o
+	}
+	return &model.ObjThumb{
+		Object:    o,
+		Thumbnail: model.Thumbnail{Thumbnail: thumb},
+	}
+}
@@ -248,7 +248,7 @@ func (d *BaiduPhoto) Put(ctx context.Context, dstDir model.Obj, stream model.Fil
 		err   error
 	)
 	if _, ok := cache.(io.ReaderAt); !ok {
-		tmpF, err = os.CreateTemp(conf.Conf.TempDir, "file-*")
+		tmpF, err = os.CreateTemp(conf.Conf.StreamTempDir, "file-*")
 		if err != nil {
 			return nil, err
 		}
@@ -2,7 +2,10 @@ package base
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -19,13 +22,7 @@ var UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (K
 var DefaultTimeout = time.Second * 30
 
 func InitClient() {
-	NoRedirectClient = resty.New().SetRedirectPolicy(
-		resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}),
-	).SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify})
-	NoRedirectClient.SetHeader("user-agent", UserAgent)
-
+	NoRedirectClient = NewNoRedirectClient()
 	RestyClient = NewRestyClient()
 	HttpClient = net.NewHttpClient()
 }
@@ -39,3 +36,93 @@ func NewRestyClient() *resty.Client {
 		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify})
 	return client
 }
+
+func NewNoRedirectClient() *resty.Client {
+	client := resty.New().SetRedirectPolicy(
+		resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}),
+	).SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify})
+	client.SetHeader("user-agent", UserAgent)
+	return client
+}
+
+// ClientOptions are the network settings of the clients of a storage
+type ClientOptions struct {
+	Proxy              string // http, https or socks5 proxy url, empty to use the proxy of the environment
+	CACert             string // PEM encoded CA certificates trusted besides the system ones
+	InsecureSkipVerify bool
+	// MaxIdleConnsPerHost and IdleConnTimeout tune the keep-alive connections, 0 keeps the default of resty
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableHTTP2        bool // only use HTTP/1.1, HTTP/2 is negotiated by default
+}
+
+func (o ClientOptions) proxyURL() (*url.URL, error) {
+	if o.Proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(o.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy: host is empty")
+	}
+	return u, nil
+}
+
+func (o ClientOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify || conf.Conf.TlsInsecureSkipVerify}
+	if o.CACert == "" {
+		return config, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(o.CACert)) {
+		return nil, fmt.Errorf("invalid ca cert: no PEM certificate found")
+	}
+	config.RootCAs = pool
+	return config, nil
+}
+
+// Apply sets the proxy, the tls config and the connection pool of client. Each resty client has its own
+// transport, so the settings only affect the storage which owns the client
+func (o ClientOptions) Apply(client *resty.Client) error {
+	config, err := o.tlsConfig()
+	if err != nil {
+		return err
+	}
+	u, err := o.proxyURL()
+	if err != nil {
+		return err
+	}
+	client.SetTLSClientConfig(config)
+	transport, err := client.Transport()
+	if err != nil {
+		return err
+	}
+	if u != nil {
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		transport.MaxIdleConns = max(transport.MaxIdleConns, o.MaxIdleConnsPerHost)
+	}
+	if o.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if o.DisableHTTP2 {
+		// a non-nil empty TLSNextProto disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package base
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// DebugBodyLimit is the max length of a response body in the debug log
+const DebugBodyLimit = 2048
+
+// secretKeys are the (lower case) names of query params and json fields masked in the debug log,
+// a name containing one of them is also masked, e.g. x-amz-signature
+var secretKeys = []string{"token", "secret", "sign", "password", "passwd", "key", "auth", "cookie", "session", "credential"}
+
+var secretJsonRe = regexp.MustCompile(`"([^"]*)"\s*:\s*"[^"]*"`)
+
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range secretKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactURL masks the values of the secret query params of u
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	q := u.Query()
+	if len(q) == 0 {
+		return u.String()
+	}
+	for k := range q {
+		if isSecretKey(k) {
+			q.Set(k, "***")
+		}
+	}
+	c := *u
+	c.User = nil
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// RedactBody masks the secret string fields of a json body and truncates it to DebugBodyLimit
+func RedactBody(body []byte) string {
+	s := secretJsonRe.ReplaceAllStringFunc(string(body), func(m string) string {
+		k := secretJsonRe.FindStringSubmatch(m)[1]
+		if !isSecretKey(k) {
+			return m
+		}
+		return `"` + k + `":"***"`
+	})
+	if len(s) > DebugBodyLimit {
+		return s[:DebugBodyLimit] + "...(truncated)"
+	}
+	return s
+}
+
+// EnableDebugLog logs the requests of the client with the redacted url, the status code
+// and the truncated response body at debug level, name is used to tell the storage
+func EnableDebugLog(client *resty.Client, name string) *resty.Client {
+	return client.
+		OnAfterResponse(func(c *resty.Client, r *resty.Response) error {
+			// the body is empty if the response is not parsed, e.g. a download stream
+			log.Debugf("[%s] %s %s -> %d (%s): %s", name, r.Request.Method,
+				RedactURL(r.Request.RawRequest.URL), r.StatusCode(), r.Time(), RedactBody(r.Body()))
+			return nil
+		}).
+		OnError(func(r *resty.Request, err error) {
+			u := "<invalid url>"
+			if r.RawRequest != nil {
+				u = RedactURL(r.RawRequest.URL)
+			} else if pu, e := url.Parse(r.URL); e == nil {
+				u = RedactURL(pu)
+			}
+			log.Debugf("[%s] %s %s -> error: %v", name, r.Method, u, err)
+		})
+}
@@ -0,0 +1,170 @@
+package base
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// MinAdaptiveRate is the lowest requests/sec an AdaptiveLimiter halves to
+	MinAdaptiveRate = 0.1
+	// adaptiveHitInterval merges the hits of the requests sent before the last decrease, e.g. the concurrent ones,
+	// so that a burst of hits halves the rate once
+	adaptiveHitInterval = time.Second
+	// adaptiveRecoverSteps is how many steps of max/steps the rate recovers by after the cooldown,
+	// one step every cooldown/steps
+	adaptiveRecoverSteps = 10
+)
+
+// RateLimitEvent is reported by AdaptiveLimiter when its rate changes
+type RateLimitEvent struct {
+	// Limited is true for a hit of the rate limit of the server, false for a recovery step
+	Limited bool
+	// Prev and Rate are the requests/sec before and after the change, 0 for unlimited
+	Prev, Rate float64
+	// Until is when the rate starts to recover, it's only set for a hit
+	Until time.Time
+}
+
+// RateLimitHook is called with every change of the rate, e.g. to log it or to export it as a metric
+type RateLimitHook func(ev RateLimitEvent)
+
+type AdaptiveLimiterOptions struct {
+	// Max is the static limit of requests/sec the rate recovers up to, 0 for unlimited, which is then
+	// the rate observed when the first hit happens
+	Max float64
+	// Cooldown is how long the rate is kept after a hit before it recovers
+	Cooldown time.Duration
+	// Hooks are called in order with every change of the rate, no lock of the limiter is held
+	Hooks []RateLimitHook
+}
+
+// AdaptiveLimiter limits the requests in the AIMD way: the rate is halved on every hit of the rate limit
+// of the server, kept for a cooldown, then increased linearly back to the static limit
+type AdaptiveLimiter struct {
+	opts    AdaptiveLimiterOptions
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	rate     float64   // 0 for unlimited
+	ceiling  float64   // rate recovered up to, 0 for unlimited
+	until    time.Time // end of the cooldown
+	lastHit  time.Time
+	lastStep time.Time
+	// requests counted in the current and the previous second, to know the rate when unlimited
+	window    time.Time
+	count     int
+	prevCount int
+}
+
+func NewAdaptiveLimiter(opts AdaptiveLimiterOptions) *AdaptiveLimiter {
+	if opts.Max < 0 {
+		opts.Max = 0
+	}
+	l := &AdaptiveLimiter{opts: opts, rate: opts.Max, ceiling: opts.Max}
+	l.limiter = rate.NewLimiter(toLimit(opts.Max), 1)
+	return l
+}
+
+func toLimit(r float64) rate.Limit {
+	if r <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(r)
+}
+
+// Rate is the current requests/sec, 0 for unlimited
+func (l *AdaptiveLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Wait blocks until a request is allowed by the current rate or ctx is done
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	ev, changed := l.step(time.Now())
+	if changed {
+		l.report(ev)
+	}
+	return l.limiter.Wait(ctx)
+}
+
+// step counts a request and recovers the rate after the cooldown
+func (l *AdaptiveLimiter) step(now time.Time) (RateLimitEvent, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.window) >= time.Second {
+		if now.Sub(l.window) < 2*time.Second {
+			l.prevCount = l.count
+		} else {
+			l.prevCount = 0
+		}
+		l.window, l.count = now, 0
+	}
+	l.count++
+	if l.rate <= 0 || l.rate == l.ceiling || now.Before(l.until) {
+		return RateLimitEvent{}, false
+	}
+	interval := l.opts.Cooldown / adaptiveRecoverSteps
+	steps := 1
+	if interval > 0 {
+		steps = int(now.Sub(maxTime(l.lastStep, l.until)) / interval)
+	}
+	if steps <= 0 {
+		return RateLimitEvent{}, false
+	}
+	ev := RateLimitEvent{Prev: l.rate}
+	l.rate = math.Min(l.rate+float64(steps)*l.ceiling/adaptiveRecoverSteps, l.ceiling)
+	if l.rate == l.ceiling && l.opts.Max <= 0 {
+		// recovered to the rate observed when unlimited, stop limiting
+		l.rate, l.ceiling = 0, 0
+	}
+	l.lastStep = now
+	l.limiter.SetLimit(toLimit(l.rate))
+	ev.Rate = l.rate
+	return ev, true
+}
+
+// Limited tells the limiter the server rejected a request for the rate limit, the rate is halved
+// unless it's already halved for a request sent before
+func (l *AdaptiveLimiter) Limited() {
+	now := time.Now()
+	l.mu.Lock()
+	if now.Sub(l.lastHit) < adaptiveHitInterval {
+		l.until = now.Add(l.opts.Cooldown)
+		l.mu.Unlock()
+		return
+	}
+	ev := RateLimitEvent{Limited: true, Prev: l.rate}
+	current := l.rate
+	if current <= 0 {
+		// the rate observed in the last second, the requests of the current one are counted as well
+		current = math.Max(float64(max(l.prevCount, l.count)), 2*MinAdaptiveRate)
+		l.ceiling = current
+	}
+	l.rate = math.Max(current/2, MinAdaptiveRate)
+	l.lastHit = now
+	l.until = now.Add(l.opts.Cooldown)
+	l.lastStep = l.until
+	l.limiter.SetLimit(toLimit(l.rate))
+	ev.Rate, ev.Until = l.rate, l.until
+	l.mu.Unlock()
+	l.report(ev)
+}
+
+func (l *AdaptiveLimiter) report(ev RateLimitEvent) {
+	for _, hook := range l.opts.Hooks {
+		hook(ev)
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
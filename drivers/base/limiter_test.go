@@ -0,0 +1,49 @@
+package base
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      float64
+		requests int // sent in the second before the hits
+		hits     int // in a row, merged into one
+		after    time.Duration
+		want     float64 // after the cooldown plus after, 0 for unlimited
+	}{
+		{"halved", 10, 0, 1, -time.Second, 5},
+		{"hits merged", 10, 0, 3, -time.Second, 5},
+		{"one step", 10, 0, 1, time.Second, 6},
+		{"recovered to max", 10, 0, 1, time.Minute, 10},
+		{"min rate", 0.1, 0, 1, -time.Second, MinAdaptiveRate},
+		{"unlimited halved from the observed rate", 0, 8, 1, -time.Second, 4},
+		{"unlimited recovered", 0, 8, 1, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []RateLimitEvent
+			l := NewAdaptiveLimiter(AdaptiveLimiterOptions{
+				Max:      tt.max,
+				Cooldown: 10 * time.Second,
+				Hooks:    []RateLimitHook{func(ev RateLimitEvent) { events = append(events, ev) }},
+			})
+			now := time.Now()
+			for range tt.requests {
+				l.step(now)
+			}
+			for range tt.hits {
+				l.Limited()
+			}
+			if len(events) != 1 || !events[0].Limited {
+				t.Fatalf("expect one hit event, got %+v", events)
+			}
+			l.step(l.until.Add(tt.after))
+			if got := l.Rate(); got != tt.want {
+				t.Errorf("rate %v, expect %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package base
+
+import (
+	"fmt"
+	stdpath "path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+)
+
+// the policies of a name which breaks the NameRule of the backend
+const (
+	NamePolicyReject  = "reject"
+	NamePolicyReplace = "replace"
+)
+
+// NameRule is the restriction of the object names of a backend
+type NameRule struct {
+	MaxLength int    // max characters of a name, 0 for unlimited
+	Illegal   string // the characters not allowed in a name
+}
+
+func (r NameRule) illegal(c rune) bool {
+	return strings.ContainsRune(r.Illegal, c) || c < 0x20
+}
+
+// Check validates name against the rule. With NamePolicyReplace the illegal characters are replaced
+// with substitute and a too long name is truncated keeping its extension, otherwise an error
+// wrapping errs.InvalidName is returned
+func (r NameRule) Check(name, policy, substitute string) (string, error) {
+	bad := strings.IndexFunc(name, r.illegal) >= 0
+	long := r.MaxLength > 0 && utf8.RuneCountInString(name) > r.MaxLength
+	if !bad && !long {
+		return name, nil
+	}
+	if policy != NamePolicyReplace {
+		if bad {
+			return "", fmt.Errorf("%w %q: the characters %s and control characters are not allowed",
+				errs.InvalidName, name, r.describe())
+		}
+		return "", fmt.Errorf("%w %q: longer than %d characters", errs.InvalidName, name, r.MaxLength)
+	}
+	if bad {
+		var b strings.Builder
+		for _, c := range name {
+			if r.illegal(c) {
+				b.WriteString(substitute)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+		name = b.String()
+	}
+	if r.MaxLength > 0 && utf8.RuneCountInString(name) > r.MaxLength {
+		ext := stdpath.Ext(name)
+		if utf8.RuneCountInString(ext) >= r.MaxLength {
+			ext = ""
+		}
+		base := []rune(strings.TrimSuffix(name, ext))
+		name = string(base[:r.MaxLength-utf8.RuneCountInString(ext)]) + ext
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: empty after replacing the illegal characters", errs.InvalidName)
+	}
+	return name, nil
+}
+
+// CheckSubstitute validates the substitute of the illegal characters
+func (r NameRule) CheckSubstitute(substitute string) error {
+	if strings.IndexFunc(substitute, r.illegal) >= 0 {
+		return fmt.Errorf("the substitute %q contains the illegal characters %s", substitute, r.describe())
+	}
+	return nil
+}
+
+// describe lists the illegal characters separated by space, e.g. \ / : *
+func (r NameRule) describe() string {
+	return strings.Join(strings.Split(r.Illegal, ""), " ")
+}
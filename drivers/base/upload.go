@@ -1,12 +1,18 @@
 package base
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 // storage upload progress, for upload recovery
@@ -29,3 +35,96 @@ func GetUploadProgress[T any](driver driver.Driver, keys ...string) (state T, ok
 	}
 	return
 }
+
+// persistedUploadState is the upload progress saved in the data dir, which survives a restart
+type persistedUploadState struct {
+	Expire time.Time       `json:"expire"`
+	State  json.RawMessage `json:"state"`
+}
+
+var (
+	uploadStateDirMu sync.Mutex
+	uploadStateClean time.Time
+)
+
+func uploadStatePath(driver driver.Driver, keys ...string) string {
+	name := utils.GetMD5EncodeStr(fmt.Sprint(driver.Config().Name, "-upload-", strings.Join(keys, "-")))
+	return filepath.Join(flags.DataDir, "upload_state", name+".json")
+}
+
+// cleanExpiredUploadStates remove the expired states at most once an hour, the caller must hold uploadStateDirMu
+func cleanExpiredUploadStates(dir string) {
+	if time.Since(uploadStateClean) < time.Hour {
+		return
+	}
+	uploadStateClean = time.Now()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		var s persistedUploadState
+		data, err := os.ReadFile(p)
+		if err == nil && utils.Json.Unmarshal(data, &s) == nil && time.Now().Before(s.Expire) {
+			continue
+		}
+		_ = os.Remove(p)
+	}
+}
+
+// SavePersistentUploadProgress same as SaveUploadProgress, but the state is saved to the data dir for ttl,
+// so that the upload can be resumed after a restart. A nil state removes the saved one
+func SavePersistentUploadProgress(driver driver.Driver, state any, ttl time.Duration, keys ...string) error {
+	p := uploadStatePath(driver, keys...)
+	uploadStateDirMu.Lock()
+	defer uploadStateDirMu.Unlock()
+	cleanExpiredUploadStates(filepath.Dir(p))
+	if state == nil {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	raw, err := utils.Json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	data, err := utils.Json.Marshal(persistedUploadState{
+		Expire: time.Now().Add(ttl),
+		State:  raw,
+	})
+	if err != nil {
+		return err
+	}
+	if err = utils.CreateNestedDirectory(filepath.Dir(p)); err != nil {
+		return err
+	}
+	// write to a temp file first, so a crash won't leave a broken state
+	tmp := p + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// GetPersistentUploadProgress get the state saved by SavePersistentUploadProgress if it's not expired
+func GetPersistentUploadProgress[T any](driver driver.Driver, keys ...string) (state T, ok bool) {
+	p := uploadStatePath(driver, keys...)
+	uploadStateDirMu.Lock()
+	defer uploadStateDirMu.Unlock()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return
+	}
+	var s persistedUploadState
+	if err = utils.Json.Unmarshal(data, &s); err != nil || time.Now().After(s.Expire) {
+		_ = os.Remove(p)
+		return
+	}
+	if err = utils.Json.Unmarshal(s.State, &state); err != nil {
+		_ = os.Remove(p)
+		return
+	}
+	return state, true
+}
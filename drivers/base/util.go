@@ -1 +1,93 @@
 package base
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ParseHeaders parse the header overrides of a storage, one "Key: Value" per line,
+// empty lines and lines starting with # are ignored
+func ParseHeaders(s string) (http.Header, error) {
+	h := http.Header{}
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" || strings.ContainsAny(k, " \t\"(),/;<=>?@[\\]{}") {
+			return nil, fmt.Errorf("invalid header at line %d: %s", i+1, line)
+		}
+		h.Add(k, strings.TrimSpace(v))
+	}
+	return h, nil
+}
+
+// GetURLExpiration returns how long a signed url is valid, parsed from its expiry query param, 0 if unknown.
+// The param is either a unix timestamp, or a duration like "8h" or seconds relative to the signing time
+func GetURLExpiration(u string) time.Duration {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return 0
+	}
+	q := parsed.Query()
+	if date, err := time.Parse("20060102T150405Z", q.Get("X-Amz-Date")); err == nil {
+		if sec, err := strconv.ParseInt(q.Get("X-Amz-Expires"), 10, 64); err == nil {
+			return time.Until(date.Add(time.Duration(sec) * time.Second))
+		}
+	}
+	var v string
+	for _, k := range []string{"expires", "Expires", "x-expires", "e"} {
+		if v = q.Get(k); v != "" {
+			break
+		}
+	}
+	if v == "" {
+		return 0
+	}
+	signedAt := time.Now()
+	for _, k := range []string{"dstime", "time", "t"} {
+		if ts, err := strconv.ParseInt(q.Get(k), 10, 64); err == nil && ts > 1e9 {
+			signedAt = time.Unix(ts, 0)
+			break
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Until(signedAt.Add(d))
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if n > 1e9 {
+		return time.Until(time.Unix(n, 0))
+	}
+	return time.Until(signedAt.Add(time.Duration(n) * time.Second))
+}
+
+// SetHeaders override the headers of req with h
+func SetHeaders(req *resty.Request, h http.Header) *resty.Request {
+	for k, v := range h {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	return req
+}
+
+// MergeHeaders returns a copy of dst overridden by h, e.g. for the Header of model.Link
+func MergeHeaders(dst, h http.Header) http.Header {
+	res := dst.Clone()
+	if res == nil {
+		res = http.Header{}
+	}
+	for k, v := range h {
+		res[http.CanonicalHeaderKey(k)] = v
+	}
+	return res
+}
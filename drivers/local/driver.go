@@ -318,11 +318,7 @@ func (d *Local) Copy(_ context.Context, srcObj, dstDir model.Obj) error {
 func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	var err error
 	if utils.SliceContains([]string{"", "delete permanently"}, d.RecycleBinPath) {
-		if obj.IsDir() {
-			err = os.RemoveAll(obj.GetPath())
-		} else {
-			err = os.Remove(obj.GetPath())
-		}
+		err = d.RemovePermanently(ctx, obj)
 	} else {
 		dstPath := filepath.Join(d.RecycleBinPath, obj.GetName())
 		if utils.Exists(dstPath) {
@@ -336,6 +332,14 @@ func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	return nil
 }
 
+// RemovePermanently removes obj without moving it to the recycle bin
+func (d *Local) RemovePermanently(_ context.Context, obj model.Obj) error {
+	if obj.IsDir() {
+		return os.RemoveAll(obj.GetPath())
+	}
+	return os.Remove(obj.GetPath())
+}
+
 func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	fullPath := filepath.Join(dstDir.GetPath(), stream.GetName())
 	out, err := os.Create(fullPath)
@@ -360,3 +364,5 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 }
 
 var _ driver.Driver = (*Local)(nil)
+var _ driver.NameRestricted = (*Local)(nil)
+var _ driver.RemovePermanently = (*Local)(nil)
@@ -156,3 +156,11 @@ func (d *Local) getThumb(file model.Obj) (*bytes.Buffer, *string, error) {
 	}
 	return &buf, nil, nil
 }
+
+// IllegalNameChars of the file system, Windows doesn't allow more characters than /
+func (d *Local) IllegalNameChars() string {
+	if runtime.GOOS == "windows" {
+		return `\/:*?"<>|`
+	}
+	return "/"
+}
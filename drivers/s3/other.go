@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/aws/aws-sdk-go/aws"
@@ -59,6 +60,14 @@ type RestoreStatus struct {
 	Raw     string `json:"raw"`
 }
 
+// OtherKind archive and thaw change the storage class of the object
+func (d *S3) OtherKind(method string) int {
+	if method == OtherMethodArchive || method == OtherMethodThaw {
+		return driver.OtherWrite
+	}
+	return driver.OtherRead
+}
+
 func (d *S3) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
 	if args.Obj == nil {
 		return nil, fmt.Errorf("missing object reference")
@@ -82,6 +82,22 @@ func InitConfig() {
 	if err != nil {
 		log.Fatalf("create temp dir error: %+v", err)
 	}
+	// the files buffered for streams, it's temp_dir/stream by default
+	if conf.Conf.StreamTempDir == "" {
+		conf.Conf.StreamTempDir = filepath.Join(conf.Conf.TempDir, "stream")
+	} else if !filepath.IsAbs(conf.Conf.StreamTempDir) {
+		absPath, err := filepath.Abs(conf.Conf.StreamTempDir)
+		if err != nil {
+			log.Fatalf("get abs path error: %+v", err)
+		}
+		conf.Conf.StreamTempDir = absPath
+	}
+	err = os.MkdirAll(conf.Conf.StreamTempDir, 0o777)
+	if err != nil {
+		log.Fatalf("create stream temp dir error: %+v", err)
+	}
+	// the stream temp files are only used during an upload, so they are orphaned if left from the last run
+	cleanDir(conf.Conf.StreamTempDir)
 	log.Debugf("config: %+v", conf.Conf)
 	base.InitClient()
 	initURL()
@@ -112,12 +128,22 @@ func initURL() {
 }
 
 func CleanTempDir() {
-	files, err := os.ReadDir(conf.Conf.TempDir)
+	cleanDir(conf.Conf.TempDir)
+}
+
+func cleanDir(dir string) {
+	files, err := os.ReadDir(dir)
 	if err != nil {
 		log.Errorln("failed list temp file: ", err)
 	}
 	for _, file := range files {
-		if err := os.RemoveAll(filepath.Join(conf.Conf.TempDir, file.Name())); err != nil {
+		p := filepath.Join(dir, file.Name())
+		// the stream temp dir may be in the temp dir, keep it for the uploads
+		if p == conf.Conf.StreamTempDir {
+			cleanDir(p)
+			continue
+		}
+		if err := os.RemoveAll(p); err != nil {
 			log.Errorln("failed delete temp file: ", err)
 		}
 	}
@@ -123,6 +123,8 @@ func InitialSettings() []model.SettingItem {
 		//{Key: conf.OfficeTypes, Value: "doc,docx,xls,xlsx,ppt,pptx", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
 		{Key: conf.ProxyTypes, Value: "m3u8,url", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
 		{Key: conf.ProxyIgnoreHeaders, Value: "authorization,referer", Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
+		{Key: conf.MimeTypes, Value: `{}`, Type: conf.TypeText, Group: model.PREVIEW, Flag: model.PRIVATE},
+		{Key: conf.ProxyGzip, Value: "false", Type: conf.TypeBool, Group: model.PREVIEW, Flag: model.PRIVATE, Help: `gzip the proxied text files if the client accepts`},
 		{Key: "external_previews", Value: `{}`, Type: conf.TypeText, Group: model.PREVIEW},
 		{Key: "iframe_previews", Value: `{
 	"doc,docx,xls,xlsx,ppt,pptx": {
@@ -168,6 +170,23 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.MaxDevices, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL},
 		{Key: conf.DeviceEvictPolicy, Value: "deny", Type: conf.TypeSelect, Options: "deny,evict_oldest", Group: model.GLOBAL},
 		{Key: conf.DeviceSessionTTL, Value: "86400", Type: conf.TypeNumber, Group: model.GLOBAL},
+		{Key: conf.WebdavPropfindMaxDepth, Value: "16", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.WebdavPropfindMaxNodes, Value: "10000", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.PutConflictPolicy, Value: "wait", Type: conf.TypeSelect, Options: "wait,fail", Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.CopyNameEncoding, Value: "none", Type: conf.TypeSelect, Options: "none,percent", Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `percent-encode the characters the dst storage doesn't allow in the names copied between two storages, and % itself so that the names can be decoded`},
+		{Key: conf.FoldersFirst, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Help: `list the folders before the files, unless the storage sets extract folder`},
+		{Key: conf.PeekMaxSize, Value: "1024", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max KB of a file read by /fs/peek, 0 to disable it`},
+		{Key: conf.DirSizeCacheTTL, Value: "60", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `minutes the sizes computed by /fs/dir_size are kept, a write under the dir drops them earlier`},
+		{Key: conf.DirSizeForUsers, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `allow the users other than the admin to compute the dir sizes by /fs/dir_size, which walks the whole dirs`},
+		{Key: conf.MoveBetweenStorages, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `move files between two storages by copying and verifying all of them, then removing the source, which is kept if any copy fails`},
+		{Key: conf.CleanFailedMove, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `remove the files copied by a failed move between two storages, the source is always kept`},
+		{Key: conf.TusExpiration, Value: "24", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `hours before an unfinished tus upload is removed, 0 to keep them`},
+		{Key: conf.Webhooks, Value: "[]", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `json array of {"url", "secret", "path_prefix", "events"} posted on create, remove and move, events and path_prefix are optional filters`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -175,6 +194,8 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.IndexUpdateDebounce, Value: "5", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `seconds to wait for more changes before updating the index`},
+		{Key: conf.AutoCrawlInterval, Value: "0", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `hours between two full builds of the index, 0 to disable`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
@@ -219,6 +240,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.FTPTLSPrivateKeyPath, Value: "", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE},
 		{Key: conf.FTPTLSPublicCertPath, Value: "", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE},
 
+		// offline download settings
+		{Key: conf.OfflineDownloadTempRetention, Value: "24", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE,
+			Help: `hours the downloaded temp files of a failed upload are kept for retrying the upload, 0 to remove them by the delete policy at once`},
+
 		// traffic settings
 		{Key: conf.TaskOfflineDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Download.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskOfflineDownloadTransferThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Transfer.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
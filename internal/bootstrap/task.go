@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/xhofe/tache"
 )
 
@@ -26,17 +27,21 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.CopyTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)))
 	})
-	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
-	op.RegisterSettingChangingCallback(func() {
-		tool.DownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
+	// the threads of the offline downloads and transfers are the limits of their queues, so that the queued tasks can be bumped
+	tool.DownloadQueue = task.NewQueue(func() int {
+		return int(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
 	})
-	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
-	op.RegisterSettingChangingCallback(func() {
-		tool.TransferTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
+	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(task.QueueWorkers), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
+	op.RegisterSettingChangingCallback(tool.DownloadQueue.Notify)
+	tool.TransferQueue = task.NewQueue(func() int {
+		return int(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
 	})
+	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(task.QueueWorkers), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	op.RegisterSettingChangingCallback(tool.TransferQueue.Notify)
 	if len(tool.TransferTaskManager.GetAll()) == 0 { //prevent offline downloaded files from being deleted
 		CleanTempDir()
 	}
+	tool.ScheduleTempExpiration()
 	workers := conf.Conf.Tasks.S3Transition.Workers
 	if workers < 0 {
 		workers = 0
@@ -49,6 +54,8 @@ func InitTaskManager() {
 		),
 		tache.WithMaxRetry(conf.Conf.Tasks.S3Transition.MaxRetry),
 	)
+	fs.ClipTaskManager = tache.NewManager[*fs.ClipTask](tache.WithWorks(max(conf.Conf.Tasks.Clip.Workers, 0)), tache.WithPersistFunction(db.GetTaskDataFunc("clip", conf.Conf.Tasks.Clip.TaskPersistant), db.UpdateTaskDataFunc("clip", conf.Conf.Tasks.Clip.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Clip.MaxRetry))
+	fs.BatchTaskManager = tache.NewManager[*fs.BatchTask](tache.WithWorks(max(conf.Conf.Tasks.Batch.Workers, 0)), tache.WithPersistFunction(db.GetTaskDataFunc("batch", conf.Conf.Tasks.Batch.TaskPersistant), db.UpdateTaskDataFunc("batch", conf.Conf.Tasks.Batch.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Batch.MaxRetry))
 	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
@@ -61,6 +61,8 @@ type TasksConfig struct {
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
 	S3Transition       TaskConfig `json:"s3_transition" envPrefix:"S3_TRANSITION_"`
+	Clip               TaskConfig `json:"clip" envPrefix:"CLIP_"`
+	Batch              TaskConfig `json:"batch" envPrefix:"BATCH_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -89,34 +91,73 @@ type FTP struct {
 	EnablePasvConnIPCheck   bool   `json:"enable_pasv_conn_ip_check" env:"ENABLE_PASV_CONN_IP_CHECK"`
 }
 
+// ListCache bounds the in-memory list cache, 0 for unlimited
+type ListCache struct {
+	MaxEntries int `json:"max_entries" env:"MAX_ENTRIES"`
+	MaxObjects int `json:"max_objects" env:"MAX_OBJECTS"`
+	// StaleSeconds keeps an expired listing for a while, it's returned if the storage fails transiently, 0 to disable
+	StaleSeconds int `json:"stale_seconds" env:"STALE_SECONDS"`
+	// ObjSeconds serves Get from the objs of a dir listed within the seconds, 0 to disable
+	ObjSeconds int `json:"obj_seconds" env:"OBJ_SECONDS"`
+}
+
+type Metrics struct {
+	Enable bool `json:"enable" env:"ENABLE"`
+	// Token is required as "Authorization: Bearer <token>" to scrape the metrics if not empty
+	Token string `json:"token" env:"TOKEN"`
+}
+
+// DownloadReassemble is where the chunks of a parallel download (link.Concurrency > 1) are reassembled
+type DownloadReassemble struct {
+	// Mode is memory, file, or auto to use a temp file in the stream temp dir for the big files
+	Mode string `json:"mode" env:"MODE"`
+	// FileThreshold is the MB of a download from which the auto mode uses a temp file, 0 to always use the memory
+	FileThreshold int64 `json:"file_threshold" env:"FILE_THRESHOLD"`
+}
+
+// RequestTimeout is the seconds of a driver call, after which it fails, 0 means no limit. The timeout of a storage
+// takes precedence over Default, List and Link
+type RequestTimeout struct {
+	Default int `json:"default" env:"DEFAULT"`
+	List    int `json:"list" env:"LIST"` // 0 means Default
+	Link    int `json:"link" env:"LINK"` // 0 means Default
+	// Put is only for the uploads, which aren't limited by Default since they take as long as the files need
+	Put int `json:"put" env:"PUT"`
+}
+
 type SFTP struct {
 	Enable bool   `json:"enable" env:"ENABLE"`
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
 type Config struct {
-	Force                 bool        `json:"force" env:"FORCE"`
-	SiteURL               string      `json:"site_url" env:"SITE_URL"`
-	Cdn                   string      `json:"cdn" env:"CDN"`
-	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	Database              Database    `json:"database" envPrefix:"DB_"`
-	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
-	Scheme                Scheme      `json:"scheme"`
-	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
-	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
-	DistDir               string      `json:"dist_dir"`
-	Log                   LogConfig   `json:"log"`
-	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
-	MaxConnections        int         `json:"max_connections" env:"MAX_CONNECTIONS"`
-	MaxConcurrency        int         `json:"max_concurrency" env:"MAX_CONCURRENCY"`
-	TlsInsecureSkipVerify bool        `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
-	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
-	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
-	S3                    S3          `json:"s3" envPrefix:"S3_"`
-	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
-	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
-	LastLaunchedVersion   string      `json:"last_launched_version"`
+	Force                 bool               `json:"force" env:"FORCE"`
+	SiteURL               string             `json:"site_url" env:"SITE_URL"`
+	Cdn                   string             `json:"cdn" env:"CDN"`
+	JwtSecret             string             `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn        int                `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	Database              Database           `json:"database" envPrefix:"DB_"`
+	Meilisearch           Meilisearch        `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Scheme                Scheme             `json:"scheme"`
+	TempDir               string             `json:"temp_dir" env:"TEMP_DIR"`
+	StreamTempDir         string             `json:"stream_temp_dir" env:"STREAM_TEMP_DIR"`
+	BleveDir              string             `json:"bleve_dir" env:"BLEVE_DIR"`
+	DistDir               string             `json:"dist_dir"`
+	Log                   LogConfig          `json:"log"`
+	DelayedStart          int                `json:"delayed_start" env:"DELAYED_START"`
+	MaxConnections        int                `json:"max_connections" env:"MAX_CONNECTIONS"`
+	MaxConcurrency        int                `json:"max_concurrency" env:"MAX_CONCURRENCY"`
+	ListCache             ListCache          `json:"list_cache" envPrefix:"LIST_CACHE_"`
+	TlsInsecureSkipVerify bool               `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	Tasks                 TasksConfig        `json:"tasks" envPrefix:"TASKS_"`
+	Cors                  Cors               `json:"cors" envPrefix:"CORS_"`
+	S3                    S3                 `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP                `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP               `json:"sftp" envPrefix:"SFTP_"`
+	Metrics               Metrics            `json:"metrics" envPrefix:"METRICS_"`
+	DownloadReassemble    DownloadReassemble `json:"download_reassemble" envPrefix:"DOWNLOAD_REASSEMBLE_"`
+	RequestTimeout        RequestTimeout     `json:"request_timeout" envPrefix:"REQUEST_TIMEOUT_"`
+	LastLaunchedVersion   string             `json:"last_launched_version"`
 }
 
 func DefaultConfig() *Config {
@@ -157,6 +198,10 @@ func DefaultConfig() *Config {
 		MaxConnections:        0,
 		MaxConcurrency:        64,
 		TlsInsecureSkipVerify: true,
+		ListCache: ListCache{
+			StaleSeconds: 300,
+			ObjSeconds:   10,
+		},
 		Tasks: TasksConfig{
 			Download: TaskConfig{
 				Workers:  5,
@@ -190,6 +235,16 @@ func DefaultConfig() *Config {
 				MaxRetry: 2,
 				// TaskPersistant: true,
 			},
+			Clip: TaskConfig{
+				Workers:  5,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
+			Batch: TaskConfig{
+				Workers:  5,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -218,6 +273,18 @@ func DefaultConfig() *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Metrics: Metrics{
+			Enable: false,
+		},
+		DownloadReassemble: DownloadReassemble{
+			Mode:          "auto",
+			FileThreshold: 1024,
+		},
+		RequestTimeout: RequestTimeout{
+			Default: 300,
+			// a day, long enough for the large files, while a hung upload still fails
+			Put: 86400,
+		},
 		LastLaunchedVersion: "",
 	}
 }
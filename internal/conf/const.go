@@ -31,6 +31,8 @@ const (
 	ImageTypes               = "image_types"
 	ProxyTypes               = "proxy_types"
 	ProxyIgnoreHeaders       = "proxy_ignore_headers"
+	MimeTypes                = "mime_types"
+	ProxyGzip                = "proxy_gzip"
 	AudioAutoplay            = "audio_autoplay"
 	VideoAutoplay            = "video_autoplay"
 	PreviewArchivesByDefault = "preview_archives_by_default"
@@ -51,12 +53,26 @@ const (
 	MaxDevices              = "max_devices"
 	DeviceEvictPolicy       = "device_evict_policy"
 	DeviceSessionTTL        = "device_session_ttl"
+	WebdavPropfindMaxDepth  = "webdav_propfind_max_depth"
+	WebdavPropfindMaxNodes  = "webdav_propfind_max_nodes"
+	PutConflictPolicy       = "put_conflict_policy"
+	Webhooks                = "webhooks"
+	TusExpiration           = "tus_expiration"
+	CopyNameEncoding        = "copy_name_encoding"
+	FoldersFirst            = "folders_first"
+	PeekMaxSize             = "peek_max_size"
+	DirSizeCacheTTL         = "dir_size_cache_ttl"
+	DirSizeForUsers         = "dir_size_for_users"
+	MoveBetweenStorages     = "move_between_storages"
+	CleanFailedMove         = "clean_failed_move"
 
 	// index
-	SearchIndex     = "search_index"
-	AutoUpdateIndex = "auto_update_index"
-	IgnorePaths     = "ignore_paths"
-	MaxIndexDepth   = "max_index_depth"
+	SearchIndex         = "search_index"
+	AutoUpdateIndex     = "auto_update_index"
+	IgnorePaths         = "ignore_paths"
+	MaxIndexDepth       = "max_index_depth"
+	IndexUpdateDebounce = "index_update_debounce"
+	AutoCrawlInterval   = "auto_crawl_interval"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -75,6 +91,9 @@ const (
 	// thunder
 	ThunderTempDir = "thunder_temp_dir"
 
+	// baidu
+	BaiduTempDir = "baidu_temp_dir"
+
 	// single
 	Token         = "token"
 	IndexProgress = "index_progress"
@@ -111,6 +130,9 @@ const (
 	S3AccessKeyId     = "s3_access_key_id"
 	S3SecretAccessKey = "s3_secret_access_key"
 
+	// offline download
+	OfflineDownloadTempRetention = "offline_download_temp_retention"
+
 	// qbittorrent
 	QbittorrentUrl      = "qbittorrent_url"
 	QbittorrentSeedtime = "qbittorrent_seedtime"
@@ -149,5 +171,14 @@ const (
 
 // ContextKey is the type of context keys.
 const (
-	NoTaskKey = "no_task"
+	NoTaskKey     = "no_task"
+	CopyVerifyKey = "copy_verify"
+	// CopyConflictKey is the stream.Conflict* of the files uploaded by a copy, empty to overwrite
+	CopyConflictKey = "copy_conflict"
+	// CopyIgnoreKey is the ignore patterns of a sync job, see model.IgnorePatterns, they are matched relative to
+	// the actual dst path of CopyIgnoreRootKey, or the dst dir of the copy if it isn't set
+	CopyIgnoreKey     = "copy_ignore"
+	CopyIgnoreRootKey = "copy_ignore_root"
+	// StaleListKey is a *bool set to true if op.List returns a stale cached listing
+	StaleListKey = "stale_list"
 )
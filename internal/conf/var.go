@@ -20,6 +20,7 @@ var (
 
 var SlicesMap = make(map[string][]string)
 var FilenameCharMap = make(map[string]string)
+var MimeTypesMap = make(map[string]string) // ext (with dot, lower case) -> mime type, overrides the builtin types
 var PrivacyReg []*regexp.Regexp
 
 var (
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetShortcuts() ([]model.Shortcut, error) {
+	var shortcuts []model.Shortcut
+	if err := db.Order(columnName("id")).Find(&shortcuts).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get shortcuts")
+	}
+	return shortcuts, nil
+}
+
+func GetShortcutById(id uint) (*model.Shortcut, error) {
+	var s model.Shortcut
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get shortcut")
+	}
+	return &s, nil
+}
+
+func CreateShortcut(s *model.Shortcut) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func DeleteShortcutById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Shortcut{}, id).Error)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetUploadHash(src string) (*model.UploadHash, error) {
+	var h model.UploadHash
+	if err := db.Where("src = ?", src).First(&h).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get upload hash")
+	}
+	return &h, nil
+}
+
+// SaveUploadHash replaces the hash of the same src
+func SaveUploadHash(h *model.UploadHash) error {
+	if err := db.Where("src = ?", h.Src).Delete(&model.UploadHash{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Create(h).Error)
+}
+
+func DeleteUploadHash(src string) error {
+	return errors.WithStack(db.Where("src = ?", src).Delete(&model.UploadHash{}).Error)
+}
+
+// DeleteUploadHashes deletes the hashes updated before t, and the least recently updated ones beyond max
+func DeleteUploadHashes(t time.Time, max int) error {
+	if err := db.Where("updated < ?", t).Delete(&model.UploadHash{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	var count int64
+	if err := db.Model(&model.UploadHash{}).Count(&count).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if count <= int64(max) {
+		return nil
+	}
+	var ids []uint
+	if err := db.Model(&model.UploadHash{}).Order("updated").Limit(int(count)-max).Pluck("id", &ids).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.UploadHash{}, ids).Error)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// SaveUploadRecord replaces the record of the same path, the file is overwritten by the upload
+func SaveUploadRecord(r *model.UploadRecord) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("path = ?", r.Path).Delete(&model.UploadRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(r).Error
+	}))
+}
+
+func GetUploadUsage(userID uint) (used, files int64, err error) {
+	var res struct {
+		Used  int64
+		Files int64
+	}
+	err = db.Model(&model.UploadRecord{}).Select("COALESCE(SUM(size), 0) AS used, COUNT(*) AS files").
+		Where("user_id = ?", userID).Scan(&res).Error
+	return res.Used, res.Files, errors.WithStack(err)
+}
+
+// DeleteUploadRecordsUnder deletes the records of path and the files under it
+func DeleteUploadRecordsUnder(path string) error {
+	prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.TrimSuffix(path, "/")) + "/%"
+	return errors.WithStack(db.Where("path = ? OR path LIKE ? ESCAPE '!'", path, prefix).Delete(&model.UploadRecord{}).Error)
+}
+
+func DeleteUploadRecordsByUser(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.UploadRecord{}).Error)
+}
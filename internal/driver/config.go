@@ -13,6 +13,11 @@ type Config struct {
 	Alert             string `json:"alert"` //info,success,warning,danger
 	NoOverwriteUpload bool   `json:"-"`     // whether to support overwrite upload
 	ProxyRangeOption  bool   `json:"-"`
+	// MakeDir fails with errs.ObjectExists if the name exists, so that op doesn't list the parent to check
+	// whether the folder exists before it's made
+	IdempotentMkdir bool `json:"-"`
+	// Put keeps both files if the upload conflicts and the stream asks for stream.ConflictRename
+	RenameUpload bool `json:"-"`
 }
 
 func (c Config) MustProxy() bool {
@@ -2,8 +2,10 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
 type Driver interface {
@@ -29,6 +31,22 @@ type Other interface {
 	Other(ctx context.Context, args model.OtherArgs) (interface{}, error)
 }
 
+// the kinds of the methods of Other, see OtherKind
+const (
+	// OtherRead the method only reads, the default
+	OtherRead = iota
+	// OtherWrite the method changes the files or the account, e.g. restores a version
+	OtherWrite
+	// OtherRemove the method removes the files permanently
+	OtherRemove
+)
+
+// OtherKind is implemented by a driver.Other whose methods may change the data, they are refused on
+// a read-only storage, and the users need the write or remove permission to call them
+type OtherKind interface {
+	OtherKind(method string) int
+}
+
 type Reader interface {
 	// List files in the path
 	// if identify files by path, need to set ID with path,like path.Join(dir.GetID(), obj.GetName())
@@ -42,11 +60,40 @@ type GetRooter interface {
 	GetRoot(ctx context.Context) (model.Obj, error)
 }
 
+type WithDetails interface {
+	// GetDetails get the quota of the storage, errors are treated as unknown
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
+type ListFilter interface {
+	// ListFilterKey describes the driver options which filter objs out of List,
+	// the search index of the storage is rebuilt when it changes
+	ListFilterKey() string
+}
+
+type SameBackend interface {
+	// SameBackend reports whether the other storage belongs to the same backend account,
+	// so that objs of it can be passed to the native Copy of this driver
+	SameBackend(other Driver) bool
+}
+
+type NameRestricted interface {
+	// IllegalNameChars are the characters the backend doesn't allow in a name besides the control characters,
+	// they are encoded in the names copied from the other storages if copy_name_encoding is enabled
+	IllegalNameChars() string
+}
+
 type Getter interface {
 	// Get file by path, the path haven't been joined with root path
 	Get(ctx context.Context, path string) (model.Obj, error)
 }
 
+type GetByID interface {
+	// GetByID gets the obj by its id in the backend without resolving its path, path is where it's relative to
+	// the root of the storage. errs.ObjectNotFound if there is no such obj or it's out of the root
+	GetByID(ctx context.Context, id string) (obj model.Obj, path string, err error)
+}
+
 //type Writer interface {
 //	Mkdir
 //	Move
@@ -76,6 +123,84 @@ type Remove interface {
 	Remove(ctx context.Context, obj model.Obj) error
 }
 
+type ListPager interface {
+	// ListPage list a page of the dir, nextCursor is empty if it's the last page
+	ListPage(ctx context.Context, dir model.Obj, args model.ListPageArgs) (objs []model.Obj, nextCursor string, err error)
+}
+
+type ListModifiedSince interface {
+	// ListModifiedSince list the files of the dir which are modified at or after since,
+	// the dirs are always listed so that the caller can go down
+	ListModifiedSince(ctx context.Context, dir model.Obj, since time.Time) ([]model.Obj, error)
+}
+
+type ListRecursive interface {
+	// ListRecursive calls fn with the objs under dir down to depth levels, 1 for the children only, 0 for unlimited.
+	// The path passed to fn is relative to dir and the order is undefined, it stops when fn returns an error.
+	// Each backend call should be made with the ctx of CallContext
+	ListRecursive(ctx context.Context, dir model.Obj, depth int, fn func(path string, obj model.Obj) error) error
+}
+
+type SearchName interface {
+	// SearchName lists the objs of dir, or under it if recursive, whose names contain keyword case-insensitively.
+	// It may return more objs, the caller filters them by the name. The objs must have the full path if recursive
+	SearchName(ctx context.Context, dir model.Obj, keyword string, recursive bool) ([]model.Obj, error)
+}
+
+type MkdirAll interface {
+	// MakeDirAll creates the dir at dirPath relative to the root of the storage with its missing parents,
+	// and returns the dir. It fails with errs.ObjectExists if dirPath exists, errs.FileExists if a parent is a file
+	MakeDirAll(ctx context.Context, dirPath string) (model.Obj, error)
+}
+
+type Toucher interface {
+	// Touch sets the mtime of the existing file without changing its content
+	Touch(ctx context.Context, file model.Obj, mtime time.Time) (model.Obj, error)
+}
+
+type Truncater interface {
+	// Truncate changes the size of the file to size without uploading it again, the extended part reads as zeros
+	Truncate(ctx context.Context, file model.Obj, size int64) (model.Obj, error)
+}
+
+type MtimeSetter interface {
+	// SetsMtime reports whether Put keeps file.ModTime() of the stream as the mtime of the new file,
+	// the fs copy layer passes the mtime of the src obj there. Other drivers ignore it
+	SetsMtime() bool
+}
+
+type ListOrder interface {
+	// ListOrder returns how the listing is sorted by the remote, in the orderBy and orderDirection of model.SortFiles,
+	// so that an obj changed in the list cache can be put in its place without listing again
+	ListOrder() (orderBy, orderDirection string)
+}
+
+// BatchMove, BatchCopy, BatchRemove and BatchRename are optional,
+// they handle many objs in as few requests as possible, the objs are operated one by one if not implemented
+
+type BatchMove interface {
+	BatchMove(ctx context.Context, srcObjs []model.Obj, dstDir model.Obj) error
+}
+
+type BatchCopy interface {
+	BatchCopy(ctx context.Context, srcObjs []model.Obj, dstDir model.Obj) error
+}
+
+type BatchRemove interface {
+	BatchRemove(ctx context.Context, objs []model.Obj) error
+}
+
+type BatchRename interface {
+	// BatchRename rename srcObjs[i] to newNames[i]
+	BatchRename(ctx context.Context, srcObjs []model.Obj, newNames []string) error
+}
+
+type RemovePermanently interface {
+	// RemovePermanently remove the obj without moving it to the recycle bin,
+	// drivers without a recycle bin don't need it
+	RemovePermanently(ctx context.Context, obj model.Obj) error
+}
+
 type Put interface {
 	// Put a file (provided as a FileStreamer) into the driver
 	// Besides the most basic upload functionality, the following features also need to be implemented:
@@ -162,6 +287,15 @@ type PutResult interface {
 	Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up UpdateProgress) (model.Obj, error)
 }
 
+type PutRapid interface {
+	// RapidHashTypes returns the hash types needed by PutRapid, e.g. md5
+	RapidHashTypes() []*utils.HashType
+	// PutRapid creates the file by its hash without uploading the content, such as the rapid upload
+	// of baidu netdisk. op.Put calls it before Put if the stream carries all the RapidHashTypes,
+	// and falls back to Put if it fails. The drivers without it just ignore the hash
+	PutRapid(ctx context.Context, dstDir model.Obj, file model.FileStreamer) (model.Obj, error)
+}
+
 type PutURLResult interface {
 	// PutURL directly put a URL into the storage
 	// Applicable to index-based drivers like URL-Tree or drivers that support uploading files as URLs
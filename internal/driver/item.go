@@ -13,6 +13,18 @@ type Item struct {
 	Help     string `json:"help"`
 }
 
+// Field is the machine-readable schema of an option of a driver, for the external tools to build storage forms
+type Field struct {
+	Name     string   `json:"name"`  // name of the go field, empty for the common options
+	Key      string   `json:"key"`   // key in the storage json, i.e. addition for the additional options
+	Group    string   `json:"group"` // common or additional
+	Type     string   `json:"type"`  // string, select, bool, text, number or float
+	Options  []string `json:"options"`
+	Default  string   `json:"default"`
+	Required bool     `json:"required"`
+	Help     string   `json:"help"`
+}
+
 type Info struct {
 	Common     []Item `json:"common"`
 	Additional []Item `json:"additional"`
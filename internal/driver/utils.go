@@ -5,6 +5,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"io"
+	"sync/atomic"
+	"time"
 )
 
 type UpdateProgress = model.UpdateProgress
@@ -29,6 +31,32 @@ func NewProgress(total int64, up UpdateProgress) *Progress {
 	}
 }
 
+// ChunkProgress reports the uploaded bytes of chunked uploads, chunks can be done concurrently
+type ChunkProgress struct {
+	total int64
+	done  atomic.Int64
+	up    UpdateProgress
+}
+
+func NewChunkProgress(total int64, up UpdateProgress) *ChunkProgress {
+	return &ChunkProgress{
+		total: total,
+		up:    up,
+	}
+}
+
+// Add reports n more bytes are done
+func (p *ChunkProgress) Add(n int64) {
+	done := p.done.Add(n)
+	if p.up != nil && p.total > 0 {
+		p.up(float64(done) / float64(p.total) * 100)
+	}
+}
+
+func (p *ChunkProgress) Done() int64 {
+	return p.done.Load()
+}
+
 type RateLimitReader = stream.RateLimitReader
 
 type RateLimitWriter = stream.RateLimitWriter
@@ -60,3 +88,19 @@ type ReaderWithCtx = stream.ReaderWithCtx
 type ReaderUpdatingProgress = stream.ReaderUpdatingProgress
 
 type SimpleReaderWithSize = stream.SimpleReaderWithSize
+
+type callTimeoutKey struct{}
+
+// WithCallTimeout sets the timeout of each backend call of a long driver call, e.g. of every page of ListRecursive,
+// whose total time depends on the size of the dir
+func WithCallTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, timeout)
+}
+
+// CallContext is the ctx of a backend call limited by the timeout of WithCallTimeout, if any
+func CallContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t, _ := ctx.Value(callTimeoutKey{}).(time.Duration); t > 0 {
+		return context.WithTimeout(ctx, t)
+	}
+	return ctx, func() {}
+}
@@ -1,8 +1,33 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"net"
+)
 
 var (
 	EmptyToken = errors.New("empty token")
 	LinkIsDir  = errors.New("link is dir")
+	// RateLimited is wrapped by the drivers when the backend throttles the requests
+	RateLimited = errors.New("rate limited by the backend")
+	// Unavailable is wrapped by the drivers when the backend fails with a 5xx
+	Unavailable = errors.New("the backend is temporarily unavailable")
+	// Unauthorized is wrapped by the drivers when the backend rejects the token or the credentials
+	Unauthorized = errors.New("unauthorized by the backend")
+	// QuotaExceeded is wrapped by the drivers when the backend has no space left for the write
+	QuotaExceeded = errors.New("not enough space in the backend")
+	// Forbidden is wrapped by the drivers when the backend denies the access to an object,
+	// unlike PermissionDenied which is refused by the permissions of alist
+	Forbidden = errors.New("forbidden by the backend")
+	// InvalidParameter is wrapped by the drivers when the backend rejects the parameters of a request
+	InvalidParameter = errors.New("invalid parameter for the backend")
 )
+
+// IsTransient tells whether err is likely to go away soon, e.g. throttling, a 5xx or a timeout
+func IsTransient(err error) bool {
+	if errors.Is(err, RateLimited) || errors.Is(err, Unavailable) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
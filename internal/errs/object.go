@@ -10,6 +10,11 @@ var (
 	ObjectNotFound = errors.New("object not found")
 	NotFolder      = errors.New("not a folder")
 	NotFile        = errors.New("not a file")
+	InvalidName    = errors.New("invalid object name")
+	FileExists     = errors.New("a file with the same name exists")
+	ObjectExists   = errors.New("an object with the same name exists")
+	ShortcutLoop   = errors.New("too many levels of shortcuts")
+	ShortcutChange = errors.New("a shortcut can't be moved, renamed or removed, manage it as a shortcut")
 )
 
 func IsObjectNotFound(err error) bool {
@@ -4,4 +4,6 @@ import "errors"
 
 var (
 	PermissionDenied = errors.New("permission denied")
+	UploadInProgress = errors.New("another upload to the same path is in progress")
+	UploadTooLarge   = errors.New("the uploaded file exceeds the max upload size of the storage")
 )
@@ -7,4 +7,6 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+
+	UploadQuotaExceeded = errors.New("upload quota exceeded")
 )
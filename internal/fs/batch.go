@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/pkg/errors"
+)
+
+// storagePaths is the actual paths of one storage, in the order of the mount paths
+type storagePaths struct {
+	storage     driver.Driver
+	actualPaths []string
+	index       []int // index of the actual paths in the given paths
+}
+
+func groupByStorage(paths []string) ([]*storagePaths, error) {
+	var groups []*storagePaths
+	for i, p := range paths {
+		storage, actualPath, err := op.GetStorageAndActualPath(p)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get storage of [%s]", p)
+		}
+		var g *storagePaths
+		for _, item := range groups {
+			if item.storage.GetStorage() == storage.GetStorage() {
+				g = item
+				break
+			}
+		}
+		if g == nil {
+			g = &storagePaths{storage: storage}
+			groups = append(groups, g)
+		}
+		g.actualPaths = append(g.actualPaths, actualPath)
+		g.index = append(g.index, i)
+	}
+	return groups, nil
+}
+
+func batchMove(ctx context.Context, srcPaths []string, dstDirPath string) error {
+	if err := checkNotShortcut(srcPaths...); err != nil {
+		return err
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	groups, err := groupByStorage(srcPaths)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.storage.GetStorage() != dstStorage.GetStorage() {
+			for _, i := range g.index {
+				if err := moveBetweenStorages(ctx, srcPaths[i], dstDirPath); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := op.BatchMove(ctx, dstStorage, g.actualPaths, dstDirActualPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchCopy(ctx context.Context, srcObjPaths []string, dstDirPath string) ([]task.TaskExtensionInfo, error) {
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	groups, err := groupByStorage(srcObjPaths)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []task.TaskExtensionInfo
+	for _, g := range groups {
+		// copy in the same storage with driver.BatchCopy, others are copied one by one as _copy does
+		if _, ok := dstStorage.(driver.BatchCopy); ok && g.storage.GetStorage() == dstStorage.GetStorage() {
+			if err = op.BatchCopy(ctx, dstStorage, g.actualPaths, dstDirActualPath); err != nil {
+				return tasks, err
+			}
+			continue
+		}
+		for i, index := range g.index {
+			t, err := _copy(ctx, srcObjPaths[index], dstDirPath, len(g.index) > i+1)
+			if err != nil {
+				return tasks, err
+			}
+			if t != nil {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+func batchRename(ctx context.Context, srcPaths, dstNames []string) error {
+	if len(srcPaths) != len(dstNames) {
+		return errors.New("the count of names mismatch")
+	}
+	if err := checkNotShortcut(srcPaths...); err != nil {
+		return err
+	}
+	groups, err := groupByStorage(srcPaths)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		names := make([]string, len(g.index))
+		for i, index := range g.index {
+			names[i] = dstNames[index]
+		}
+		if err = op.BatchRename(ctx, g.storage, g.actualPaths, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchRemove(ctx context.Context, paths []string) error {
+	if err := checkNotShortcut(paths...); err != nil {
+		return err
+	}
+	groups, err := groupByStorage(paths)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if err = op.BatchRemove(ctx, g.storage, g.actualPaths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
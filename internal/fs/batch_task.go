@@ -0,0 +1,207 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+const (
+	BatchOpMove = "move"
+	BatchOpCopy = "copy"
+)
+
+// BatchItem is the result of one src of a BatchTask, TaskID is the copy task added for it if any
+type BatchItem struct {
+	SrcPath string `json:"src_path"`
+	Done    bool   `json:"done"`
+	TaskID  string `json:"task_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchTask moves or copies many srcs to one dst dir. The srcs in the same storage are moved or copied
+// by one op.BatchMove or op.BatchCopy, if it fails they are tried one by one, so that a failed src
+// doesn't stop the others. A retry only runs the srcs not done
+type BatchTask struct {
+	task.TaskExtension
+	Status     string      `json:"-"`
+	Op         string      `json:"op"`
+	DstDirPath string      `json:"dst_path"`
+	Verify     string      `json:"verify"`
+	Items      []BatchItem `json:"items"`
+}
+
+var BatchTaskManager *tache.Manager[*BatchTask]
+
+func (t *BatchTask) GetName() string {
+	return fmt.Sprintf("%s %d objs to [%s]", t.Op, len(t.Items), t.DstDirPath)
+}
+
+func (t *BatchTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *BatchTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	// the copy tasks added for the items are created by the creator of the batch, _copy takes it from the user of ctx
+	ctx := context.WithValue(context.WithValue(t.Ctx(), "user", t.GetCreator()), conf.CopyVerifyKey, t.Verify)
+	var todo []int
+	var srcPaths []string
+	for i, item := range t.Items {
+		if !item.Done {
+			todo = append(todo, i)
+			srcPaths = append(srcPaths, item.SrcPath)
+		}
+	}
+	groups, err := groupByStorage(srcPaths)
+	if err != nil {
+		return err
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	for _, g := range groups {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		items := make([]*BatchItem, len(g.index))
+		for i, index := range g.index {
+			items[i] = &t.Items[todo[index]]
+		}
+		t.runGroup(ctx, g, items, dstStorage, dstDirActualPath)
+		t.Persist()
+	}
+	var failed []string
+	for _, item := range t.Items {
+		if !item.Done {
+			failed = append(failed, fmt.Sprintf("[%s]: %s", item.SrcPath, item.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d failed: %s", len(failed), len(t.Items), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// runGroup moves or copies the srcs of one storage, a batch call of the driver is only used
+// in the dst storage, the srcs of other storages are copied by the copy tasks as _copy does
+func (t *BatchTask) runGroup(ctx context.Context, g *storagePaths, items []*BatchItem, dstStorage driver.Driver, dstDirActualPath string) {
+	sameStorage := g.storage.GetStorage() == dstStorage.GetStorage()
+	if t.Op == BatchOpMove && !sameStorage {
+		for _, item := range items {
+			if utils.IsCanceled(ctx) {
+				return
+			}
+			t.Status = fmt.Sprintf("%s [%s]", t.Op, item.SrcPath)
+			t.setItem(item, moveBetweenStorages(ctx, item.SrcPath, t.DstDirPath))
+		}
+		return
+	}
+	_, batchCopy := dstStorage.(driver.BatchCopy)
+	if sameStorage && len(items) > 1 && (t.Op == BatchOpMove || batchCopy) {
+		t.Status = fmt.Sprintf("%s %d objs in [%s]", t.Op, len(items), g.storage.GetStorage().MountPath)
+		var err error
+		if t.Op == BatchOpMove {
+			err = op.BatchMove(ctx, dstStorage, g.actualPaths, dstDirActualPath)
+		} else {
+			err = op.BatchCopy(ctx, dstStorage, g.actualPaths, dstDirActualPath)
+		}
+		if err == nil {
+			for _, item := range items {
+				t.setItem(item, nil)
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	for i, item := range items {
+		if utils.IsCanceled(ctx) {
+			return
+		}
+		t.Status = fmt.Sprintf("%s [%s]", t.Op, item.SrcPath)
+		var err error
+		if t.Op == BatchOpMove {
+			err = op.Move(ctx, dstStorage, g.actualPaths[i], dstDirActualPath)
+			// already moved by the failed batch call
+			if errs.IsObjectNotFound(err) && exists(ctx, dstStorage, stdpath.Join(dstDirActualPath, stdpath.Base(g.actualPaths[i]))) {
+				err = nil
+			}
+		} else {
+			var tsk task.TaskExtensionInfo
+			tsk, err = _copy(ctx, item.SrcPath, t.DstDirPath)
+			if tsk != nil {
+				item.TaskID = tsk.GetID()
+			}
+		}
+		t.setItem(item, err)
+	}
+}
+
+func exists(ctx context.Context, storage driver.Driver, actualPath string) bool {
+	_, err := op.Get(ctx, storage, actualPath)
+	return err == nil
+}
+
+func (t *BatchTask) setItem(item *BatchItem, err error) {
+	item.Done, item.Error = err == nil, ""
+	if err != nil {
+		item.Error = err.Error()
+	}
+	done := 0
+	for _, i := range t.Items {
+		if i.Done {
+			done++
+		}
+	}
+	t.SetProgress(float64(done) / float64(len(t.Items)) * 100)
+}
+
+func batchMoveCopy(ctx context.Context, opName string, srcPaths []string, dstDirPath string) (task.TaskExtensionInfo, error) {
+	if opName != BatchOpMove && opName != BatchOpCopy {
+		return nil, errors.Errorf("invalid op: %s", opName)
+	}
+	if len(srcPaths) == 0 {
+		return nil, errors.New("empty src paths")
+	}
+	if opName == BatchOpMove {
+		if err := checkNotShortcut(srcPaths...); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := op.GetStorageAndActualPath(dstDirPath); err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	verify, _ := ctx.Value(conf.CopyVerifyKey).(string)
+	t := &BatchTask{
+		TaskExtension: task.TaskExtension{
+			Creator: taskCreator,
+		},
+		Op:         opName,
+		DstDirPath: utils.FixAndCleanPath(dstDirPath),
+		Verify:     verify,
+	}
+	for _, p := range srcPaths {
+		t.Items = append(t.Items, BatchItem{SrcPath: utils.FixAndCleanPath(p)})
+	}
+	BatchTaskManager.Add(t)
+	return t, nil
+}
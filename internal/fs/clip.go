@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+// ClipTask saves the byte range [Start, Start+Length) of a file as a new file, which is read
+// from the link of the src with a Range request and put to the dst storage
+type ClipTask struct {
+	task.TaskExtension
+	Status       string        `json:"-"`
+	SrcObjPath   string        `json:"src_path"`
+	DstDirPath   string        `json:"dst_path"`
+	DstName      string        `json:"dst_name"`
+	Start        int64         `json:"start"`
+	Length       int64         `json:"length"`
+	SrcStorageMp string        `json:"src_storage_mp"`
+	DstStorageMp string        `json:"dst_storage_mp"`
+	srcStorage   driver.Driver `json:"-"`
+	dstStorage   driver.Driver `json:"-"`
+}
+
+var ClipTaskManager *tache.Manager[*ClipTask]
+
+func (t *ClipTask) GetName() string {
+	return fmt.Sprintf("clip [%s](%s) bytes %d-%d to [%s](%s)", t.SrcStorageMp, t.SrcObjPath,
+		t.Start, t.Start+t.Length-1, t.DstStorageMp, stdpath.Join(t.DstDirPath, t.DstName))
+}
+
+func (t *ClipTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ClipTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	var err error
+	if t.srcStorage == nil {
+		t.srcStorage, err = op.GetStorageByMountPath(t.SrcStorageMp)
+	}
+	if t.dstStorage == nil && err == nil {
+		t.dstStorage, err = op.GetStorageByMountPath(t.DstStorageMp)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	t.SetTotalBytes(t.Length)
+	t.Status = "getting src link"
+	ss, err := openClip(t.Ctx(), t.srcStorage, t.SrcObjPath, t.Start, t.Length)
+	if err != nil {
+		return err
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: t.Start, Length: t.Length})
+	if err != nil {
+		return errors.WithMessagef(err, "failed read range of [%s]", t.SrcObjPath)
+	}
+	file := &stream.FileStream{
+		Ctx: t.Ctx(),
+		Obj: &model.Object{
+			Name:     t.DstName,
+			Size:     t.Length,
+			Modified: time.Now(),
+		},
+		Reader: r,
+	}
+	if c, ok := r.(io.Closer); ok {
+		file.Add(c)
+	}
+	t.Status = "uploading"
+	return op.Put(t.Ctx(), t.dstStorage, t.DstDirPath, file, t.SetProgress, true)
+}
+
+// openClip opens the link of the src file and checks that the range can be read of it
+func openClip(ctx context.Context, storage driver.Driver, path string, start, length int64) (*stream.SeekableStream, error) {
+	obj, err := op.Get(ctx, storage, path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", path)
+	}
+	if obj.IsDir() {
+		return nil, errors.Errorf("src [%s] is a folder", path)
+	}
+	if start < 0 || length <= 0 || start+length > obj.GetSize() {
+		return nil, errors.Errorf("range %d+%d is out of the size %d of [%s]", start, length, obj.GetSize(), path)
+	}
+	link, _, err := op.Link(ctx, storage, path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] link", path)
+	}
+	if err = probeRange(ctx, link, start); err != nil {
+		return nil, errors.WithMessagef(err, "can't clip [%s]", path)
+	}
+	return stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+}
+
+// probeRange requests the first byte of the range, a url which ignores Range can't be clipped
+// without downloading the bytes before start
+func probeRange(ctx context.Context, link *model.Link, start int64) error {
+	if link.MFile != nil || link.RangeReadCloser != nil {
+		return nil
+	}
+	if link.URL == "" {
+		return errors.New("the link is neither an url nor a range reader")
+	}
+	res, err := stream.RequestRangedHttp(ctx, link, start, 1)
+	if err != nil {
+		return errors.WithMessage(err, "failed probe range")
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		return errors.Errorf("the link doesn't support Range, it responds %s", res.Status)
+	}
+	return nil
+}
+
+// clipName is the default name of a clip, e.g. a_100-199.mp4
+func clipName(name string, start, length int64) string {
+	ext := stdpath.Ext(name)
+	return fmt.Sprintf("%s_%d-%d%s", strings.TrimSuffix(name, ext), start, start+length-1, ext)
+}
+
+func clip(ctx context.Context, srcObjPath, dstDirPath, dstName string, start, length int64) (task.TaskExtensionInfo, error) {
+	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	// fail early if the range can't be read, the stream is opened again by the task
+	ss, err := openClip(ctx, srcStorage, srcObjActualPath, start, length)
+	if err != nil {
+		return nil, err
+	}
+	_ = ss.Close()
+	if dstName == "" {
+		dstName = clipName(stdpath.Base(srcObjActualPath), start, length)
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &ClipTask{
+		TaskExtension: task.TaskExtension{
+			Creator: taskCreator,
+		},
+		srcStorage:   srcStorage,
+		dstStorage:   dstStorage,
+		SrcObjPath:   srcObjActualPath,
+		DstDirPath:   dstDirActualPath,
+		DstName:      dstName,
+		Start:        start,
+		Length:       length,
+		SrcStorageMp: srcStorage.GetStorage().MountPath,
+		DstStorageMp: dstStorage.GetStorage().MountPath,
+	}
+	ClipTaskManager.Add(t)
+	return t, nil
+}
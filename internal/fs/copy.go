@@ -6,6 +6,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"net/http"
 	stdpath "path"
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -28,6 +29,16 @@ type CopyTask struct {
 	dstStorage   driver.Driver `json:"-"`
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
+	Verify       string        `json:"verify"`
+	// Conflict is the stream.Conflict* of the uploaded files, empty to overwrite
+	Conflict string `json:"conflict,omitempty"`
+	// DstName is the encoded name of the copied file if it differs from the src, see encodeName
+	DstName string `json:"dst_name,omitempty"`
+	// Ignore is the ignore patterns of the sync job matched relative to IgnoreRoot in the dst storage
+	Ignore     string `json:"ignore,omitempty"`
+	IgnoreRoot string `json:"ignore_root,omitempty"`
+	// Ignored is the names of the objs of the dir skipped by the ignore patterns
+	Ignored []string `json:"ignored,omitempty"`
 }
 
 func (t *CopyTask) GetName() string {
@@ -69,13 +80,45 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
+	conflict, _ := ctx.Value(conf.CopyConflictKey).(string)
+	rules, err := copyIgnoreRules(ctx, dstStorage, dstDirActualPath)
+	if err != nil {
+		return nil, err
+	}
+	native := true
+	if !rules.empty() {
+		srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+		}
+		if rules.ignored(stdpath.Join(dstDirActualPath, encodeName(dstStorage, srcObj.GetName())), srcObj.IsDir()) {
+			return nil, nil
+		}
+		// the native copy of a dir copies the ignored objs too
+		native = !srcObj.IsDir()
+	}
+	if conflict == stream.ConflictNewer {
+		// the native copy of a dir overwrites all of its files, so they are copied one by one to check each
+		skip, ok, err := newerCopyCheck(ctx, srcStorage, dstStorage, srcObjActualPath, dstDirActualPath)
+		if err != nil || skip {
+			return nil, err
+		}
+		native = native && ok
+	}
 	// copy if in the same storage, just call driver.Copy
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	if native && srcStorage.GetStorage() == dstStorage.GetStorage() {
 		err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
 		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
 			return nil, err
 		}
 	}
+	// copy between two storages of the same backend, try the native copy of the driver
+	if native && srcStorage.GetStorage() != dstStorage.GetStorage() && op.IsSameBackend(srcStorage, dstStorage) {
+		err = op.CopyBetween(ctx, srcStorage, dstStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+			return nil, err
+		}
+	}
 	if ctx.Value(conf.NoTaskKey) != nil {
 		srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
 		if err != nil {
@@ -90,8 +133,13 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 				return nil, errors.WithMessagef(err, "failed get [%s] link", srcObjPath)
 			}
 			fs := stream.FileStream{
-				Obj: srcObj,
-				Ctx: ctx,
+				Obj:      srcObj,
+				Ctx:      ctx,
+				Conflict: conflict,
+				Source:   srcObjPath,
+			}
+			if name := encodeName(dstStorage, srcObj.GetName()); name != srcObj.GetName() {
+				fs.Obj = &model.ObjWrapName{Name: name, Obj: srcObj}
 			}
 			// any link provided is seekable
 			ss, err := stream.NewSeekableStream(fs, link)
@@ -103,6 +151,8 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	}
 	// not in the same storage
 	taskCreator, _ := ctx.Value("user").(*model.User)
+	verify, _ := ctx.Value(conf.CopyVerifyKey).(string)
+	ignore, _ := ctx.Value(conf.CopyIgnoreKey).(string)
 	t := &CopyTask{
 		TaskExtension: task.TaskExtension{
 			Creator: taskCreator,
@@ -113,6 +163,10 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		DstDirPath:   dstDirActualPath,
 		SrcStorageMp: srcStorage.GetStorage().MountPath,
 		DstStorageMp: dstStorage.GetStorage().MountPath,
+		Verify:       verify,
+		Conflict:     conflict,
+		Ignore:       ignore,
+		IgnoreRoot:   rules.jobRoot,
 	}
 	CopyTaskManager.Add(t)
 	return t, nil
@@ -130,12 +184,21 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 		if err != nil {
 			return errors.WithMessagef(err, "failed list src [%s] objs", srcObjPath)
 		}
+		rules, err := newIgnoreRules(dstStorage, t.Ignore, t.IgnoreRoot)
+		if err != nil {
+			return err
+		}
+		t.Ignored = nil
 		for _, obj := range objs {
 			if utils.IsCanceled(t.Ctx()) {
 				return nil
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
-			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
+			dstObjPath := stdpath.Join(dstDirPath, encodeName(dstStorage, srcObj.GetName()))
+			if rules.ignored(stdpath.Join(dstObjPath, encodeName(dstStorage, obj.GetName())), obj.IsDir()) {
+				t.Ignored = append(t.Ignored, obj.GetName())
+				continue
+			}
 			CopyTaskManager.Add(&CopyTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.GetCreator(),
@@ -146,9 +209,16 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 				DstDirPath:   dstObjPath,
 				SrcStorageMp: srcStorage.GetStorage().MountPath,
 				DstStorageMp: dstStorage.GetStorage().MountPath,
+				Verify:       t.Verify,
+				Conflict:     t.Conflict,
+				Ignore:       t.Ignore,
+				IgnoreRoot:   t.IgnoreRoot,
 			})
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
+		if len(t.Ignored) > 0 {
+			t.Status += fmt.Sprintf(", ignored %d: %s", len(t.Ignored), strings.Join(t.Ignored, ", "))
+		}
 		return nil
 	}
 	return copyFileBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstDirPath)
@@ -167,13 +237,49 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
 	}
 	fs := stream.FileStream{
-		Obj: srcFile,
-		Ctx: tsk.Ctx(),
+		Obj:      srcFile,
+		Ctx:      tsk.Ctx(),
+		Conflict: tsk.Conflict,
+		Source:   stdpath.Join(tsk.SrcStorageMp, srcFilePath),
+	}
+	if name := encodeName(dstStorage, srcFile.GetName()); name != srcFile.GetName() {
+		tsk.DstName = name
+		fs.Obj = &model.ObjWrapName{Name: name, Obj: srcFile}
 	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(fs, link)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	if tsk.Verify == "" {
+		return op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	}
+	srcHash, err := srcHashForVerify(ss)
+	if err != nil {
+		_ = ss.Close()
+		return errors.WithMessagef(err, "failed get [%s] hash", srcFilePath)
+	}
+	err = op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	if err != nil {
+		return err
+	}
+	tsk.Status = "verifying"
+	return verifyUploaded(tsk.Ctx(), tsk.Verify, srcHash, srcFile.ModTime(), dstStorage, dstDirPath, fs.GetName())
+}
+
+// newerCopyCheck tells whether the copy is skipped by stream.ConflictNewer since the dst file is the same or newer,
+// and whether the native copy of the driver can be used, only for a file
+func newerCopyCheck(ctx context.Context, srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) (skip bool, native bool, err error) {
+	srcObj, err := op.Get(ctx, srcStorage, srcObjPath)
+	if err != nil {
+		return false, false, errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+	}
+	if srcObj.IsDir() {
+		return false, false, nil
+	}
+	dstObj, err := op.Get(ctx, dstStorage, stdpath.Join(dstDirPath, encodeName(dstStorage, srcObj.GetName())))
+	if err != nil {
+		return false, true, nil
+	}
+	return !dstObj.IsDir() && !op.NewerOrChanged(srcObj, dstObj), true, nil
 }
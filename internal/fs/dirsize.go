@@ -0,0 +1,166 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DirSize is the total of the files under a dir, it grows while the dir is walked until Done
+type DirSize struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Files     int64     `json:"files"`
+	Dirs      int64     `json:"dirs"`
+	Done      bool      `json:"done"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type dirSizeEntry struct {
+	mu      sync.Mutex
+	size    DirSize
+	cancel  context.CancelFunc
+	expires time.Time // zero while walking
+}
+
+func (e *dirSizeEntry) get() DirSize {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.size
+}
+
+func (e *dirSizeEntry) expired(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// maxDirSizeWalks is how many dirs are walked at once, a later one is refused until one of them is done
+const maxDirSizeWalks = 2
+
+var ErrDirSizeBusy = errors.New("too many dir sizes are being computed, try it later")
+
+var (
+	dirSizesMu sync.Mutex
+	// by the user and the path, since the objs a user can't access are skipped
+	dirSizes     = make(map[string]*dirSizeEntry)
+	dirSizeWalks = make(chan struct{}, maxDirSizeWalks)
+)
+
+func dirSizeKey(user *model.User, path string) string {
+	if user == nil {
+		return "0:" + path
+	}
+	return fmt.Sprintf("%d:%s", user.ID, path)
+}
+
+func getDirSize(ctx context.Context, path string, refresh bool) (DirSize, error) {
+	path = utils.FixAndCleanPath(path)
+	user, _ := ctx.Value("user").(*model.User)
+	key := dirSizeKey(user, path)
+	now := time.Now()
+	dirSizesMu.Lock()
+	for k, e := range dirSizes {
+		if e.expired(now) {
+			delete(dirSizes, k)
+		}
+	}
+	e, ok := dirSizes[key]
+	if ok && refresh {
+		e.cancel()
+		delete(dirSizes, key)
+		ok = false
+	}
+	dirSizesMu.Unlock()
+	if ok {
+		return e.get(), nil
+	}
+	obj, err := Get(ctx, path, &GetArgs{})
+	if err != nil {
+		return DirSize{}, err
+	}
+	if !obj.IsDir() {
+		return DirSize{Path: path, Size: obj.GetSize(), Files: 1, Done: true, StartedAt: now, UpdatedAt: now}, nil
+	}
+	dirSizesMu.Lock()
+	defer dirSizesMu.Unlock()
+	// another call may have started it meanwhile
+	if e, ok := dirSizes[key]; ok {
+		return e.get(), nil
+	}
+	select {
+	case dirSizeWalks <- struct{}{}:
+	default:
+		return DirSize{}, errors.WithStack(ErrDirSizeBusy)
+	}
+	// the walk outlives the request, the user is kept to skip the objs it can't access
+	walkCtx, cancel := context.WithCancel(context.WithValue(context.Background(), "user", user))
+	e = &dirSizeEntry{size: DirSize{Path: path, StartedAt: now, UpdatedAt: now}, cancel: cancel}
+	dirSizes[key] = e
+	go e.walk(walkCtx)
+	return e.get(), nil
+}
+
+func (e *dirSizeEntry) walk(ctx context.Context) {
+	defer func() { <-dirSizeWalks }()
+	defer e.cancel()
+	path := e.size.Path
+	err := ListRecursive(ctx, path, 0, nil, func(_ string, obj model.Obj) error {
+		e.mu.Lock()
+		if obj.IsDir() {
+			e.size.Dirs++
+		} else {
+			e.size.Files++
+			e.size.Size += obj.GetSize()
+		}
+		e.size.UpdatedAt = time.Now()
+		e.mu.Unlock()
+		return ctx.Err()
+	})
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ctx.Err() != nil {
+		// invalidated, the entry is already removed
+		return
+	}
+	e.size.Done = true
+	e.size.UpdatedAt = time.Now()
+	if err != nil {
+		e.size.Error = err.Error()
+	}
+	e.expires = time.Now().Add(time.Duration(setting.GetInt(conf.DirSizeCacheTTL, 60)) * time.Minute)
+	log.Debugf("dir size of %s: %d bytes in %d files, %d dirs", path, e.size.Size, e.size.Files, e.size.Dirs)
+}
+
+// invalidateDirSizes drops the sizes of the dirs containing path and the ones under it, the walks are canceled
+func invalidateDirSizes(path string) {
+	dirSizesMu.Lock()
+	defer dirSizesMu.Unlock()
+	for k, e := range dirSizes {
+		// the path of an entry doesn't change
+		if p := e.size.Path; utils.IsSubPath(p, path) || utils.IsSubPath(path, p) {
+			e.cancel()
+			delete(dirSizes, k)
+		}
+	}
+}
+
+func init() {
+	op.RegisterFsChangeHook(func(typ, path, dstPath string) {
+		invalidateDirSizes(path)
+		if dstPath != "" {
+			invalidateDirSizes(dstPath)
+		}
+	})
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -18,8 +20,10 @@ import (
 // then pass the actual path to the op package
 
 type ListArgs struct {
-	Refresh bool
-	NoLog   bool
+	Refresh       bool
+	NoLog         bool
+	ModifiedSince time.Time
+	NameFilter    *model.NameFilter
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
@@ -33,6 +37,25 @@ func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	return res, nil
 }
 
+type ListPageArgs struct {
+	Cursor  string
+	Limit   int
+	Refresh bool
+	NoLog   bool
+}
+
+// ListPage list a page of files, nextCursor is empty if it's the last page
+func ListPage(ctx context.Context, path string, args *ListPageArgs) ([]model.Obj, string, error) {
+	res, next, err := listPage(ctx, path, args)
+	if err != nil {
+		if !args.NoLog {
+			log.Errorf("failed list %s: %+v", path, err)
+		}
+		return nil, "", err
+	}
+	return res, next, nil
+}
+
 type GetArgs struct {
 	NoLog bool
 }
@@ -57,6 +80,75 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	return res, file, nil
 }
 
+// Touch creates an empty file at path, or sets the mtime of the existing one
+func Touch(ctx context.Context, path string, mtime time.Time) error {
+	err := touch(ctx, path, mtime)
+	if err != nil {
+		log.Errorf("failed touch %s: %+v", path, err)
+	}
+	return err
+}
+
+// Truncate changes the size of the file at path, by the driver or by uploading it again
+func Truncate(ctx context.Context, path string, size int64) error {
+	err := truncate(ctx, path, size)
+	if err != nil {
+		log.Errorf("failed truncate %s: %+v", path, err)
+	}
+	return err
+}
+
+// Peek reads at most size bytes from the start of the file by a range request, see peek
+func Peek(ctx context.Context, path string, size int64) ([]byte, model.Obj, error) {
+	data, obj, err := peek(ctx, path, size)
+	if err != nil {
+		log.Errorf("failed peek %s: %+v", path, err)
+	}
+	return data, obj, err
+}
+
+// GetByID gets the obj of the storage at path by its id without resolving the path of the obj,
+// fullPath is where it is. errs.NotSupport if the storage can't
+func GetByID(ctx context.Context, path, id string) (model.Obj, string, error) {
+	obj, fullPath, err := getByID(ctx, path, id)
+	if err != nil {
+		log.Errorf("failed get %s by id %s: %+v", path, id, err)
+	}
+	return obj, fullPath, err
+}
+
+// LinkByID gets the link of the file of the storage at path by its id, see GetByID
+func LinkByID(ctx context.Context, path, id string, args model.LinkArgs) (*model.Link, model.Obj, string, error) {
+	link, file, fullPath, err := linkByID(ctx, path, id, args)
+	if err != nil {
+		log.Errorf("failed link %s by id %s: %+v", path, id, err)
+	}
+	return link, file, fullPath, err
+}
+
+// Hash returns the hashes of the file, the types which the driver doesn't provide are computed and cached
+func Hash(ctx context.Context, path string, types []*utils.HashType) (utils.HashInfo, error) {
+	res, err := hash(ctx, path, types)
+	if err != nil {
+		log.Errorf("failed hash %s: %+v", path, err)
+	}
+	return res, err
+}
+
+// CopiedName is the name of the file copied into dstDirPath by Copy, it may be encoded for the storage
+func CopiedName(dstDirPath, name string) string {
+	storage, _, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return name
+	}
+	return encodeName(storage, name)
+}
+
+// CachedHash wraps obj with the hashes computed by Hash before, it doesn't compute any
+func CachedHash(path string, obj model.Obj) model.Obj {
+	return cachedHash(utils.FixAndCleanPath(path), obj)
+}
+
 func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
 	err := makeDir(ctx, path, lazyCache...)
 	if err != nil {
@@ -73,6 +165,16 @@ func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	return err
 }
 
+// MoveBetween moves srcPath into dstDirPath of another storage by copying and verifying all the files,
+// the source is removed only if all of them are copied, see moveBetween
+func MoveBetween(ctx context.Context, srcPath, dstDirPath string, args *MoveBetweenArgs) error {
+	err := moveBetween(ctx, srcPath, dstDirPath, args)
+	if err != nil {
+		log.Errorf("failed move %s to %s between two storages: %+v", srcPath, dstDirPath, err)
+	}
+	return err
+}
+
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (task.TaskExtensionInfo, error) {
 	res, err := _copy(ctx, srcObjPath, dstDirPath, lazyCache...)
 	if err != nil {
@@ -81,6 +183,106 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool)
 	return res, err
 }
 
+// ListRecursive calls fn with the full path of each obj under path down to depth levels, 0 for unlimited,
+// by the recursive list of the driver if it's supported. Only the objs matching filter are passed to fn if it's set,
+// they are searched by the driver if it supports. It stops when fn returns an error, filepath.SkipAll stops it without error
+func ListRecursive(ctx context.Context, path string, depth int, filter *model.NameFilter, fn func(path string, obj model.Obj) error) error {
+	err := listRecursive(ctx, path, depth, filter, fn)
+	if err != nil {
+		log.Errorf("failed list %s recursively: %+v", path, err)
+	}
+	return err
+}
+
+// GetDirSize returns the size of the dir at path computed in the background. The first call starts to walk it
+// by the recursive list of the driver if it's supported, and the later ones get how far it is, until the result
+// expires after the dir_size_cache_ttl minutes or a write under the dir through op invalidates it.
+// The walk is restarted if refresh. The sizes are kept by the user of ctx, whose inaccessible objs are skipped,
+// and ErrDirSizeBusy is returned if too many dirs are being walked
+func GetDirSize(ctx context.Context, path string, refresh bool) (DirSize, error) {
+	res, err := getDirSize(ctx, path, refresh)
+	if err != nil {
+		log.Errorf("failed get dir size %s: %+v", path, err)
+	}
+	return res, err
+}
+
+// DiffDirs compares the dirs srcPath and dstPath recursively, which may be in different storages,
+// and returns what a SyncDirs would add, update and delete in the dst without doing it
+func DiffDirs(ctx context.Context, srcPath, dstPath string) (*Diff, error) {
+	res, err := diffDirs(ctx, srcPath, dstPath)
+	if err != nil {
+		log.Errorf("failed diff %s with %s: %+v", srcPath, dstPath, err)
+	}
+	return res, err
+}
+
+// SyncDirs makes dstPath the same as srcPath with as few operations as possible, see DiffDirs.
+// The objs only in the dst are removed if withDelete
+func SyncDirs(ctx context.Context, srcPath, dstPath string, withDelete bool) (*SyncResult, error) {
+	res, err := syncDirs(ctx, srcPath, dstPath, withDelete)
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcPath, dstPath, err)
+	}
+	return res, err
+}
+
+// Clip saves the bytes [start, start+length) of srcObjPath as dstName in dstDirPath by a task,
+// the name is derived from the src if empty
+func Clip(ctx context.Context, srcObjPath, dstDirPath, dstName string, start, length int64) (task.TaskExtensionInfo, error) {
+	res, err := clip(ctx, srcObjPath, dstDirPath, dstName, start, length)
+	if err != nil {
+		log.Errorf("failed clip %s to %s: %+v", srcObjPath, dstDirPath, err)
+	}
+	return res, err
+}
+
+// BatchMove same as Move, but the objs are moved with as few driver requests as possible
+func BatchMove(ctx context.Context, srcPaths []string, dstDirPath string) error {
+	err := batchMove(ctx, srcPaths, dstDirPath)
+	if err != nil {
+		log.Errorf("failed move %v to %s: %+v", srcPaths, dstDirPath, err)
+	}
+	return err
+}
+
+// BatchCopy same as Copy, but the objs in the dst storage are copied with as few driver requests as possible
+func BatchCopy(ctx context.Context, srcObjPaths []string, dstDirPath string) ([]task.TaskExtensionInfo, error) {
+	res, err := batchCopy(ctx, srcObjPaths, dstDirPath)
+	if err != nil {
+		log.Errorf("failed copy %v to %s: %+v", srcObjPaths, dstDirPath, err)
+	}
+	return res, err
+}
+
+// BatchMoveCopy adds a task moving or copying the srcs, which may be in different dirs and storages, to one dst dir,
+// opName is BatchOpMove or BatchOpCopy. The result of each src is kept in the task, see BatchTask
+func BatchMoveCopy(ctx context.Context, opName string, srcPaths []string, dstDirPath string) (task.TaskExtensionInfo, error) {
+	res, err := batchMoveCopy(ctx, opName, srcPaths, dstDirPath)
+	if err != nil {
+		log.Errorf("failed add %s task of %v to %s: %+v", opName, srcPaths, dstDirPath, err)
+	}
+	return res, err
+}
+
+// PlanMove returns the operations that Move would perform without performing them
+func PlanMove(ctx context.Context, srcPath, dstDirPath string) (*Plan, error) {
+	res, err := planMove(ctx, srcPath, dstDirPath)
+	if err != nil {
+		log.Errorf("failed plan move %s to %s: %+v", srcPath, dstDirPath, err)
+	}
+	return res, err
+}
+
+// PlanCopy returns the operations that Copy would perform without performing them
+func PlanCopy(ctx context.Context, srcObjPath, dstDirPath string) (*Plan, error) {
+	res, err := planCopy(ctx, srcObjPath, dstDirPath)
+	if err != nil {
+		log.Errorf("failed plan copy %s to %s: %+v", srcObjPath, dstDirPath, err)
+	}
+	return res, err
+}
+
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
 	err := rename(ctx, srcPath, dstName, lazyCache...)
 	if err != nil {
@@ -90,13 +292,40 @@ func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 }
 
 func Remove(ctx context.Context, path string) error {
-	err := remove(ctx, path)
+	err := remove(ctx, path, false)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
 	return err
 }
 
+// BatchRename rename srcPaths[i] to dstNames[i] with as few driver requests as possible
+func BatchRename(ctx context.Context, srcPaths, dstNames []string) error {
+	err := batchRename(ctx, srcPaths, dstNames)
+	if err != nil {
+		log.Errorf("failed rename %v to %v: %+v", srcPaths, dstNames, err)
+	}
+	return err
+}
+
+// BatchRemove same as Remove, but the objs are removed with as few driver requests as possible
+func BatchRemove(ctx context.Context, paths []string) error {
+	err := batchRemove(ctx, paths)
+	if err != nil {
+		log.Errorf("failed remove %v: %+v", paths, err)
+	}
+	return err
+}
+
+// RemovePermanently same as Remove, but skip the recycle bin of the driver
+func RemovePermanently(ctx context.Context, path string) error {
+	err := remove(ctx, path, true)
+	if err != nil {
+		log.Errorf("failed remove %s permanently: %+v", path, err)
+	}
+	return err
+}
+
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
 	if err != nil {
@@ -113,6 +342,22 @@ func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	return t, err
 }
 
+func PutURLAsTask(ctx context.Context, dstDirPath, dstName, urlStr string) (task.TaskExtensionInfo, error) {
+	t, err := putURLAsTask(ctx, dstDirPath, dstName, urlStr)
+	if err != nil {
+		log.Errorf("failed put %s to %s: %+v", urlStr, dstDirPath, err)
+	}
+	return t, err
+}
+
+func Pack(ctx context.Context, w io.Writer, dirPath string, args PackArgs) error {
+	err := pack(ctx, w, dirPath, args)
+	if err != nil {
+		log.Errorf("failed pack %s: %+v", dirPath, err)
+	}
+	return err
+}
+
 func ArchiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
 	meta, err := archiveMeta(ctx, path, args)
 	if err != nil {
@@ -153,6 +398,26 @@ func ArchiveInternalExtract(ctx context.Context, path string, args model.Archive
 	return l, obj, err
 }
 
+// InvalidateCache drops the cached listing and links of path, see op.InvalidateCache.
+// The storages mounted under path are also invalidated if recursive
+func InvalidateCache(path string, recursive bool) error {
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err == nil {
+		op.InvalidateCache(storage, actualPath, recursive)
+	} else if !recursive {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if recursive {
+		for _, s := range op.GetAllStorages() {
+			if mountPath := s.GetStorage().MountPath; mountPath != path && utils.IsSubPath(path, mountPath) {
+				op.InvalidateCache(s, "/", true)
+			}
+		}
+	}
+	return nil
+}
+
 type GetStoragesArgs struct {
 }
 
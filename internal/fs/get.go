@@ -15,13 +15,23 @@ func get(ctx context.Context, path string) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
 	// maybe a virtual file
 	if path != "/" {
-		virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(path))
+		_, shortcutDirs := op.GetShortcutsByPath(stdpath.Dir(path))
+		virtualFiles := append(op.GetStorageVirtualFilesByPath(stdpath.Dir(path)), shortcutDirs...)
 		for _, f := range virtualFiles {
 			if f.GetName() == stdpath.Base(path) {
 				return f, nil
 			}
 		}
 	}
+	if target, err := op.ResolveShortcut(path); err != nil {
+		return nil, err
+	} else if target != path {
+		obj, err := get(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		return &model.ObjWrapName{Name: stdpath.Base(path), Obj: obj}, nil
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		// if there are no storage prefix with path, maybe root folder
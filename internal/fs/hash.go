@@ -0,0 +1,145 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// computedHash is the hashes computed of a file, they are valid while its size and mtime are unchanged
+type computedHash struct {
+	size  int64
+	mtime time.Time
+	hash  map[*utils.HashType]string
+}
+
+// computing a hash downloads the whole file, so the results are kept long
+const computedHashTTL = 24 * time.Hour
+
+var hashCache = cache.NewMemCache(cache.WithShards[*computedHash](16))
+var hashG singleflight.Group[utils.HashInfo]
+
+// ParseHashTypes parses the comma separated hash names, e.g. "md5,crc32"
+func ParseHashTypes(names string) ([]*utils.HashType, error) {
+	var types []*utils.HashType
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		ht, ok := utils.GetHashByName(name)
+		if !ok {
+			return nil, errors.Wrapf(utils.ErrUnsupported, "%s", name)
+		}
+		types = append(types, ht)
+	}
+	return types, nil
+}
+
+// validHashes are the native hashes of obj and the cached computed ones, the native ones take precedence
+func validHashes(path string, obj model.Obj) map[*utils.HashType]string {
+	h := make(map[*utils.HashType]string)
+	if c, ok := hashCache.Get(path); ok && c.size == obj.GetSize() && c.mtime.Equal(obj.ModTime()) {
+		for ht, v := range c.hash {
+			h[ht] = v
+		}
+	}
+	for ht, v := range obj.GetHash().All() {
+		if len(v) == ht.Width {
+			h[ht] = v
+		}
+	}
+	return h
+}
+
+// cachedHash wraps obj with the computed hashes of it if there are any, so that they are shown besides the native ones
+func cachedHash(path string, obj model.Obj) model.Obj {
+	if obj.IsDir() {
+		return obj
+	}
+	if _, ok := hashCache.Get(path); !ok {
+		return obj
+	}
+	return &model.ObjWrapHash{Hash: utils.NewHashInfoByMap(validHashes(path, obj)), Obj: obj}
+}
+
+// hash returns the hashes of the file, the types which the driver doesn't provide are computed
+// by downloading the file, and cached until the size or the mtime of the file changes
+func hash(ctx context.Context, path string, types []*utils.HashType) (utils.HashInfo, error) {
+	path = utils.FixAndCleanPath(path)
+	obj, err := get(ctx, path)
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	if obj.IsDir() {
+		return utils.HashInfo{}, errors.Errorf("[%s] is a folder", path)
+	}
+	h := validHashes(path, obj)
+	var missing []*utils.HashType
+	for _, ht := range types {
+		if _, ok := h[ht]; !ok {
+			missing = append(missing, ht)
+		}
+	}
+	if len(missing) == 0 {
+		return utils.NewHashInfoByMap(h), nil
+	}
+	key := path
+	for _, ht := range missing {
+		key += "|" + ht.Name
+	}
+	computed, err, _ := hashG.Do(key+"|"+strconv.FormatInt(obj.GetSize(), 10), func() (utils.HashInfo, error) {
+		return computeHash(ctx, path, obj, missing)
+	})
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	for ht, v := range computed.All() {
+		h[ht] = v
+	}
+	c := &computedHash{size: obj.GetSize(), mtime: obj.ModTime(), hash: make(map[*utils.HashType]string)}
+	if old, ok := hashCache.Get(path); ok && old.size == c.size && old.mtime.Equal(c.mtime) {
+		for ht, v := range old.hash {
+			c.hash[ht] = v
+		}
+	}
+	for ht, v := range computed.All() {
+		c.hash[ht] = v
+	}
+	hashCache.Set(path, c, cache.WithEx[*computedHash](computedHashTTL))
+	return utils.NewHashInfoByMap(h), nil
+}
+
+func computeHash(ctx context.Context, path string, obj model.Obj, types []*utils.HashType) (utils.HashInfo, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessage(err, "failed get storage")
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	hasher := utils.NewMultiHasher(types)
+	if _, err = utils.CopyWithBuffer(hasher, ss); err != nil {
+		return utils.HashInfo{}, errors.WithMessagef(err, "failed hash [%s]", path)
+	}
+	if hasher.Size() != obj.GetSize() {
+		return utils.HashInfo{}, errors.Errorf("read %d bytes of [%s], but its size is %d", hasher.Size(), path, obj.GetSize())
+	}
+	return *hasher.GetHashInfo(), nil
+}
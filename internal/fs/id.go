@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+// getByID gets the obj of the storage at path by its id, fullPath is where the obj is
+func getByID(ctx context.Context, path, id string) (obj model.Obj, fullPath string, err error) {
+	storage, err := storageByID(path)
+	if err != nil {
+		return nil, "", err
+	}
+	obj, p, err := op.GetByID(ctx, storage, id)
+	if err != nil {
+		return nil, "", idErr(storage, err)
+	}
+	return obj, stdpath.Join(storage.GetStorage().MountPath, p), nil
+}
+
+// linkByID gets the link of the file of the storage at path by its id, fullPath is where the file is
+func linkByID(ctx context.Context, path, id string, args model.LinkArgs) (link *model.Link, file model.Obj, fullPath string, err error) {
+	storage, err := storageByID(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	link, file, p, err := op.LinkByID(ctx, storage, id, args)
+	if err != nil {
+		return nil, nil, "", idErr(storage, err)
+	}
+	return link, file, stdpath.Join(storage.GetStorage().MountPath, p), nil
+}
+
+func storageByID(path string) (driver.Driver, error) {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	return storage, nil
+}
+
+func idErr(storage driver.Driver, err error) error {
+	if errs.IsNotImplement(err) {
+		return errors.WithMessagef(errs.NotSupport, "storage [%s] can't get objs by id", storage.GetStorage().MountPath)
+	}
+	return err
+}
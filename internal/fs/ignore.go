@@ -0,0 +1,93 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ignoreRules are what the uploads and copies to a storage skip, the ignore patterns of the storage matched
+// relative to its root, and the ones of a sync job matched relative to its dst dir
+type ignoreRules struct {
+	storage *model.IgnorePatterns
+	job     *model.IgnorePatterns
+	jobRoot string
+}
+
+// storageIgnore is the ignore patterns of the storage, they are checked when the storage is saved,
+// so the invalid ones are only logged
+func storageIgnore(storage driver.Driver) *model.IgnorePatterns {
+	if storage == nil {
+		return nil
+	}
+	p, err := model.NewIgnorePatterns(storage.GetStorage().IgnorePatterns)
+	if err != nil {
+		log.Warnf("storage [%s]: %v", storage.GetStorage().MountPath, err)
+		return nil
+	}
+	return p
+}
+
+// newIgnoreRules parses the ignore patterns of the job, jobRoot is the actual dst path they are relative to
+func newIgnoreRules(storage driver.Driver, job, jobRoot string) (*ignoreRules, error) {
+	p, err := model.NewIgnorePatterns(job)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &ignoreRules{storage: storageIgnore(storage), job: p, jobRoot: jobRoot}, nil
+}
+
+// copyIgnoreRules are the rules of a copy into dstDirActualPath, the patterns of the job are in ctx if any
+func copyIgnoreRules(ctx context.Context, dstStorage driver.Driver, dstDirActualPath string) (*ignoreRules, error) {
+	ignore, _ := ctx.Value(conf.CopyIgnoreKey).(string)
+	ignoreRoot, ok := ctx.Value(conf.CopyIgnoreRootKey).(string)
+	if !ok {
+		ignoreRoot = dstDirActualPath
+	}
+	return newIgnoreRules(dstStorage, ignore, ignoreRoot)
+}
+
+// copyIgnored tells whether obj copied into dstDirPath is skipped by the ignore patterns, as _copy skips it
+func copyIgnored(ctx context.Context, obj model.Obj, dstDirPath string) (bool, error) {
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed get dst storage")
+	}
+	rules, err := copyIgnoreRules(ctx, dstStorage, dstDirActualPath)
+	if err != nil {
+		return false, err
+	}
+	return rules.ignored(stdpath.Join(dstDirActualPath, encodeName(dstStorage, obj.GetName())), obj.IsDir()), nil
+}
+
+func (r *ignoreRules) empty() bool {
+	return r.storage.Empty() && r.job.Empty()
+}
+
+// ignored tells whether the obj at the actual path in the dst storage is skipped
+func (r *ignoreRules) ignored(dstActualPath string, isDir bool) bool {
+	if r.storage.Match(dstActualPath, isDir) {
+		return true
+	}
+	if r.job.Empty() {
+		return false
+	}
+	rel, ok := strings.CutPrefix(dstActualPath, strings.TrimSuffix(r.jobRoot, "/")+"/")
+	return ok && r.job.Match(rel, isDir)
+}
+
+// uploadIgnored tells whether the file uploaded to the dir is skipped by the ignore patterns of the storage
+func uploadIgnored(storage driver.Driver, dstDirActualPath string, file model.FileStreamer) bool {
+	if !storageIgnore(storage).Match(stdpath.Join(dstDirActualPath, file.GetName()), false) {
+		return false
+	}
+	log.Infof("skip uploading [%s] to [%s], it's ignored by the storage", file.GetName(), dstDirActualPath)
+	return true
+}
@@ -2,7 +2,12 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
+	"path/filepath"
+	"strings"
 
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -15,8 +20,13 @@ import (
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
 	meta, _ := ctx.Value("meta").(*model.Meta)
 	user, _ := ctx.Value("user").(*model.User)
-	virtualFiles := op.GetStorageVirtualFilesByPath(path)
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	dir, err := op.ResolveShortcut(path)
+	if err != nil {
+		return nil, err
+	}
+	shortcuts := shortcutObjs(ctx, path)
+	virtualFiles := append(op.GetStorageVirtualFilesByPath(dir), shortcuts...)
+	storage, actualPath, err := op.GetStorageAndActualPath(dir)
 	if err != nil && len(virtualFiles) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -24,8 +34,10 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	var _objs []model.Obj
 	if storage != nil {
 		_objs, err = op.List(ctx, storage, actualPath, model.ListArgs{
-			ReqPath: path,
-			Refresh: args.Refresh,
+			ReqPath:       path,
+			Refresh:       args.Refresh,
+			ModifiedSince: args.ModifiedSince,
+			NameFilter:    args.NameFilter,
 		})
 		if err != nil {
 			if !args.NoLog {
@@ -41,10 +53,56 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	if whetherHide(user, meta, path) {
 		om.InitHideReg(meta.Hide)
 	}
-	objs := om.Merge(_objs, virtualFiles...)
+	// a shortcut hides the obj with the same name
+	objs := om.Merge(shortcuts, append(_objs, virtualFiles...)...)
 	return objs, nil
 }
 
+// listPage list a page of files, the virtual files are in the first page
+func listPage(ctx context.Context, path string, args *ListPageArgs) ([]model.Obj, string, error) {
+	meta, _ := ctx.Value("meta").(*model.Meta)
+	user, _ := ctx.Value("user").(*model.User)
+	dir, err := op.ResolveShortcut(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var virtualFiles, shortcuts []model.Obj
+	if args.Cursor == "" {
+		shortcuts = shortcutObjs(ctx, path)
+		virtualFiles = append(op.GetStorageVirtualFilesByPath(dir), shortcuts...)
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(dir)
+	if err != nil && len(virtualFiles) == 0 {
+		return nil, "", errors.WithMessage(err, "failed get storage")
+	}
+
+	var _objs []model.Obj
+	var next string
+	if storage != nil {
+		_objs, next, err = op.ListPage(ctx, storage, actualPath, model.ListPageArgs{
+			ReqPath: path,
+			Cursor:  args.Cursor,
+			Limit:   args.Limit,
+			Refresh: args.Refresh,
+		})
+		if err != nil {
+			if !args.NoLog {
+				log.Errorf("fs/list: %+v", err)
+			}
+			if len(virtualFiles) == 0 {
+				return nil, "", errors.WithMessage(err, "failed get objs")
+			}
+		}
+	}
+
+	om := model.NewObjMerge()
+	if whetherHide(user, meta, path) {
+		om.InitHideReg(meta.Hide)
+	}
+	objs := om.Merge(shortcuts, append(_objs, virtualFiles...)...)
+	return objs, next, nil
+}
+
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if user is nil, don't hide
 	if user == nil {
@@ -70,3 +128,123 @@ func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is guest, hide
 	return true
 }
+
+// listRecursive uses the recursive list of the driver if no storage is mounted under path,
+// otherwise the dirs are walked page by page. fn returns filepath.SkipAll to stop without error.
+// The objs the user of ctx can't access under path are skipped, see accessibleFn
+func listRecursive(ctx context.Context, path string, depth int, filter *model.NameFilter, fn func(path string, obj model.Obj) error) error {
+	path = utils.FixAndCleanPath(path)
+	if user, _ := ctx.Value("user").(*model.User); user != nil {
+		fn = accessibleFn(user, path, fn)
+	}
+	if filter != nil {
+		next := fn
+		fn = func(path string, obj model.Obj) error {
+			if !filter.Match(obj.GetName()) {
+				return nil
+			}
+			return next(path, obj)
+		}
+	}
+	err := _listRecursive(ctx, path, depth, filter, fn)
+	if errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// accessibleFn wraps fn to skip the objs under root which user can't access without a password,
+// i.e. the hidden ones and the ones protected by the password of a meta, with everything under them.
+// root itself is checked by the caller
+func accessibleFn(user *model.User, root string, fn func(path string, obj model.Obj) error) func(path string, obj model.Obj) error {
+	accessible := map[string]bool{root: true}
+	var check func(path string) bool
+	check = func(path string) bool {
+		if ok, checked := accessible[path]; checked {
+			return ok
+		}
+		ok := false
+		if dir := stdpath.Dir(path); dir != path && check(dir) {
+			meta, err := op.GetNearestMeta(path)
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				log.Warnf("failed get meta of [%s], it's skipped: %+v", path, err)
+			} else {
+				ok = common.CanAccessWithRoles(user, meta, path, "")
+			}
+		}
+		accessible[path] = ok
+		return ok
+	}
+	return func(path string, obj model.Obj) error {
+		if !utils.IsSubPath(root, path) || !check(path) {
+			return nil
+		}
+		return fn(path, obj)
+	}
+}
+
+func _listRecursive(ctx context.Context, path string, depth int, filter *model.NameFilter, fn func(path string, obj model.Obj) error) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err == nil && !hasSubStorage(path) && filter != nil && filter.Keyword() != "" {
+		err = searchRecursive(ctx, storage, path, actualPath, depth, filter.Keyword(), fn)
+		if !errors.Is(err, errs.NotImplement) {
+			return err
+		}
+	}
+	if err == nil && !hasSubStorage(path) {
+		err = op.ListRecursive(ctx, storage, actualPath, depth, func(p string, obj model.Obj) error {
+			return fn(stdpath.Join(path, p), obj)
+		})
+		if !errors.Is(err, errs.NotImplement) {
+			return err
+		}
+	}
+	obj, err := get(ctx, path)
+	if err != nil {
+		return err
+	}
+	if !obj.IsDir() {
+		return errors.WithStack(errs.NotFolder)
+	}
+	if depth <= 0 {
+		depth = -1
+	}
+	return WalkFS(ctx, depth, path, obj, func(p string, obj model.Obj) error {
+		if p == path {
+			return nil
+		}
+		return fn(p, obj)
+	})
+}
+
+// searchRecursive passes the objs searched by the driver under path down to depth levels to fn
+func searchRecursive(ctx context.Context, storage driver.Driver, path, actualPath string, depth int, keyword string, fn func(path string, obj model.Obj) error) error {
+	objs, err := op.SearchName(ctx, storage, actualPath, keyword, true)
+	if err != nil {
+		return err
+	}
+	// the objs have the paths in the driver
+	dir, err := op.GetUnwrap(ctx, storage, actualPath)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		rel := strings.TrimPrefix(strings.TrimPrefix(obj.GetPath(), dir.GetPath()), "/")
+		if rel == "" || depth > 0 && strings.Count(rel, "/") >= depth {
+			continue
+		}
+		if err := fn(stdpath.Join(path, rel), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasSubStorage(path string) bool {
+	for _, s := range op.GetAllStorages() {
+		if mountPath := s.GetStorage().MountPath; mountPath != path && utils.IsSubPath(path, mountPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"slices"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type MoveBetweenArgs struct {
+	// CleanFailed removes the dst files and dirs created by a failed move, the sources are always kept
+	CleanFailed bool
+}
+
+// moveResult is the progress of a move between two storages, Copied are the srcs copied and verified,
+// Created are the dst files and dirs which didn't exist before the move, Skipped are the srcs ignored by the dst
+type moveResult struct {
+	Copied  []string
+	Created []string
+	Skipped []string
+}
+
+// moveBetween moves srcPath into dstDirPath of another storage in two steps: all the files are copied and
+// verified first, then the source is removed. Nothing of the source is removed if any copy fails, and the objs
+// ignored by the ignore patterns of the dst are kept in the source with their parent dirs
+func moveBetween(ctx context.Context, srcPath, dstDirPath string, args *MoveBetweenArgs) error {
+	res := &moveResult{}
+	if err := copyVerified(ctx, srcPath, dstDirPath, res); err != nil {
+		if args.CleanFailed {
+			cleanMoved(ctx, res.Created)
+			res.Copied = nil
+		}
+		log.Warnf("failed move [%s], copied: %v, the source is kept", srcPath, res.Copied)
+		return errors.WithMessagef(err, "failed move [%s], copied %d files %v, the source is kept",
+			srcPath, len(res.Copied), res.Copied)
+	}
+	if len(res.Skipped) > 0 {
+		log.Infof("move [%s]: %v are ignored by the dst, they are kept in the source", srcPath, res.Skipped)
+	}
+	if err := removeMoved(ctx, srcPath, res.Skipped); err != nil {
+		return errors.WithMessagef(err, "copied all %d files of [%s], but failed remove the source, remove it after checking",
+			len(res.Copied), srcPath)
+	}
+	return nil
+}
+
+// removeMoved removes the moved srcPath except the skipped objs and the dirs containing them
+func removeMoved(ctx context.Context, srcPath string, skipped []string) error {
+	if !slices.ContainsFunc(skipped, func(s string) bool { return utils.IsSubPath(srcPath, s) }) {
+		return remove(ctx, srcPath, false)
+	}
+	if slices.Contains(skipped, srcPath) {
+		return nil
+	}
+	objs, err := list(ctx, srcPath, &ListArgs{NoLog: true, Refresh: true})
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err = removeMoved(ctx, stdpath.Join(srcPath, o.GetName()), skipped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveBetweenStorages is the move between two storages of fs, which is refused unless the setting enables it
+func moveBetweenStorages(ctx context.Context, srcPath, dstDirPath string) error {
+	if !setting.GetBool(conf.MoveBetweenStorages) {
+		return errors.WithStack(errs.MoveBetweenTwoStorages)
+	}
+	return moveBetween(ctx, srcPath, dstDirPath, &MoveBetweenArgs{CleanFailed: setting.GetBool(conf.CleanFailedMove)})
+}
+
+// copyVerified copies srcPath into dstDirPath synchronously, each file is verified after it's copied
+func copyVerified(ctx context.Context, srcPath, dstDirPath string, res *moveResult) error {
+	obj, err := get(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	// _copy skips it without an error, it's kept in the source instead of failing the verification
+	if ignored, err := copyIgnored(ctx, obj, dstDirPath); err != nil {
+		return err
+	} else if ignored {
+		res.Skipped = append(res.Skipped, srcPath)
+		return nil
+	}
+	if !obj.IsDir() {
+		dstPath := stdpath.Join(dstDirPath, CopiedName(dstDirPath, obj.GetName()))
+		_, existErr := get(ctx, dstPath)
+		// uploaded directly without a copy task, so that it can be verified once done
+		_, err = _copy(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), srcPath, dstDirPath)
+		if errs.IsObjectNotFound(existErr) {
+			// a failed upload may leave a part of the file too
+			res.Created = append(res.Created, dstPath)
+		}
+		if err != nil {
+			return err
+		}
+		if err = verifyCopied(ctx, obj, dstPath); err != nil {
+			return err
+		}
+		res.Copied = append(res.Copied, srcPath)
+		return nil
+	}
+	// named as the copy task names it, so that a dir the dst forbids the name of is encoded as well
+	dstPath := stdpath.Join(dstDirPath, CopiedName(dstDirPath, obj.GetName()))
+	if _, err = get(ctx, dstPath); errs.IsObjectNotFound(err) {
+		res.Created = append(res.Created, dstPath)
+	}
+	if err = makeDir(ctx, dstPath); err != nil {
+		return err
+	}
+	objs, err := list(ctx, srcPath, &ListArgs{NoLog: true, Refresh: true})
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err = copyVerified(ctx, stdpath.Join(srcPath, o.GetName()), dstPath, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyCopied checks the copy has the size of the source, and the same hash if both have one of the same type
+func verifyCopied(ctx context.Context, src model.Obj, dstPath string) error {
+	dst, err := get(ctx, dstPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get the copied [%s]", dstPath)
+	}
+	if dst.GetSize() != src.GetSize() {
+		return fmt.Errorf("the copied [%s] is %d bytes, but the source is %d bytes", dstPath, dst.GetSize(), src.GetSize())
+	}
+	dstHash := dst.GetHash()
+	for ht, v := range src.GetHash().All() {
+		if w := dstHash.GetHash(ht); len(v) == ht.Width && len(w) == ht.Width && !strings.EqualFold(v, w) {
+			return fmt.Errorf("the %s of the copied [%s] differs from the source", ht.Name, dstPath)
+		}
+	}
+	return nil
+}
+
+// cleanMoved removes the dsts created by a failed move, the ones removed with their parent are skipped
+func cleanMoved(ctx context.Context, created []string) {
+	removed := make([]string, 0, len(created))
+	for _, path := range created {
+		inRemoved := false
+		for _, dir := range removed {
+			if utils.IsSubPath(dir, path) {
+				inRemoved = true
+				break
+			}
+		}
+		if inRemoved {
+			continue
+		}
+		if err := remove(ctx, path, false); err != nil && !errs.IsObjectNotFound(err) {
+			log.Warnf("failed clean [%s] of the failed move: %v", path, err)
+			continue
+		}
+		removed = append(removed, path)
+	}
+}
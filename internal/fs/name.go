@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/setting"
+)
+
+// NameEncodingPercent percent-encodes the characters the dst storage doesn't allow
+const NameEncodingPercent = "percent"
+
+// encodeName encodes the name of an obj copied to dst if copy_name_encoding is percent and dst declares
+// its illegal characters, % is also encoded so that url.PathUnescape restores the original name.
+// The name is kept as is by default, the copy fails then if dst refuses it
+func encodeName(dst driver.Driver, name string) string {
+	if setting.GetStr(conf.CopyNameEncoding) != NameEncodingPercent {
+		return name
+	}
+	r, ok := dst.(driver.NameRestricted)
+	if !ok {
+		return name
+	}
+	illegal := r.IllegalNameChars()
+	var b strings.Builder
+	for _, c := range name {
+		if c != '%' && c >= 0x20 && !strings.ContainsRune(illegal, c) {
+			b.WriteRune(c)
+			continue
+		}
+		for _, x := range []byte(string(c)) {
+			fmt.Fprintf(&b, "%%%02X", x)
+		}
+	}
+	return b.String()
+}
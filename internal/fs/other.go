@@ -7,10 +7,10 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/drivers/s3"
-	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +23,9 @@ func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
 }
 
 func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
+	if err := checkNotShortcut(srcPath); err != nil {
+		return err
+	}
 	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get src storage")
@@ -32,12 +35,15 @@ func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 		return errors.WithMessage(err, "failed get dst storage")
 	}
 	if srcStorage.GetStorage() != dstStorage.GetStorage() {
-		return errors.WithStack(errs.MoveBetweenTwoStorages)
+		return moveBetweenStorages(ctx, srcPath, dstDirPath)
 	}
 	return op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
 }
 
 func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
+	if err := checkNotShortcut(srcPath); err != nil {
+		return err
+	}
 	storage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -45,12 +51,23 @@ func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	return op.Rename(ctx, storage, srcActualPath, dstName, lazyCache...)
 }
 
-func remove(ctx context.Context, path string) error {
+func remove(ctx context.Context, path string, permanent bool) error {
+	if err := checkNotShortcut(path); err != nil {
+		return err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Remove(ctx, storage, actualPath)
+	if permanent {
+		err = op.RemovePermanently(ctx, storage, actualPath)
+	} else {
+		err = op.Remove(ctx, storage, actualPath)
+	}
+	if err == nil {
+		op.CreditRemoved(utils.FixAndCleanPath(path))
+	}
+	return err
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
@@ -63,6 +80,9 @@ func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	if _, ok := storage.(*s3.S3); ok {
 		method := strings.ToLower(strings.TrimSpace(args.Method))
 		if method == s3.OtherMethodArchive || method == s3.OtherMethodThaw {
+			if err := op.CheckOtherWritable(storage, method); err != nil {
+				return nil, err
+			}
 			if S3TransitionTaskManager == nil {
 				return nil, errors.New("s3 transition task manager is not initialized")
 			}
@@ -0,0 +1,200 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	PackZip = "zip"
+	PackTar = "tar"
+)
+
+func IsValidPackFormat(format string) bool {
+	return format == PackZip || format == PackTar
+}
+
+type PackArgs struct {
+	Format string
+	// Store disables the compression of zip, for already compressed media
+	Store bool
+	// Flatten packs all the files in the archive root without the dirs, a name which is taken
+	// is suffixed as "name (1).ext" in the order of walking
+	Flatten bool
+	// Filter reports whether the obj should be packed, a dir is skipped with its children if false
+	Filter func(reqPath string, obj model.Obj) bool
+}
+
+// packWriter is the common part of zip and tar writers
+type packWriter interface {
+	writeDir(name string, obj model.Obj) error
+	writeFile(name string, obj model.Obj) (io.Writer, error)
+	Close() error
+}
+
+type zipPackWriter struct {
+	*zip.Writer
+	method uint16
+}
+
+func (z *zipPackWriter) writeDir(name string, obj model.Obj) error {
+	_, err := z.CreateHeader(&zip.FileHeader{
+		Name:     name + "/",
+		Method:   zip.Store,
+		Modified: obj.ModTime(),
+	})
+	return err
+}
+
+func (z *zipPackWriter) writeFile(name string, obj model.Obj) (io.Writer, error) {
+	return z.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   z.method,
+		Modified: obj.ModTime(),
+	})
+}
+
+type tarPackWriter struct {
+	*tar.Writer
+}
+
+func (t *tarPackWriter) writeDir(name string, obj model.Obj) error {
+	return t.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		ModTime:  obj.ModTime(),
+		Format:   tar.FormatPAX,
+	})
+}
+
+func (t *tarPackWriter) writeFile(name string, obj model.Obj) (io.Writer, error) {
+	err := t.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     obj.GetSize(),
+		Mode:     0644,
+		ModTime:  obj.ModTime(),
+		Format:   tar.FormatPAX,
+	})
+	return t.Writer, err
+}
+
+// pack streams the dir as an archive to w, files are read one by one from their links,
+// so nothing is staged on disk. A file which fails to open is skipped, but an error
+// after its header is written breaks the archive and stops packing.
+func pack(ctx context.Context, w io.Writer, dirPath string, args PackArgs) error {
+	dirPath = utils.FixAndCleanPath(dirPath)
+	dir, err := get(ctx, dirPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", dirPath)
+	}
+	if !dir.IsDir() {
+		return errors.Errorf("[%s] is not a dir", dirPath)
+	}
+	var pw packWriter
+	switch args.Format {
+	case PackZip:
+		method := zip.Deflate
+		if args.Store {
+			method = zip.Store
+		}
+		pw = &zipPackWriter{Writer: zip.NewWriter(w), method: method}
+	case PackTar:
+		pw = &tarPackWriter{Writer: tar.NewWriter(w)}
+	default:
+		return errors.Errorf("unsupported pack format: %s", args.Format)
+	}
+	names := packNames{}
+	err = WalkFS(ctx, -1, dirPath, dir, func(reqPath string, obj model.Obj) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if reqPath == dirPath {
+			return nil
+		}
+		if args.Filter != nil && !args.Filter(reqPath, obj) {
+			if obj.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := entryName(strings.TrimPrefix(strings.TrimPrefix(reqPath, dirPath), "/"))
+		if args.Flatten {
+			if obj.IsDir() {
+				return nil
+			}
+			name = names.take(entryName(obj.GetName()))
+		}
+		if obj.IsDir() {
+			return pw.writeDir(name, obj)
+		}
+		return packFile(ctx, pw, reqPath, name)
+	})
+	if err != nil {
+		_ = pw.Close()
+		return err
+	}
+	return pw.Close()
+}
+
+// entryName makes the entry name of the relative path, which is separated by "/" in both zip and tar
+// on all platforms. A backslash in a name is replaced, otherwise it's taken as a separator when extracting on windows
+func entryName(rel string) string {
+	return strings.ReplaceAll(rel, "\\", "_")
+}
+
+// packNames de-duplicates the flattened names case-insensitively, since they collide when extracting on windows or macOS
+type packNames map[string]struct{}
+
+func (n packNames) take(name string) string {
+	ext := stdpath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	res := name
+	for i := 1; ; i++ {
+		if _, ok := n[strings.ToLower(res)]; !ok {
+			break
+		}
+		res = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	n[strings.ToLower(res)] = struct{}{}
+	return res
+}
+
+func packFile(ctx context.Context, pw packWriter, reqPath, name string) error {
+	l, obj, err := link(ctx, reqPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		log.Warnf("skip packing [%s]: failed get link: %+v", reqPath, err)
+		return nil
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, l)
+	if err != nil {
+		log.Warnf("skip packing [%s]: failed get stream: %+v", reqPath, err)
+		return nil
+	}
+	defer ss.Close()
+	fw, err := pw.writeFile(name, obj)
+	if err != nil {
+		return errors.WithMessagef(err, "failed write header of [%s]", reqPath)
+	}
+	if _, err = utils.CopyWithBuffer(fw, ss); err != nil {
+		return errors.WithMessagef(err, "failed pack [%s]", reqPath)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// peek reads at most size bytes from the start of the file at path, only the range is requested from the link
+// of the driver so that the rest isn't downloaded. The file is returned too, to tell whether it is truncated
+func peek(ctx context.Context, path string, size int64) ([]byte, model.Obj, error) {
+	if size <= 0 {
+		return nil, nil, errors.Errorf("invalid size: %d", size)
+	}
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get storage")
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.IsDir() {
+		return nil, nil, errors.WithStack(errs.NotFile)
+	}
+	length := min(size, obj.GetSize())
+	if length == 0 {
+		return []byte{}, obj, nil
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: length})
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed read [%s]", path)
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	data, err := io.ReadAll(io.LimitReader(r, length))
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed read [%s]", path)
+	}
+	return data, obj, nil
+}
@@ -0,0 +1,163 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+)
+
+const (
+	PlanServerMove = "server_move"
+	PlanServerCopy = "server_copy"
+	PlanTransfer   = "transfer"
+)
+
+// PlannedOp is an operation that a copy or move would perform
+type PlannedOp struct {
+	SrcPath string `json:"src_path"`
+	DstPath string `json:"dst_path"`
+	Size    int64  `json:"size"`
+	IsDir   bool   `json:"is_dir"`
+	Method  string `json:"method"`
+}
+
+// Plan is the result of a dry run
+type Plan struct {
+	Ops           []PlannedOp `json:"ops"`
+	TotalFiles    int         `json:"total_files"`
+	TotalSize     int64       `json:"total_size"`
+	TransferBytes int64       `json:"transfer_bytes"`
+}
+
+func (p *Plan) add(o PlannedOp) {
+	p.Ops = append(p.Ops, o)
+	if !o.IsDir {
+		p.TotalFiles++
+		p.TotalSize += o.Size
+		if o.Method == PlanTransfer {
+			p.TransferBytes += o.Size
+		}
+	}
+}
+
+// Merge appends the ops of another plan
+func (p *Plan) Merge(o *Plan) {
+	for _, item := range o.Ops {
+		p.add(item)
+	}
+}
+
+func planMove(ctx context.Context, srcPath, dstDirPath string) (*Plan, error) {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, _, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	sameStorage := srcStorage.GetStorage() == dstStorage.GetStorage()
+	if !sameStorage && !setting.GetBool(conf.MoveBetweenStorages) {
+		return nil, errors.WithStack(errs.MoveBetweenTwoStorages)
+	}
+	srcObj, err := op.Get(ctx, srcStorage, srcActualPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcPath)
+	}
+	plan := &Plan{}
+	if !sameStorage {
+		// same as moveBetween, all the files are transferred
+		if err = planTransfer(ctx, plan, srcStorage, srcPath, srcActualPath, srcObj, dstDirPath); err != nil {
+			return nil, err
+		}
+		return plan, nil
+	}
+	plan.add(PlannedOp{
+		SrcPath: srcPath,
+		DstPath: stdpath.Join(dstDirPath, srcObj.GetName()),
+		Size:    srcObj.GetSize(),
+		IsDir:   srcObj.IsDir(),
+		Method:  PlanServerMove,
+	})
+	return plan, nil
+}
+
+func planCopy(ctx context.Context, srcObjPath, dstDirPath string) (*Plan, error) {
+	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, _, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+	}
+	plan := &Plan{}
+	// same as _copy, the driver copy is used if in the same storage or backend
+	if op.IsSameBackend(srcStorage, dstStorage) && canCopy(dstStorage) {
+		plan.add(PlannedOp{
+			SrcPath: srcObjPath,
+			DstPath: stdpath.Join(dstDirPath, srcObj.GetName()),
+			Size:    srcObj.GetSize(),
+			IsDir:   srcObj.IsDir(),
+			Method:  PlanServerCopy,
+		})
+		return plan, nil
+	}
+	err = planTransfer(ctx, plan, srcStorage, srcObjPath, srcObjActualPath, srcObj, dstDirPath)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planTransfer walks the src tree the same way as copyBetween2Storages
+func planTransfer(ctx context.Context, plan *Plan, srcStorage driver.Driver, srcPath, srcActualPath string, srcObj model.Obj, dstDirPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dstPath := stdpath.Join(dstDirPath, srcObj.GetName())
+	plan.add(PlannedOp{
+		SrcPath: srcPath,
+		DstPath: dstPath,
+		Size:    srcObj.GetSize(),
+		IsDir:   srcObj.IsDir(),
+		Method:  PlanTransfer,
+	})
+	if !srcObj.IsDir() {
+		return nil
+	}
+	objs, err := op.List(ctx, srcStorage, srcActualPath, model.ListArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s] objs", srcPath)
+	}
+	for _, obj := range objs {
+		err = planTransfer(ctx, plan, srcStorage,
+			stdpath.Join(srcPath, obj.GetName()),
+			stdpath.Join(srcActualPath, obj.GetName()),
+			obj, dstPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func canCopy(storage driver.Driver) bool {
+	switch storage.(type) {
+	case driver.Copy, driver.CopyResult:
+		return true
+	default:
+		return false
+	}
+}
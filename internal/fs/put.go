@@ -3,14 +3,22 @@ package fs
 import (
 	"context"
 	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"time"
+
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/net"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/xhofe/tache"
-	"time"
 )
 
 type UploadTask struct {
@@ -18,13 +26,24 @@ type UploadTask struct {
 	storage          driver.Driver
 	dstDirActualPath string
 	file             model.FileStreamer
+	// url and name are set if the file is fetched from url when the task runs
+	url  string
+	name string
+	// skipped is set if the name resolved from the url is ignored by the storage
+	skipped bool
 }
 
 func (t *UploadTask) GetName() string {
+	if t.url != "" {
+		return fmt.Sprintf("upload %s to [%s](%s)", t.url, t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	}
 	return fmt.Sprintf("upload %s to [%s](%s)", t.file.GetName(), t.storage.GetStorage().MountPath, t.dstDirActualPath)
 }
 
 func (t *UploadTask) GetStatus() string {
+	if t.skipped {
+		return "skipped, ignored by the storage"
+	}
 	return "uploading"
 }
 
@@ -32,7 +51,81 @@ func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	file := t.file
+	if t.url != "" {
+		// the url is opened again on every retry, since the stream of the last run is consumed
+		var err error
+		file, err = openURLStream(t.Ctx(), t.storage, t.url, t.name)
+		if err != nil {
+			return err
+		}
+		// the name is known only now if it's from the url
+		if uploadIgnored(t.storage, t.dstDirActualPath, file) {
+			_ = file.Close()
+			t.skipped = true
+			return nil
+		}
+		t.SetTotalBytes(file.GetSize())
+	}
+	// the upload quota of the creator is checked and counted by op.Put
+	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, file, t.SetProgress, true)
+}
+
+// openURLStream opens the url as a file stream to upload to the storage, the body is read while uploading,
+// it's only cached in a temp file if the size is unknown
+func openURLStream(ctx context.Context, storage driver.Driver, urlStr, name string) (model.FileStreamer, error) {
+	// the url is given by the user, it mustn't reach the services of the local network
+	res, err := net.RequestPublicHttp(ctx, http.MethodGet, http.Header{}, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = urlFileName(res, urlStr)
+	}
+	mimetype := res.Header.Get("Content-Type")
+	if mimetype == "" {
+		mimetype = utils.GetMimeType(name)
+	}
+	modified := time.Now()
+	if lm, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		modified = lm
+	}
+	s := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     res.ContentLength,
+			Modified: modified,
+			Ctime:    modified,
+		},
+		Reader:   res.Body,
+		Mimetype: mimetype,
+	}
+	s.Closers.Add(res.Body)
+	if err = op.LimitUploadSize(storage, s); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	if res.ContentLength < 0 {
+		if _, err = s.CacheFullInTempFile(); err != nil {
+			_ = s.Close()
+			return nil, errors.WithMessagef(err, "failed cache [%s]", urlStr)
+		}
+	}
+	return s, nil
+}
+
+// urlFileName get the file name from Content-Disposition, or the last element of the url path
+func urlFileName(res *http.Response, urlStr string) string {
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return stdpath.Base(params["filename"])
+	}
+	if u, err := url.Parse(urlStr); err == nil {
+		if name := stdpath.Base(u.Path); name != "/" && name != "." {
+			return name
+		}
+	}
+	return "index.html"
 }
 
 var UploadTaskManager *tache.Manager[*UploadTask]
@@ -46,6 +139,13 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	if uploadIgnored(storage, dstDirActualPath, file) {
+		return nil, nil
+	}
+	// checked before caching the file
+	if err := op.LimitUploadSize(storage, file); err != nil {
+		return nil, err
+	}
 	if file.NeedStore() {
 		_, err := file.CacheFullInTempFile()
 		if err != nil {
@@ -55,6 +155,9 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 		//file.SetTmpFile(tempFile)
 	}
 	taskCreator, _ := ctx.Value("user").(*model.User) // taskCreator is nil when convert failed
+	if err := op.CheckUploadQuota(taskCreator, file.GetSize()); err != nil {
+		return nil, err
+	}
 	t := &UploadTask{
 		TaskExtension: task.TaskExtension{
 			Creator: taskCreator,
@@ -77,5 +180,38 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	if storage.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
+	if uploadIgnored(storage, dstDirActualPath, file) {
+		return nil
+	}
 	return op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
 }
+
+// putURLAsTask add a task which streams the file of url to the storage
+func putURLAsTask(ctx context.Context, dstDirPath, dstName, urlStr string) (task.TaskExtensionInfo, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.Errorf("invalid url: %s", urlStr)
+	}
+	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	if storage.Config().NoUpload {
+		return nil, errors.WithStack(errs.UploadNotSupported)
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	if err := op.CheckUploadQuota(taskCreator, -1); err != nil {
+		return nil, err
+	}
+	t := &UploadTask{
+		TaskExtension: task.TaskExtension{
+			Creator: taskCreator,
+		},
+		storage:          storage,
+		dstDirActualPath: dstDirActualPath,
+		url:              urlStr,
+		name:             dstName,
+	}
+	UploadTaskManager.Add(t)
+	return t, nil
+}
@@ -0,0 +1,36 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkNotShortcut fails if any of paths is a shortcut, the move, rename or remove of it would change its target
+func checkNotShortcut(paths ...string) error {
+	for _, p := range paths {
+		if op.IsShortcut(p) {
+			return errors.WithMessagef(errs.ShortcutChange, "[%s]", p)
+		}
+	}
+	return nil
+}
+
+// shortcutObjs returns the shortcuts in dir as their targets named after the shortcuts,
+// and the virtual dirs in dir which contain deeper shortcuts
+func shortcutObjs(ctx context.Context, dir string) []model.Obj {
+	shortcuts, objs := op.GetShortcutsByPath(dir)
+	for _, s := range shortcuts {
+		obj, err := get(ctx, s.Path)
+		if err != nil {
+			log.Warnf("failed get the target [%s] of shortcut [%s]: %v", s.Target, s.Path, err)
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
@@ -0,0 +1,273 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	DiffReasonMissing = "missing"
+	DiffReasonType    = "type"
+	DiffReasonSize    = "size"
+	DiffReasonHash    = "hash"
+	DiffReasonMtime   = "mtime"
+	DiffReasonExtra   = "extra"
+)
+
+// DiffEntry is a file or dir which differs between the src and the dst, Path is relative to them,
+// by the src names except for the deleted ones. A dir missing in the dst or extra in it is a single entry,
+// its objs are not listed
+type DiffEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	IsDir  bool   `json:"is_dir"`
+	Reason string `json:"reason"`
+}
+
+// Diff is what a sync from the src to the dst would do, Ignored is the src objs skipped by the ignore patterns,
+// the ignored dst objs are neither updated nor deleted
+type Diff struct {
+	Add     []DiffEntry `json:"add"`
+	Update  []DiffEntry `json:"update"`
+	Delete  []DiffEntry `json:"delete"`
+	Ignored []DiffEntry `json:"ignored"`
+}
+
+// SyncResult is what a sync did, the add and the update are copied by the tasks
+type SyncResult struct {
+	Diff
+	Tasks   []task.TaskExtensionInfo `json:"-"`
+	Deleted int                      `json:"deleted"`
+}
+
+// listTree lists the objs under path recursively by their paths relative to it, empty if path doesn't exist
+func listTree(ctx context.Context, path string) (map[string]model.Obj, error) {
+	objs := make(map[string]model.Obj)
+	if _, err := get(ctx, path); errs.IsObjectNotFound(err) {
+		return objs, nil
+	}
+	err := listRecursive(ctx, path, 0, nil, func(p string, obj model.Obj) error {
+		if rel := strings.TrimPrefix(strings.TrimPrefix(p, path), "/"); rel != "" {
+			objs[rel] = obj
+		}
+		return nil
+	})
+	return objs, err
+}
+
+// dstRelPath is the path in the dst of a src path, the names may be encoded for the dst storage, see encodeName
+func dstRelPath(dst driver.Driver, rel string) string {
+	if dst == nil {
+		return rel
+	}
+	names := strings.Split(rel, "/")
+	for i, name := range names {
+		names[i] = encodeName(dst, name)
+	}
+	return strings.Join(names, "/")
+}
+
+// sameHash compares the hashes of the same type, ok is false if they have none in common
+func sameHash(a, b utils.HashInfo) (same bool, ok bool) {
+	for ht, v := range a.All() {
+		w := b.GetHash(ht)
+		if len(v) != ht.Width || len(w) != ht.Width {
+			continue
+		}
+		return strings.EqualFold(v, w), true
+	}
+	return false, false
+}
+
+// fileDiffReason tells why the dst file should be updated, empty if it's the same as the src.
+// The mtime is only compared without a common hash, and only a newer src counts,
+// since many drivers don't keep the mtime of the uploaded files
+func fileDiffReason(src, dst model.Obj) string {
+	if src.GetSize() != dst.GetSize() {
+		return DiffReasonSize
+	}
+	if same, ok := sameHash(src.GetHash(), dst.GetHash()); ok {
+		if !same {
+			return DiffReasonHash
+		}
+		return ""
+	}
+	if src.ModTime().Unix() > dst.ModTime().Unix() {
+		return DiffReasonMtime
+	}
+	return ""
+}
+
+// underAny tells whether rel is under one of the dirs
+func underAny(rel string, dirs map[string]struct{}) bool {
+	for dir := stdpath.Dir(rel); dir != "."; dir = stdpath.Dir(dir) {
+		if _, ok := dirs[dir]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func diffDirs(ctx context.Context, srcPath, dstPath string) (*Diff, error) {
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	if utils.IsSubPath(srcPath, dstPath) || utils.IsSubPath(dstPath, srcPath) {
+		return nil, errors.New("the src and the dst can't contain each other")
+	}
+	srcObj, err := get(ctx, srcPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s]", srcPath)
+	}
+	if !srcObj.IsDir() {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	srcObjs, err := listTree(ctx, srcPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list src [%s]", srcPath)
+	}
+	dstObjs, err := listTree(ctx, dstPath)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list dst [%s]", dstPath)
+	}
+	dstStorage, dstActualPath, _ := op.GetStorageAndActualPath(dstPath)
+	ignore, _ := ctx.Value(conf.CopyIgnoreKey).(string)
+	rules, err := newIgnoreRules(dstStorage, ignore, dstActualPath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Diff{}
+	added := make(map[string]struct{})
+	matched := make(map[string]struct{})
+	deleted := make(map[string]struct{})
+	ignored := make(map[string]struct{})
+	for _, rel := range sortedKeys(srcObjs) {
+		if underAny(rel, added) || underAny(rel, ignored) {
+			continue
+		}
+		src := srcObjs[rel]
+		dstRel := dstRelPath(dstStorage, rel)
+		if rules.ignored(stdpath.Join(dstActualPath, dstRel), src.IsDir()) {
+			if src.IsDir() {
+				ignored[rel] = struct{}{}
+			}
+			res.Ignored = append(res.Ignored, DiffEntry{Path: rel, Size: src.GetSize(), IsDir: src.IsDir()})
+			continue
+		}
+		dst, ok := dstObjs[dstRel]
+		if !ok {
+			if src.IsDir() {
+				added[rel] = struct{}{}
+			}
+			res.Add = append(res.Add, DiffEntry{Path: rel, Size: src.GetSize(), IsDir: src.IsDir(), Reason: DiffReasonMissing})
+			continue
+		}
+		matched[dstRel] = struct{}{}
+		reason := DiffReasonType
+		if src.IsDir() == dst.IsDir() {
+			if src.IsDir() {
+				continue
+			}
+			reason = fileDiffReason(src, dst)
+		}
+		if reason == DiffReasonType {
+			// the whole dir replaces the dst file, or the file replaces the whole dst dir
+			if src.IsDir() {
+				added[rel] = struct{}{}
+			} else {
+				deleted[dstRel] = struct{}{}
+			}
+		}
+		if reason != "" {
+			res.Update = append(res.Update, DiffEntry{Path: rel, Size: src.GetSize(), IsDir: src.IsDir(), Reason: reason})
+		}
+	}
+	for _, rel := range sortedKeys(dstObjs) {
+		if _, ok := matched[rel]; ok || underAny(rel, deleted) {
+			continue
+		}
+		dst := dstObjs[rel]
+		if rules.ignored(stdpath.Join(dstActualPath, rel), dst.IsDir()) {
+			continue
+		}
+		if dst.IsDir() {
+			deleted[rel] = struct{}{}
+		}
+		res.Delete = append(res.Delete, DiffEntry{Path: rel, Size: dst.GetSize(), IsDir: dst.IsDir(), Reason: DiffReasonExtra})
+	}
+	return res, nil
+}
+
+// syncDirs copies the added and updated objs from the src to the dst by the copy tasks,
+// and removes the objs only in the dst if withDelete
+func syncDirs(ctx context.Context, srcPath, dstPath string, withDelete bool) (*SyncResult, error) {
+	d, err := diffDirs(ctx, srcPath, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	res := &SyncResult{Diff: *d}
+	if len(d.Add)+len(d.Update) > 0 {
+		if err := makeDir(ctx, dstPath); err != nil {
+			return nil, errors.WithMessagef(err, "failed make dst dir [%s]", dstPath)
+		}
+	}
+	dstStorage, dstActualPath, _ := op.GetStorageAndActualPath(dstPath)
+	// the ignore patterns of the job are relative to the dst dir of the sync, not of each copy
+	ctx = context.WithValue(ctx, conf.CopyIgnoreRootKey, dstActualPath)
+	copyEntry := func(e DiffEntry) error {
+		t, err := _copy(ctx, stdpath.Join(srcPath, e.Path), stdpath.Join(dstPath, dstRelPath(dstStorage, stdpath.Dir(e.Path))))
+		if err != nil {
+			return errors.WithMessagef(err, "failed copy [%s]", e.Path)
+		}
+		if t != nil {
+			res.Tasks = append(res.Tasks, t)
+		}
+		return nil
+	}
+	for _, e := range d.Add {
+		if err := copyEntry(e); err != nil {
+			return res, err
+		}
+	}
+	for _, e := range d.Update {
+		if e.Reason == DiffReasonType {
+			// a file and a dir can't overwrite each other
+			if err := remove(ctx, stdpath.Join(dstPath, dstRelPath(dstStorage, e.Path)), false); err != nil {
+				return res, errors.WithMessagef(err, "failed remove [%s]", e.Path)
+			}
+		}
+		if err := copyEntry(e); err != nil {
+			return res, err
+		}
+	}
+	if !withDelete {
+		return res, nil
+	}
+	for _, e := range d.Delete {
+		if err := remove(ctx, stdpath.Join(dstPath, e.Path), false); err != nil {
+			return res, errors.WithMessagef(err, "failed remove [%s]", e.Path)
+		}
+		res.Deleted++
+	}
+	return res, nil
+}
+
+func sortedKeys(objs map[string]model.Obj) []string {
+	keys := make([]string, 0, len(objs))
+	for k := range objs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,207 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/net"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ThumbWidths are the widths a thumbnail can be requested in, so that a signed
+// thumbnail link can't be used to generate unlimited variants
+var ThumbWidths = []int{140, 360, 850, 1280}
+
+const (
+	DefaultThumbWidth  = 360
+	maxThumbSourceSize = 50 * utils.MB
+	// a small compressed image can still decode to gigabytes, so the dimensions are checked before decoding
+	maxThumbSourcePixels = 50 * 1000 * 1000
+	// the least recently used thumbnails are removed once the cache grows beyond it
+	maxThumbCacheSize = 256 * utils.MB
+)
+
+var (
+	thumbG       singleflight.Group[string]
+	thumbEvictMu sync.Mutex
+)
+
+// thumbDir is in the data dir rather than the temp dir, which is cleaned on startup
+func thumbDir() string {
+	return filepath.Join(flags.DataDir, "thumb")
+}
+
+// Thumb returns the local path of the thumbnail of path in width. It's resized from the
+// thumbnail provided by the storage if any, otherwise from the image itself, and cached
+// in the data dir by path, width and modified time
+func Thumb(ctx context.Context, path string, width int) (string, error) {
+	res, err := thumb(ctx, path, width)
+	if err != nil {
+		log.Errorf("failed get thumbnail of %s: %+v", path, err)
+		return "", err
+	}
+	return res, nil
+}
+
+func thumb(ctx context.Context, path string, width int) (string, error) {
+	if !slices.Contains(ThumbWidths, width) {
+		return "", errors.Errorf("invalid thumbnail width %d, must be one of %v", width, ThumbWidths)
+	}
+	obj, err := Get(ctx, path, &GetArgs{NoLog: true})
+	if err != nil {
+		return "", err
+	}
+	if obj.IsDir() {
+		return "", errors.WithStack(errs.NotFile)
+	}
+	key := utils.GetMD5EncodeStr(fmt.Sprintf("%s:%d:%d", path, width, obj.ModTime().Unix()))
+	thumbPath := filepath.Join(thumbDir(), key+".jpg")
+	if utils.Exists(thumbPath) {
+		// the modified time is the last use for the eviction
+		now := time.Now()
+		_ = os.Chtimes(thumbPath, now, now)
+		return thumbPath, nil
+	}
+	res, err, _ := thumbG.Do(key, func() (string, error) {
+		if err := makeThumb(ctx, path, obj, width, thumbPath); err != nil {
+			return "", err
+		}
+		go evictThumbs()
+		return thumbPath, nil
+	})
+	return res, err
+}
+
+// evictThumbs removes the least recently used thumbnails until the cache is within maxThumbCacheSize
+func evictThumbs() {
+	if !thumbEvictMu.TryLock() {
+		// another eviction is running
+		return
+	}
+	defer thumbEvictMu.Unlock()
+	entries, err := os.ReadDir(thumbDir())
+	if err != nil {
+		log.Warnf("failed read the thumbnail cache: %v", err)
+		return
+	}
+	var total int64
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), "tmp_") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		infos = append(infos, info)
+	}
+	if total <= maxThumbCacheSize {
+		return
+	}
+	slices.SortFunc(infos, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range infos {
+		if total <= maxThumbCacheSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(thumbDir(), info.Name())); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed remove the thumbnail %s: %v", info.Name(), err)
+			continue
+		}
+		total -= info.Size()
+	}
+}
+
+func makeThumb(ctx context.Context, path string, obj model.Obj, width int, thumbPath string) error {
+	rc, err := thumbSource(ctx, path, obj)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxThumbSourceSize))
+	if err != nil {
+		return errors.WithMessage(err, "failed read image")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return errors.WithMessage(err, "failed decode image")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxThumbSourcePixels {
+		return errors.Errorf("the image is %dx%d, must have at most %d pixels", cfg.Width, cfg.Height, maxThumbSourcePixels)
+	}
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return errors.WithMessage(err, "failed decode image")
+	}
+	if img.Bounds().Dx() > width {
+		img = imaging.Resize(img, width, 0, imaging.Lanczos)
+	}
+	if err = os.MkdirAll(filepath.Dir(thumbPath), 0777); err != nil {
+		return errors.WithStack(err)
+	}
+	// write to a temp file first, so that a thumbnail being written is never served
+	f, err := os.CreateTemp(filepath.Dir(thumbPath), "tmp_*.jpg")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(f.Name())
+	err = imaging.Encode(f, img, imaging.JPEG)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed encode thumbnail")
+	}
+	return errors.WithStack(os.Rename(f.Name(), thumbPath))
+}
+
+// thumbSource opens the thumbnail provided by the storage, which is smaller to download,
+// or the image itself if there is none
+func thumbSource(ctx context.Context, path string, obj model.Obj) (io.ReadCloser, error) {
+	if thumb, ok := model.GetThumb(obj); ok && strings.HasPrefix(thumb, "http") {
+		res, err := net.RequestHttp(ctx, http.MethodGet, http.Header{}, thumb)
+		if err == nil {
+			return res.Body, nil
+		}
+		log.Debugf("failed get the thumbnail of %s provided by the storage, use the image: %v", path, err)
+	}
+	if utils.GetFileType(obj.GetName()) != conf.IMAGE {
+		return nil, errors.Errorf("%s is not an image and has no thumbnail", path)
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get stream")
+	}
+	return ss, nil
+}
@@ -0,0 +1,140 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxTruncateGrowth caps the zeros appended by truncate to a storage without max_upload_size,
+// since they are written to a temp file first
+const maxTruncateGrowth = utils.GB
+
+// touch creates an empty file at path, or sets the mtime of the existing one. Only the drivers
+// implementing driver.Toucher can set the mtime, the existing file is left as is by the others
+func touch(ctx context.Context, path string, mtime time.Time) error {
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err == nil {
+		if obj.IsDir() {
+			return errors.WithStack(errs.NotFile)
+		}
+		err = op.Touch(ctx, storage, actualPath, mtime)
+		if errs.IsNotImplement(err) {
+			log.Debugf("storage [%s] can't set the mtime, touch of [%s] does nothing", storage.GetStorage().MountPath, path)
+			return nil
+		}
+		return err
+	}
+	if !errs.IsObjectNotFound(err) {
+		return err
+	}
+	return putDirectly(ctx, stdpath.Dir(path), &stream.FileStream{
+		Obj: &model.Object{
+			Name:     stdpath.Base(path),
+			Modified: mtime,
+			Ctime:    mtime,
+		},
+		Reader:   bytes.NewReader(nil),
+		Mimetype: utils.GetMimeType(path),
+	})
+}
+
+// truncate changes the size of the file at path, the extended part reads as zeros. The drivers not implementing
+// driver.Truncater upload the file again, the kept part is downloaded first. errs.TruncateNotSupported is returned
+// if the storage can do neither
+func truncate(ctx context.Context, path string, size int64) error {
+	if size < 0 {
+		return errors.Errorf("invalid size: %d", size)
+	}
+	path = utils.FixAndCleanPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		return err
+	}
+	if obj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	if obj.GetSize() == size {
+		return nil
+	}
+	err = op.Truncate(ctx, storage, actualPath, size)
+	if !errs.IsNotImplement(err) {
+		return err
+	}
+	if storage.Config().NoUpload {
+		return errors.WithMessagef(errs.TruncateNotSupported, "storage [%s] can neither truncate nor upload", storage.GetStorage().MountPath)
+	}
+	fs := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     obj.GetName(),
+			Size:     size,
+			Modified: time.Now(),
+			Ctime:    obj.CreateTime(),
+		},
+		Mimetype: utils.GetMimeType(obj.GetName()),
+	}
+	// checked before the temp file is written, op.Put checks them again
+	if err = op.LimitUploadSize(storage, fs); err != nil {
+		return err
+	}
+	if storage.GetStorage().MaxUploadSize <= 0 && size-obj.GetSize() > maxTruncateGrowth {
+		return errors.WithMessagef(errs.UploadTooLarge, "truncate can grow a file by %d bytes at most", int64(maxTruncateGrowth))
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	if err = op.CheckUploadQuota(user, size); err != nil {
+		return err
+	}
+	var r io.Reader = bytes.NewReader(nil)
+	if kept := min(size, obj.GetSize()); kept > 0 {
+		link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{Header: http.Header{}})
+		if err != nil {
+			return errors.WithMessage(err, "failed get link")
+		}
+		ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+		if err != nil {
+			return errors.WithMessage(err, "failed get stream")
+		}
+		defer ss.Close()
+		if r, err = ss.RangeRead(http_range.Range{Start: 0, Length: kept}); err != nil {
+			return errors.WithMessage(err, "failed read the kept part")
+		}
+	}
+	if size > obj.GetSize() {
+		r = io.MultiReader(r, io.LimitReader(zeroReader{}, size-obj.GetSize()))
+	}
+	// the existing file may be removed before the upload, so the content is kept in a temp file first
+	tmpF, err := utils.CreateTempFile(r, size)
+	if err != nil {
+		return err
+	}
+	fs.SetTmpFile(tmpF)
+	return putDirectly(ctx, stdpath.Dir(path), fs)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,118 @@
+package fs
+
+import (
+	"context"
+	"net/http"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// VerifyHash compare the hash of src with the hash returned by the dst driver
+	VerifyHash = "hash"
+	// VerifyDownload same as VerifyHash, but re-download the dst file if the dst driver doesn't return a hash
+	VerifyDownload = "download"
+)
+
+func IsValidVerify(verify string) bool {
+	return verify == "" || verify == VerifyHash || verify == VerifyDownload
+}
+
+var verifyHashTypes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// srcHashForVerify get the hash of the src file, if the src driver doesn't provide one,
+// the stream is cached in a temp file to compute it, so it must be called before uploading
+func srcHashForVerify(ss *stream.SeekableStream) (utils.HashInfo, error) {
+	if h := stream.KnownHashes(ss); len(h) > 0 {
+		return utils.NewHashInfoByMap(h), nil
+	}
+	// the hashes are set to the stream, so the dst driver gets the md5 for the rapid upload without another pass
+	_, h, err := stream.CacheFullInTempFileAndHashes(ss, verifyHashTypes)
+	if err != nil {
+		return utils.HashInfo{}, errors.WithMessage(err, "failed cache and hash src file")
+	}
+	return h, nil
+}
+
+// getUploaded get the uploaded obj, the list cache may be kept lazily during the copy task
+func getUploaded(ctx context.Context, storage driver.Driver, dstDirPath, name string) (model.Obj, error) {
+	if _, ok := storage.(driver.Getter); ok {
+		return op.GetUnwrap(ctx, storage, stdpath.Join(dstDirPath, name))
+	}
+	objs, err := op.List(ctx, storage, dstDirPath, model.ListArgs{Refresh: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if obj.GetName() == name {
+			return model.UnwrapObj(obj), nil
+		}
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
+func verifyUploaded(ctx context.Context, verify string, srcHash utils.HashInfo, srcMtime time.Time, dstStorage driver.Driver, dstDirPath, name string) error {
+	dstObj, err := getUploaded(ctx, dstStorage, dstDirPath, name)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get uploaded [%s]", name)
+	}
+	if s, ok := dstStorage.(driver.MtimeSetter); ok && s.SetsMtime() && !srcMtime.IsZero() &&
+		dstObj.ModTime().Unix() != srcMtime.Unix() {
+		log.Warnf("the mtime of [%s] isn't kept, src: %s, dst: %s", name, srcMtime, dstObj.ModTime())
+	}
+	for ht, v := range dstObj.GetHash().All() {
+		want := srcHash.GetHash(ht)
+		if want == "" || len(v) != ht.Width {
+			continue
+		}
+		if !strings.EqualFold(want, v) {
+			return errors.Errorf("%s mismatch of [%s], src: %s, dst: %s", ht.Name, name, want, v)
+		}
+		return nil
+	}
+	if verify != VerifyDownload {
+		log.Warnf("skip verify [%s]: the dst driver doesn't return a comparable hash", name)
+		return nil
+	}
+	var ht *utils.HashType
+	for t := range srcHash.All() {
+		ht = t
+		break
+	}
+	if ht == nil {
+		return errors.Errorf("failed verify [%s]: no hash of src", name)
+	}
+	dstPath := stdpath.Join(dstDirPath, name)
+	link, _, err := op.Link(ctx, dstStorage, dstPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", dstPath)
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Obj: dstObj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] stream", dstPath)
+	}
+	defer ss.Close()
+	got, err := utils.HashReader(ht, ss)
+	if err != nil {
+		return errors.WithMessagef(err, "failed hash [%s]", dstPath)
+	}
+	if !strings.EqualFold(got, srcHash.GetHash(ht)) {
+		return errors.Errorf("%s mismatch of [%s], src: %s, dst: %s", ht.Name, name, srcHash.GetHash(ht), got)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/op"
 )
 
+const walkPageSize = 1000
+
 // WalkFS traverses filesystem fs starting at name up to depth levels.
 //
 // WalkFS will stop when current depth > `depth`. For each visited node,
@@ -27,19 +29,26 @@ func WalkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 		return nil
 	}
 	meta, _ := op.GetNearestMeta(name)
-	// Read directory names.
-	objs, err := List(context.WithValue(ctx, "meta", meta), name, &ListArgs{})
-	if err != nil {
-		return walkFnErr
-	}
-	for _, fileInfo := range objs {
-		filename := path.Join(name, fileInfo.GetName())
-		if err := WalkFS(ctx, depth-1, filename, fileInfo, walkFn); err != nil {
-			if err == filepath.SkipDir {
-				break
+	listCtx := context.WithValue(ctx, "meta", meta)
+	// Read directory names page by page, so that a huge dir is not listed at once.
+	args := &ListPageArgs{Limit: walkPageSize}
+	for {
+		objs, next, err := ListPage(listCtx, name, args)
+		if err != nil {
+			return walkFnErr
+		}
+		for _, fileInfo := range objs {
+			filename := path.Join(name, fileInfo.GetName())
+			if err := WalkFS(ctx, depth-1, filename, fileInfo, walkFn); err != nil {
+				if err == filepath.SkipDir {
+					return nil
+				}
+				return err
 			}
-			return err
 		}
+		if next == "" {
+			return nil
+		}
+		args.Cursor = next
 	}
-	return nil
 }
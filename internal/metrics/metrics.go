@@ -0,0 +1,167 @@
+// Package metrics collects the driver call counts, latencies, retries, rate limits, transferred bytes
+// and cache hits of every storage, they're exposed in the Prometheus format by Handler
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "alist"
+
+var storageLabels = []string{"driver", "storage"}
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	calls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_calls_total",
+		Help:      "Number of the driver calls made by the op layer.",
+	}, append(storageLabels, "method"))
+	callErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_call_errors_total",
+		Help:      "Number of the failed driver calls, by the reason of the error.",
+	}, append(storageLabels, "method", "reason"))
+	callDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "driver_call_duration_seconds",
+		Help:      "Latency of the driver calls.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, append(storageLabels, "method"))
+	retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_retries_total",
+		Help:      "Number of the requests retried by the drivers.",
+	}, storageLabels)
+	uploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes of the files uploaded successfully.",
+	}, storageLabels)
+	downloadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "download_bytes_total",
+		Help:      "Bytes sent to the clients by the local proxy.",
+	}, storageLabels)
+	rateLimitHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_rate_limit_hits_total",
+		Help:      "Number of the requests rejected by the servers for their rate limits.",
+	}, storageLabels)
+	adaptiveRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "driver_adaptive_rate",
+		Help:      "Requests per second currently allowed by the adaptive rate limit of the drivers, 0 for unlimited.",
+	}, storageLabels)
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Lookups of the list and link caches, by the result hit or miss.",
+	}, append(storageLabels, "cache", "result"))
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		calls, callErrors, callDuration, retries, uploadBytes, downloadBytes, cacheRequests,
+		rateLimitHits, adaptiveRate,
+	)
+}
+
+func labels(storage driver.Driver) (string, string) {
+	return storage.Config().Name, strconv.FormatUint(uint64(storage.GetStorage().ID), 10)
+}
+
+// Observe starts to measure a driver call, the returned func must be called with the result of the call
+func Observe(storage driver.Driver, method string) func(err error) {
+	start := time.Now()
+	d, s := labels(storage)
+	return func(err error) {
+		calls.WithLabelValues(d, s, method).Inc()
+		callDuration.WithLabelValues(d, s, method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			callErrors.WithLabelValues(d, s, method, reason(err)).Inc()
+		}
+	}
+}
+
+// reason classifies the error with a few values to keep the cardinality low
+func reason(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errs.IsObjectNotFound(err):
+		return "not_found"
+	case errors.Is(err, errs.RateLimited):
+		return "rate_limited"
+	case errors.Is(err, errs.Unauthorized):
+		return "unauthorized"
+	case errors.Is(err, errs.QuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, errs.NotImplement) || errors.Is(err, errs.NotSupport):
+		return "not_support"
+	default:
+		return "other"
+	}
+}
+
+// IncRetry counts a request retried by the driver of storage
+func IncRetry(storage driver.Driver) {
+	d, s := labels(storage)
+	retries.WithLabelValues(d, s).Inc()
+}
+
+// IncRateLimited counts a request of the driver of storage rejected for the rate limit of the server
+func IncRateLimited(storage driver.Driver) {
+	d, s := labels(storage)
+	rateLimitHits.WithLabelValues(d, s).Inc()
+}
+
+// SetAdaptiveRate sets the requests/sec the driver of storage currently allows itself, 0 for unlimited
+func SetAdaptiveRate(storage driver.Driver, r float64) {
+	d, s := labels(storage)
+	adaptiveRate.WithLabelValues(d, s).Set(r)
+}
+
+func AddUploadBytes(storage driver.Driver, n int64) {
+	if n <= 0 {
+		return
+	}
+	d, s := labels(storage)
+	uploadBytes.WithLabelValues(d, s).Add(float64(n))
+}
+
+func AddDownloadBytes(storage driver.Driver, n int64) {
+	if n <= 0 {
+		return
+	}
+	d, s := labels(storage)
+	downloadBytes.WithLabelValues(d, s).Add(float64(n))
+}
+
+// CacheLookup counts a lookup of the cache, e.g. list or link
+func CacheLookup(storage driver.Driver, cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	d, s := labels(storage)
+	cacheRequests.WithLabelValues(d, s, cache, result).Inc()
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
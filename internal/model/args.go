@@ -8,12 +8,24 @@ import (
 
 	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"golang.org/x/time/rate"
 )
 
 type ListArgs struct {
 	ReqPath           string
 	S3ShowPlaceholder bool
 	Refresh           bool
+	// ModifiedSince only the files modified at or after it are listed if not zero, dirs are always listed
+	ModifiedSince time.Time
+	// NameFilter only the files and dirs whose names match are listed if set
+	NameFilter *NameFilter
+}
+
+type ListPageArgs struct {
+	ReqPath string
+	Cursor  string // empty for the first page
+	Limit   int
+	Refresh bool
 }
 
 type LinkArgs struct {
@@ -36,6 +48,54 @@ type Link struct {
 	//for accelerating request, use multi-thread downloading
 	Concurrency int `json:"concurrency"`
 	PartSize    int `json:"part_size"`
+
+	// Mode tells how the link is served if the driver has several ways, e.g. "range" or "m3u8"
+	Mode string `json:"mode,omitempty"`
+
+	// MustProxy tells that URL carries a credential of the storage, e.g. its access token, so the link is
+	// requested by alist and never given to the clients, even if the storage redirects the downloads
+	MustProxy bool `json:"-"`
+
+	// DownloadLimiter limits the speed of the storage when the link is proxied, shared by all its links
+	DownloadLimiter *rate.Limiter `json:"-"`
+
+	// Candidates are the other URLs of the file in priority order, tried in turn when a request to URL fails
+	Candidates []LinkCandidate `json:"candidates,omitempty"`
+	noRange    bool            // of a candidate which doesn't support range
+}
+
+// LinkCandidate is another URL of the file of a Link
+type LinkCandidate struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	// SupportRange tells whether the URL honors the Range header, only the whole file is requested from it if not
+	SupportRange bool `json:"support_range"`
+	// NeedUA tells that the User-Agent in Header must be sent, so the client can't be redirected to the URL
+	NeedUA bool `json:"need_ua"`
+	// Speed is the expected bytes/sec of the URL, 0 if unknown
+	Speed int64 `json:"speed,omitempty"`
+}
+
+// Links returns the link itself and the links of its candidates in priority order. A candidate link
+// keeps the concurrency of the link only if it supports range
+func (l *Link) Links() []*Link {
+	links := []*Link{l}
+	for _, c := range l.Candidates {
+		cl := *l
+		cl.URL, cl.Header, cl.Candidates = c.URL, c.Header, nil
+		cl.RangeReadCloser, cl.MFile = nil, nil
+		if !c.SupportRange {
+			cl.Concurrency, cl.PartSize, cl.noRange = 0, 0, true
+		}
+		links = append(links, &cl)
+	}
+	return links
+}
+
+// CanRange tells whether the link can serve r of the file of size, false if it is of a candidate which doesn't
+// support range and r isn't the whole file
+func (l *Link) CanRange(r http_range.Range, size int64) bool {
+	return !l.noRange || r.Start == 0 && (r.Length < 0 || r.Length == size)
 }
 
 type OtherArgs struct {
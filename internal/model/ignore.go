@@ -0,0 +1,117 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IgnorePatterns matches the paths to skip by gitignore-style patterns, one per line. Empty lines and the lines
+// starting with # are ignored, a leading ! re-includes the paths matched by the previous patterns, and the last
+// matching pattern wins. A trailing / matches only dirs. A pattern with a / elsewhere is matched against the whole
+// path relative to the root, otherwise against the name at any level. Each element is the glob of NameFilter,
+// and ** matches any number of elements, e.g. "**/cache/*.tmp". Everything under a matched dir is matched too
+type IgnorePatterns struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	elems    []*NameFilter // nil for **
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func NewIgnorePatterns(text string) (*IgnorePatterns, error) {
+	p := &IgnorePatterns{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		pattern := line
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		r.anchored = strings.Contains(line, "/")
+		line = strings.TrimLeft(line, "/")
+		if line == "" {
+			return nil, errors.Errorf("invalid ignore pattern %q", pattern)
+		}
+		for _, elem := range strings.Split(line, "/") {
+			if elem == "**" {
+				r.elems = append(r.elems, nil)
+				continue
+			}
+			f, err := NewNameFilter(elem, false)
+			if err != nil {
+				return nil, errors.WithMessage(err, "invalid ignore pattern")
+			}
+			r.elems = append(r.elems, f)
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// Empty tells whether nothing is ignored
+func (p *IgnorePatterns) Empty() bool {
+	return p == nil || len(p.rules) == 0
+}
+
+// Match tells whether the obj at rel, a slash separated path relative to the root, is ignored by itself
+// or by one of its parent dirs
+func (p *IgnorePatterns) Match(rel string, isDir bool) bool {
+	if p.Empty() {
+		return false
+	}
+	elems := strings.Split(strings.Trim(rel, "/"), "/")
+	for i := range elems {
+		if p.matchElems(elems[:i+1], isDir || i < len(elems)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *IgnorePatterns) matchElems(elems []string, isDir bool) bool {
+	ignored := false
+	for _, r := range p.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var ok bool
+		if r.anchored {
+			ok = matchIgnoreElems(r.elems, elems)
+		} else {
+			ok = matchIgnoreElems(r.elems, elems[len(elems)-1:])
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func matchIgnoreElems(pattern []*NameFilter, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == nil {
+		for i := 0; i <= len(elems); i++ {
+			if matchIgnoreElems(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(elems) > 0 && pattern[0].Match(elems[0]) && matchIgnoreElems(pattern[1:], elems[1:])
+}
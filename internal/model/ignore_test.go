@@ -0,0 +1,56 @@
+package model
+
+import "testing"
+
+func TestIgnorePatterns(t *testing.T) {
+	patterns := `# comment
+*.tmp
+!keep.tmp
+node_modules/
+/build
+docs/*.pdf
+**/cache/*.bin
+\#hash`
+	p, err := NewIgnorePatterns(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.tmp", false, true},
+		{"x/y/A.TMP", false, true},
+		{"keep.tmp", false, false},
+		{"x/keep.tmp", false, false},
+		{"node_modules", true, true},
+		{"node_modules", false, false},
+		{"src/node_modules/pkg/index.js", false, true},
+		{"build", true, true},
+		{"build/out.js", false, true},
+		{"src/build", true, false},
+		{"docs/a.pdf", false, true},
+		{"docs/sub/a.pdf", false, false},
+		{"cache/a.bin", false, true},
+		{"x/y/cache/a.bin", false, true},
+		{"x/cache/a.txt", false, false},
+		{"#hash", false, true},
+		{"# comment", false, false},
+		{"/readme.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := p.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, expect %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+	var empty *IgnorePatterns
+	if !empty.Empty() || empty.Match("a.tmp", false) {
+		t.Error("a nil IgnorePatterns should ignore nothing")
+	}
+	for _, invalid := range []string{"/", "!", "a/[b"} {
+		if _, err := NewIgnorePatterns(invalid); err == nil {
+			t.Errorf("expect %q to be invalid", invalid)
+		}
+	}
+}
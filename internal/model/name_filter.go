@@ -0,0 +1,118 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NameFilter matches the names of objs by a glob, or by a regular expression if it's opt-in.
+//
+// The glob is matched against the whole name case-insensitively, "*" matches any characters, "?" one character,
+// "[abc]" one of the characters with ranges like "[a-z]" and negation like "[!abc]" or "[^abc]", and "\x" the
+// character x literally, e.g. "\*" or "\[".
+//
+// The regular expression is RE2 syntax matched anywhere in the name and is case-sensitive unless it
+// starts with (?i), anchor it with ^ and $ to match the whole name
+type NameFilter struct {
+	re      *regexp.Regexp
+	keyword string
+}
+
+func NewNameFilter(pattern string, regex bool) (*NameFilter, error) {
+	if !regex {
+		expr, keyword, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &NameFilter{re: regexp.MustCompile(expr), keyword: keyword}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid name regex")
+	}
+	prefix, _ := re.LiteralPrefix()
+	return &NameFilter{re: re, keyword: prefix}, nil
+}
+
+func (f *NameFilter) Match(name string) bool {
+	return f.re.MatchString(name)
+}
+
+// Keyword returns a literal contained in every matched name, case-insensitively for a glob,
+// so that a search by it returns all the matched objs. It's empty if there is none
+func (f *NameFilter) Keyword() string {
+	return f.keyword
+}
+
+func FilterByName(objs []Obj, f *NameFilter) []Obj {
+	res := make([]Obj, 0, len(objs))
+	for _, obj := range objs {
+		if f.Match(obj.GetName()) {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
+// globToRegexp converts the glob to an anchored case-insensitive regular expression,
+// keyword is the longest literal between the wildcards
+func globToRegexp(glob string) (expr, keyword string, err error) {
+	var b, lit strings.Builder
+	b.WriteString("(?is)^")
+	flush := func() {
+		if lit.Len() > len(keyword) {
+			keyword = lit.String()
+		}
+		lit.Reset()
+	}
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			flush()
+			b.WriteString(".*")
+		case '?':
+			flush()
+			b.WriteString(".")
+		case '[':
+			flush()
+			j := i + 1
+			if j < len(runes) && (runes[j] == '!' || runes[j] == '^') {
+				j++
+			}
+			if j < len(runes) && runes[j] == ']' {
+				j++
+			}
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+			if j >= len(runes) {
+				return "", "", errors.Errorf("invalid name glob %q: unclosed [", glob)
+			}
+			class := string(runes[i+1 : j])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = j
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				c = runes[i]
+			}
+			lit.WriteRune(c)
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			lit.WriteRune(c)
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	flush()
+	b.WriteString("$")
+	if _, err := regexp.Compile(b.String()); err != nil {
+		return "", "", errors.Wrapf(err, "invalid name glob %q", glob)
+	}
+	return b.String(), keyword, nil
+}
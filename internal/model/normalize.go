@@ -0,0 +1,37 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// the unicode normalization forms of the names of a storage, see Storage.NameNormalization
+const (
+	NameNormNone = ""
+	NameNormNFC  = "nfc"
+	NameNormNFD  = "nfd"
+)
+
+// CheckNameNormalization checks the normalization form of a storage
+func CheckNameNormalization(form string) error {
+	switch strings.ToLower(form) {
+	case NameNormNone, NameNormNFC, NameNormNFD:
+		return nil
+	default:
+		return errors.Errorf("invalid name normalization %q, must be one of nfc, nfd or empty", form)
+	}
+}
+
+// NormalizeName converts name, or a slash separated path, to the normalization form, it's unchanged if form is none
+func NormalizeName(form, name string) string {
+	switch strings.ToLower(form) {
+	case NameNormNFC:
+		return norm.NFC.String(name)
+	case NameNormNFD:
+		return norm.NFD.String(name)
+	default:
+		return name
+	}
+}
@@ -90,11 +90,19 @@ func SortFiles(objs []Obj, orderBy, orderDirection string) {
 	if orderBy == "" {
 		return
 	}
+	less := ObjLess(orderBy, orderDirection)
 	sort.Slice(objs, func(i, j int) bool {
+		return less(objs[i], objs[j])
+	})
+}
+
+// ObjLess returns the comparison used by SortFiles
+func ObjLess(orderBy, orderDirection string) func(a, b Obj) bool {
+	return func(a, b Obj) bool {
 		switch orderBy {
 		case "name":
 			{
-				c := natural.Less(objs[i].GetName(), objs[j].GetName())
+				c := natural.Less(a.GetName(), b.GetName())
 				if orderDirection == "desc" {
 					return !c
 				}
@@ -103,18 +111,18 @@ func SortFiles(objs []Obj, orderBy, orderDirection string) {
 		case "size":
 			{
 				if orderDirection == "desc" {
-					return objs[i].GetSize() >= objs[j].GetSize()
+					return a.GetSize() >= b.GetSize()
 				}
-				return objs[i].GetSize() <= objs[j].GetSize()
+				return a.GetSize() <= b.GetSize()
 			}
 		case "modified":
 			if orderDirection == "desc" {
-				return objs[i].ModTime().After(objs[j].ModTime())
+				return a.ModTime().After(b.ModTime())
 			}
-			return objs[i].ModTime().Before(objs[j].ModTime())
+			return a.ModTime().Before(b.ModTime())
 		}
 		return false
-	})
+	}
 }
 
 func ExtractFolder(objs []Obj, extractFolder string) {
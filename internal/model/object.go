@@ -16,6 +16,12 @@ type ObjWrapStorageClass struct {
 	Obj
 }
 
+// ObjWrapHash replaces the hashes of Obj, e.g. with the ones computed by alist besides the native ones
+type ObjWrapHash struct {
+	Hash utils.HashInfo
+	Obj
+}
+
 func (o *ObjWrapName) Unwrap() Obj {
 	return o.Obj
 }
@@ -24,6 +30,14 @@ func (o *ObjWrapName) GetName() string {
 	return o.Name
 }
 
+func (o *ObjWrapHash) Unwrap() Obj {
+	return o.Obj
+}
+
+func (o *ObjWrapHash) GetHash() utils.HashInfo {
+	return o.Hash
+}
+
 func (o *ObjWrapStorageClass) Unwrap() Obj {
 	return o.Obj
 }
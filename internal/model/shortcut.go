@@ -0,0 +1,8 @@
+package model
+
+// Shortcut shows Target at Path like a junction, Target can be in any storage
+type Shortcut struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Path   string `json:"path" gorm:"unique" binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
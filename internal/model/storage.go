@@ -17,6 +17,16 @@ type Storage struct {
 	Disabled        bool      `json:"disabled"` // if disabled
 	DisableIndex    bool      `json:"disable_index"`
 	EnableSign      bool      `json:"enable_sign"`
+	ReadOnly        bool      `json:"read_only"`        // refuse all writes, whichever front-end they come from
+	MaxConcurrency  int       `json:"max_concurrency"`  // max concurrent driver calls, 0 means unlimited
+	MaxListEntries  int       `json:"max_list_entries"` // max objs of a dir listed at once, the rest are paged, 0 means the default, negative means unlimited
+	RequestTimeout  int       `json:"request_timeout"`  // seconds of a driver call except the uploads, 0 means the global request_timeout
+	MaxUploadSize   int       `json:"max_upload_size"`  // MB of a file uploaded to the storage at most, 0 means unlimited
+	// the objs skipped by the uploads and copies to the storage, see IgnorePatterns
+	IgnorePatterns string `json:"ignore_patterns" gorm:"type:text"`
+	// the unicode form the listed names and the names of the requests are converted to, one of NameNormNFC,
+	// NameNormNFD, or empty to keep them as they are, so that a name matches whichever form the client sends
+	NameNormalization string `json:"name_normalization"`
 	Sort
 	Proxy
 }
@@ -25,6 +35,7 @@ type Sort struct {
 	OrderBy        string `json:"order_by"`
 	OrderDirection string `json:"order_direction"`
 	ExtractFolder  string `json:"extract_folder"`
+	NaturalSort    bool   `json:"natural_sort"` // sort the names naturally after listing, 2 before 10
 }
 
 type Proxy struct {
@@ -35,6 +46,15 @@ type Proxy struct {
 	DownProxySign bool   `json:"down_proxy_sign" gorm:"default:true"`
 }
 
+type StorageDetails struct {
+	TotalSpace int64 `json:"total_space"`
+	UsedSpace  int64 `json:"used_space"`
+}
+
+func (d StorageDetails) FreeSpace() int64 {
+	return d.TotalSpace - d.UsedSpace
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
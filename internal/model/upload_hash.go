@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// UploadHash is the hash of a src file computed by a driver for the upload, e.g. the block list of baidu_netdisk,
+// kept to skip hashing the same src again. It is valid while the size and the mtime of the src are unchanged
+type UploadHash struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Src      string    `json:"src" gorm:"size:64;uniqueIndex"` // sha1 of the scope of the driver and the src
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Hash     string    `json:"hash" gorm:"type:text"` // defined by the driver
+	Updated  time.Time `json:"updated" gorm:"index"`
+}
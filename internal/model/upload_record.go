@@ -0,0 +1,15 @@
+package model
+
+// UploadRecord is a file uploaded by a user, the sizes of the records of a user count against the upload quota
+type UploadRecord struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Path   string `json:"path" gorm:"type:text"`
+	Size   int64  `json:"size"`
+}
+
+type UploadUsage struct {
+	Quota int64 `json:"quota"` // 0 for unlimited
+	Used  int64 `json:"used"`
+	Files int64 `json:"files"`
+}
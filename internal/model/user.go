@@ -53,6 +53,8 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// bytes the user can upload in total, 0 for unlimited
+	UploadQuota int64 `json:"upload_quota"`
 }
 
 func (u *User) IsGuest() bool {
@@ -0,0 +1,234 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ReassembleMemory reassembles the chunks in Concurrency bufs of PartSize, the workers wait for the reader
+	ReassembleMemory = "memory"
+	// ReassembleFile reassembles the chunks in a temp file, the workers download ahead of the reader
+	ReassembleFile = "file"
+	// ReassembleAuto uses the temp file for the ranges of at least DownloadReassemble.FileThreshold MB
+	ReassembleAuto = "auto"
+)
+
+// reassembleInFile tells whether the chunks of a range of length are reassembled in a temp file
+func reassembleInFile(length int64) bool {
+	if conf.Conf == nil {
+		return false
+	}
+	c := conf.Conf.DownloadReassemble
+	switch c.Mode {
+	case ReassembleFile:
+		return true
+	case ReassembleMemory:
+		return false
+	default:
+		return c.FileThreshold > 0 && length >= c.FileThreshold*utils.MB
+	}
+}
+
+// fileReassembler is the reader of the chunks downloaded into a temp file in any order. filled[i] is how many
+// bytes of chunk i are written, the reader waits until the bytes it reaches are written
+type fileReassembler struct {
+	d      *downloader
+	file   *os.File
+	mu     sync.Mutex
+	cond   *sync.Cond
+	filled []int64
+	pos    int64 // read position relative to the range start
+	wg     sync.WaitGroup
+}
+
+// downloadToFile downloads the chunks by d.cfg.Concurrency workers into a temp file in the stream temp dir,
+// the file is removed when the reader is closed or the ctx is canceled
+func (d *downloader) downloadToFile() (io.ReadCloser, error) {
+	file, err := os.CreateTemp(conf.Conf.StreamTempDir, "download-*")
+	if err != nil {
+		d.concurrencyFinish()
+		return nil, err
+	}
+	partSize := int64(d.cfg.PartSize)
+	maxPart := int((d.params.Range.Length + partSize - 1) / partSize)
+	r := &fileReassembler{d: d, file: file, filled: make([]int64, maxPart)}
+	r.cond = sync.NewCond(&r.mu)
+	chunks := make(chan int, maxPart)
+	for i := 0; i < maxPart; i++ {
+		chunks <- i
+	}
+	close(chunks)
+	for i := 0; i < d.cfg.Concurrency; i++ {
+		// the first worker uses the concurrency checked in download
+		if i > 0 && d.concurrencyCheck() != nil {
+			break
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer d.concurrencyFinish()
+			for id := range chunks {
+				if d.getErr() != nil {
+					return
+				}
+				if err := r.downloadChunk(id); err != nil {
+					d.setErr(err)
+					d.cancel(err)
+					return
+				}
+			}
+		}()
+	}
+	// wake up the reader when canceled, and remove the file after the workers stop writing it
+	stop := context.AfterFunc(d.ctx, func() {
+		r.mu.Lock()
+		r.cond.Broadcast()
+		r.mu.Unlock()
+	})
+	go func() {
+		<-d.ctx.Done()
+		stop()
+		r.wg.Wait()
+		_ = file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			log.Warnf("failed remove the download temp file %s: %v", file.Name(), err)
+		}
+	}()
+	return r, nil
+}
+
+func (r *fileReassembler) chunkRange(id int) (start, size int64) {
+	partSize := int64(r.d.cfg.PartSize)
+	start = int64(id) * partSize
+	return start, min(partSize, r.d.params.Range.Length-start)
+}
+
+// downloadChunk downloads chunk id, retrying from the written bytes if the body breaks
+func (r *fileReassembler) downloadChunk(id int) error {
+	start, size := r.chunkRange(id)
+	var err error
+	for retry := 0; retry <= r.d.cfg.PartBodyMaxRetries; retry++ {
+		if retry > 0 {
+			log.Warnf("err chunk_%d, object part download error %s, retrying attempt %d. %v",
+				id, r.d.params.URL, retry, err)
+			select {
+			case <-r.d.ctx.Done():
+				return r.d.ctx.Err()
+			case <-time.After(time.Millisecond * 200):
+			}
+		}
+		r.mu.Lock()
+		written := r.filled[id]
+		r.mu.Unlock()
+		if written == size {
+			return nil
+		}
+		var params HttpRequestParams
+		awsutil.Copy(&params, r.d.params)
+		params.Range = http_range.Range{Start: r.d.params.Range.Start + start + written, Length: size - written}
+		var resp *http.Response
+		resp, err = r.d.cfg.HttpClient(r.d.ctx, &params)
+		if err != nil {
+			if utils.IsCanceled(r.d.ctx) {
+				return r.d.ctx.Err()
+			}
+			if resp == nil || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+				return err
+			}
+			continue
+		}
+		if id == 0 && written == 0 {
+			if err = checkRespSize(resp, r.d.params.Size); err != nil {
+				_ = resp.Body.Close()
+				return err
+			}
+		}
+		_, err = utils.CopyWithBuffer(&chunkWriter{r: r, id: id, off: start + written}, io.LimitReader(resp.Body, size-written))
+		_ = resp.Body.Close()
+		if err == nil {
+			r.mu.Lock()
+			written = r.filled[id]
+			r.mu.Unlock()
+			if written == size {
+				return nil
+			}
+			err = fmt.Errorf("chunk download size incorrect, expected=%d, got=%d", size, written)
+		}
+		if utils.IsCanceled(r.d.ctx) {
+			return r.d.ctx.Err()
+		}
+	}
+	return err
+}
+
+// chunkWriter writes the body of chunk id at off of the temp file and wakes up the reader
+type chunkWriter struct {
+	r   *fileReassembler
+	id  int
+	off int64
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n, err := w.r.file.WriteAt(p, w.off)
+	w.off += int64(n)
+	w.r.mu.Lock()
+	w.r.filled[w.id] += int64(n)
+	w.r.cond.Broadcast()
+	w.r.mu.Unlock()
+	return n, err
+}
+
+func (r *fileReassembler) Read(p []byte) (int, error) {
+	length := r.d.params.Range.Length
+	if r.pos >= length {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	id := int(r.pos / int64(r.d.cfg.PartSize))
+	start, _ := r.chunkRange(id)
+	r.mu.Lock()
+	for r.filled[id] <= r.pos-start {
+		if err := r.d.getErr(); err != nil {
+			r.mu.Unlock()
+			return 0, err
+		}
+		if r.d.ctx.Err() != nil {
+			r.mu.Unlock()
+			return 0, context.Cause(r.d.ctx)
+		}
+		r.cond.Wait()
+	}
+	available := r.filled[id] - (r.pos - start)
+	r.mu.Unlock()
+	n, err := r.file.ReadAt(p[:min(int64(len(p)), available)], r.pos)
+	r.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *fileReassembler) Close() error {
+	if r.pos != r.d.params.Range.Length {
+		log.Debugf("Downloader interrupt before finish")
+		if r.d.getErr() == nil {
+			r.d.setErr(fmt.Errorf("interrupted"))
+		}
+	}
+	r.d.cancel(r.d.getErr())
+	return nil
+}
@@ -180,6 +180,9 @@ func (d *downloader) download() (io.ReadCloser, error) {
 		}
 		return resp.Body, nil
 	}
+	if reassembleInFile(d.params.Range.Length) {
+		return d.downloadToFile()
+	}
 
 	// workers
 	d.chunkChannel = make(chan chunk, d.cfg.Concurrency)
@@ -455,6 +458,17 @@ func (d *downloader) getParamsFromChunk(ch *chunk) *HttpRequestParams {
 }
 
 func (d *downloader) checkTotalBytes(resp *http.Response) error {
+	err := checkRespSize(resp, d.params.Size)
+	if err != nil {
+		// _ = d.interrupt()
+		d.setErr(err)
+		d.cancel(err)
+	}
+	return err
+}
+
+// checkRespSize checks the size of the file reported by the Content-Range or the Content-Length of resp
+func checkRespSize(resp *http.Response, size int64) error {
 	var err error
 	totalBytes := int64(-1)
 	contentRange := resp.Header.Get("Content-Range")
@@ -484,16 +498,10 @@ func (d *downloader) checkTotalBytes(resp *http.Response) error {
 
 		totalBytes = total
 	}
-	if totalBytes != d.params.Size && err == nil {
-		err = fmt.Errorf("expect file size=%d unmatch remote report size=%d, need refresh cache", d.params.Size, totalBytes)
-	}
-	if err != nil {
-		// _ = d.interrupt()
-		d.setErr(err)
-		d.cancel(err)
+	if totalBytes != size && err == nil {
+		err = fmt.Errorf("expect file size=%d unmatch remote report size=%d, need refresh cache", size, totalBytes)
 	}
 	return err
-
 }
 
 func (d *downloader) incrWritten(n int64) {
@@ -12,6 +12,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/slices"
@@ -133,6 +134,42 @@ func TestDownloadSingle(t *testing.T) {
 	}
 }
 
+func TestDownloadToFile(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.StreamTempDir = t.TempDir()
+	conf.Conf.DownloadReassemble.Mode = ReassembleFile
+	defer func() { conf.Conf = nil }()
+	buff := make([]byte, 100)
+	for i := range buff {
+		buff[i] = byte(i)
+	}
+	downloader, invocations, _ := newDownloadRangeClient(buff)
+	d := NewDownloader(func(d *Downloader) {
+		d.Concurrency = 3
+		d.PartSize = 7
+		d.HttpClient = downloader.HttpRequest
+	})
+	req := &HttpRequestParams{
+		Range: http_range.Range{Start: 5, Length: 90},
+		Size:  int64(len(buff)),
+	}
+	readCloser, err := d.Download(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	resultBuf, err := io.ReadAll(readCloser)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	_ = readCloser.Close()
+	if !bytes.Equal(resultBuf, buff[5:95]) {
+		t.Errorf("expect %v, got %v", buff[5:95], resultBuf)
+	}
+	if e, a := 13, *invocations; e != a {
+		t.Errorf("expect %v API calls, got %v", e, a)
+	}
+}
+
 type downloadCaptureClient struct {
 	mockedHttpRequest    func(params *HttpRequestParams) (*http.Response, error)
 	GetObjectInvocations int
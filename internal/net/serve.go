@@ -6,13 +6,13 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
-	"mime"
 	"mime/multipart"
+	stdnet "net"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -69,15 +69,18 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, name string, modTime time
 
 	code := http.StatusOK
 
+	// 使用请求的Context
+	// 不然从sendContent读不到数据，即使请求断开CopyBuffer也会一直堵塞
+	ctx := context.WithValue(r.Context(), "request_header", r.Header)
+
 	// If Content-Type isn't set, use the file's extension to find it, but
 	// if the Content-Type is unset explicitly, do not sniff the type.
 	contentTypes, haveType := w.Header()["Content-Type"]
 	var contentType string
 	if !haveType {
-		contentType = mime.TypeByExtension(filepath.Ext(name))
+		contentType = utils.GetMimeTypeByExt(name)
 		if contentType == "" {
-			// most modern application can handle the default contentType
-			contentType = "application/octet-stream"
+			contentType = sniffContentType(ctx, RangeReadCloser, size)
 		}
 		w.Header().Set("Content-Type", contentType)
 	} else if len(contentTypes) > 0 {
@@ -112,9 +115,6 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, name string, modTime time
 		ranges = nil
 	}
 
-	// 使用请求的Context
-	// 不然从sendContent读不到数据，即使请求断开CopyBuffer也会一直堵塞
-	ctx := context.WithValue(r.Context(), "request_header", r.Header)
 	switch {
 	case len(ranges) == 0:
 		reader, err := RangeReadCloser.RangeRead(ctx, http_range.Range{Length: -1})
@@ -211,6 +211,28 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, name string, modTime time
 	}
 	return nil
 }
+
+// sniffContentType reads the first 512 bytes to detect the content type like http.ServeContent,
+// it's only called if the extension is unknown
+func sniffContentType(ctx context.Context, rrc model.RangeReadCloserIF, size int64) string {
+	// most modern application can handle the default contentType
+	const defaultType = "application/octet-stream"
+	if size <= 0 {
+		return defaultType
+	}
+	rc, err := rrc.RangeRead(ctx, http_range.Range{Length: min(size, 512)})
+	if err != nil {
+		return defaultType
+	}
+	defer rc.Close()
+	var buf [512]byte
+	n, _ := io.ReadFull(rc, buf[:])
+	if n == 0 {
+		return defaultType
+	}
+	return http.DetectContentType(buf[:n])
+}
+
 func ProcessHeader(origin, override http.Header) http.Header {
 	result := http.Header{}
 	// client header
@@ -229,12 +251,22 @@ func ProcessHeader(origin, override http.Header) http.Header {
 
 // RequestHttp deal with Header properly then send the request
 func RequestHttp(ctx context.Context, httpMethod string, headerOverride http.Header, URL string) (*http.Response, error) {
+	return requestHttp(ctx, HttpClient(), httpMethod, headerOverride, URL)
+}
+
+// RequestPublicHttp is RequestHttp refusing to connect to a non public address, see PublicHttpClient.
+// It's used for the urls given by the users
+func RequestPublicHttp(ctx context.Context, httpMethod string, headerOverride http.Header, URL string) (*http.Response, error) {
+	return requestHttp(ctx, PublicHttpClient(), httpMethod, headerOverride, URL)
+}
+
+func requestHttp(ctx context.Context, client *http.Client, httpMethod string, headerOverride http.Header, URL string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, httpMethod, URL, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header = headerOverride
-	res, err := HttpClient().Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -278,6 +310,39 @@ func HttpClient() *http.Client {
 	return httpClient
 }
 
+var publicOnce sync.Once
+var publicHttpClient *http.Client
+
+// PublicHttpClient is HttpClient which only connects to the public addresses, the address is checked when dialing,
+// so that a redirect or a dns record to a loopback or private address is refused too. No proxy is used
+func PublicHttpClient() *http.Client {
+	publicOnce.Do(func() {
+		dialer := &stdnet.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := stdnet.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if !utils.IsPublicIP(stdnet.ParseIP(host)) {
+					return errors.Errorf("the address %s isn't public", host)
+				}
+				return nil
+			},
+		}
+		publicHttpClient = &http.Client{
+			Timeout: time.Hour * 48,
+			Transport: &http.Transport{
+				DialContext:     dialer.DialContext,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify},
+			},
+			CheckRedirect: HttpClient().CheckRedirect,
+		}
+	})
+	return publicHttpClient
+}
+
 func NewHttpClient() *http.Client {
 	return &http.Client{
 		Timeout: time.Hour * 48,
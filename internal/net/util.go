@@ -236,6 +236,28 @@ func writeNotModified(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotModified)
 }
 
+// CheckNotModified reports whether StatusNotModified is sent for the GET or HEAD request,
+// according to If-None-Match with the Etag of w, or If-Modified-Since with modtime.
+// It's used before getting the content, and the headers of r are kept for the later checks
+func CheckNotModified(w http.ResponseWriter, r *http.Request, modtime time.Time) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return false
+	}
+	r = r.Clone(r.Context())
+	setLastModified(w, modtime)
+	switch checkIfNoneMatch(w, r) {
+	case condFalse:
+		writeNotModified(w)
+		return true
+	case condNone:
+		if checkIfModifiedSince(r, modtime) == condFalse {
+			writeNotModified(w)
+			return true
+		}
+	}
+	return false
+}
+
 // checkPreconditions evaluates request preconditions and reports whether a precondition
 // resulted in sending StatusNotModified or StatusPreconditionFailed.
 func checkPreconditions(w http.ResponseWriter, r *http.Request, modtime time.Time) (done bool, rangeHeader string) {
@@ -346,6 +368,9 @@ func GetRangedHttpReader(readCloser io.ReadCloser, offset, length int64) (io.Rea
 	if _, err := utils.CopyWithBuffer(io.Discard, io.LimitReader(readCloser, offset)); err != nil {
 		return nil, err
 	}
+	if length < 0 {
+		return readCloser, nil
+	}
 
 	// return an io.ReadCloser that is limited to `length` bytes.
 	return &LimitedReadCloser{readCloser, length_int}, nil
@@ -0,0 +1,137 @@
+package baidu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/drivers/baidu_netdisk"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+)
+
+type BaiduNetdisk struct{}
+
+func (b *BaiduNetdisk) Name() string {
+	return "BaiduNetdisk"
+}
+
+func (b *BaiduNetdisk) Items() []model.SettingItem {
+	return nil
+}
+
+func (b *BaiduNetdisk) Run(task *tool.DownloadTask) error {
+	return errs.NotSupport
+}
+
+func (b *BaiduNetdisk) Init() (string, error) {
+	return "ok", nil
+}
+
+func (b *BaiduNetdisk) IsReady() bool {
+	tempDir := setting.GetStr(conf.BaiduTempDir)
+	if tempDir == "" {
+		return false
+	}
+	storage, _, err := op.GetStorageAndActualPath(tempDir)
+	if err != nil {
+		return false
+	}
+	if _, ok := storage.(*baidu_netdisk.BaiduNetdisk); !ok {
+		return false
+	}
+	return true
+}
+
+func (b *BaiduNetdisk) AddURL(args *tool.AddUrlArgs) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(args.TempDir)
+	if err != nil {
+		return "", err
+	}
+	baiduDriver, ok := storage.(*baidu_netdisk.BaiduNetdisk)
+	if !ok {
+		return "", fmt.Errorf("unsupported storage driver for offline download, only BaiduNetdisk is supported")
+	}
+
+	ctx := context.Background()
+
+	if err := op.MakeDir(ctx, storage, actualPath); err != nil {
+		return "", err
+	}
+
+	parentDir, err := op.GetUnwrap(ctx, storage, actualPath)
+	if err != nil {
+		return "", err
+	}
+
+	taskId, err := baiduDriver.OfflineDownload(ctx, args.Url, parentDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to add offline download task: %w", err)
+	}
+
+	return taskId, nil
+}
+
+func (b *BaiduNetdisk) Remove(task *tool.DownloadTask) error {
+	storage, _, err := op.GetStorageAndActualPath(task.TempDir)
+	if err != nil {
+		return err
+	}
+	baiduDriver, ok := storage.(*baidu_netdisk.BaiduNetdisk)
+	if !ok {
+		return fmt.Errorf("unsupported storage driver for offline download, only BaiduNetdisk is supported")
+	}
+	return baiduDriver.DeleteOfflineTask(context.Background(), task.GID)
+}
+
+func (b *BaiduNetdisk) Status(task *tool.DownloadTask) (*tool.Status, error) {
+	storage, _, err := op.GetStorageAndActualPath(task.TempDir)
+	if err != nil {
+		return nil, err
+	}
+	baiduDriver, ok := storage.(*baidu_netdisk.BaiduNetdisk)
+	if !ok {
+		return nil, fmt.Errorf("unsupported storage driver for offline download, only BaiduNetdisk is supported")
+	}
+	tasks, err := baiduDriver.OfflineTaskInfo(context.Background(), []string{task.GID})
+	if err != nil {
+		return nil, err
+	}
+	s := &tool.Status{
+		Progress:  0,
+		NewGID:    "",
+		Completed: false,
+		Status:    "the task has been deleted",
+		Err:       nil,
+	}
+	t, ok := tasks[task.GID]
+	if !ok {
+		s.Err = fmt.Errorf("the task has been deleted")
+		return s, nil
+	}
+	s.Status = t.GetStatus()
+	s.Completed = t.IsDone()
+	s.TotalBytes, _ = strconv.ParseInt(t.FileSize, 10, 64)
+	finished, _ := strconv.ParseInt(t.FinishedSize, 10, 64)
+	if s.TotalBytes > 0 {
+		s.Progress = float64(finished) * 100 / float64(s.TotalBytes)
+	}
+	if s.Completed {
+		s.Progress = 100
+	}
+	if !s.Completed && !t.IsRunning() {
+		s.Err = errors.New(t.GetStatus())
+	}
+	return s, nil
+}
+
+var _ tool.Tool = (*BaiduNetdisk)(nil)
+
+func init() {
+	tool.Tools.Add(&BaiduNetdisk{})
+}
@@ -2,6 +2,7 @@ package tool
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -13,6 +14,13 @@ import (
 	"github.com/xhofe/tache"
 )
 
+// the phase of a DownloadTask, a retry of the failed task starts from it
+// so that a failed upload doesn't download again
+const (
+	PhaseDownload = "download"
+	PhaseUpload   = "upload"
+)
+
 type DownloadTask struct {
 	task.TaskExtension
 	Url               string       `json:"url"`
@@ -20,6 +28,7 @@ type DownloadTask struct {
 	TempDir           string       `json:"temp_dir"`
 	DeletePolicy      DeletePolicy `json:"delete_policy"`
 	Toolname          string       `json:"toolname"`
+	Phase             string       `json:"phase"`
 	Status            string       `json:"-"`
 	Signal            chan int     `json:"-"`
 	GID               string       `json:"-"`
@@ -30,6 +39,13 @@ type DownloadTask struct {
 func (t *DownloadTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
+	t.Status = "queued"
+	release, err := t.WaitQueue(DownloadQueue)
+	if err != nil {
+		return err
+	}
+	defer release()
+	t.Status = ""
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
 	if t.tool == nil {
@@ -39,9 +55,22 @@ func (t *DownloadTask) Run() error {
 		}
 		t.tool = tool
 	}
+	if t.Phase == PhaseUpload && t.downloaded() {
+		t.Status = "downloaded, retrying the upload"
+		return t.transfer()
+	}
+	t.Phase = PhaseDownload
+	err = t.download()
+	if err != nil && t.Phase == PhaseDownload {
+		return errors.WithMessage(err, "download failed")
+	}
+	return err
+}
+
+func (t *DownloadTask) download() error {
 	if err := t.tool.Run(t); !errs.IsNotSupportError(err) {
 		if err == nil {
-			return t.Transfer()
+			return t.transfer()
 		}
 		return err
 	}
@@ -87,6 +116,14 @@ outer:
 	if t.tool.Name() == "Thunder" {
 		return nil
 	}
+	if t.tool.Name() == "BaiduNetdisk" {
+		// 百度云端任务完成后清理任务记录，不影响已下载的文件
+		err := t.tool.Remove(t)
+		if err != nil {
+			log.Errorln(err.Error())
+		}
+		return nil
+	}
 	if t.tool.Name() == "115 Cloud" {
 		// hack for 115
 		<-time.After(time.Second * 1)
@@ -147,8 +184,7 @@ func (t *DownloadTask) Update() (bool, error) {
 	}
 	// if download completed
 	if info.Completed {
-		err := t.Transfer()
-		return true, errors.WithMessage(err, "failed to transfer file")
+		return true, t.transfer()
 	}
 	// if download failed
 	if info.Err != nil {
@@ -157,9 +193,33 @@ func (t *DownloadTask) Update() (bool, error) {
 	return false, nil
 }
 
-func (t *DownloadTask) Transfer() error {
+// transfer adds the transfer tasks of the downloaded files, the task is in PhaseUpload since then
+func (t *DownloadTask) transfer() error {
+	t.Phase = PhaseUpload
+	t.Persist()
+	if err := t.Transfer(); err != nil {
+		return errors.WithMessage(err, "upload failed")
+	}
+	return nil
+}
+
+// downloaded tells whether the downloaded files are still there for retrying the upload
+func (t *DownloadTask) downloaded() bool {
+	if t.cloudTool() {
+		return true
+	}
+	_, err := os.Stat(t.TempDir)
+	return err == nil
+}
+
+// cloudTool tells whether the tool downloads to a storage instead of the local temp dir
+func (t *DownloadTask) cloudTool() bool {
 	toolName := t.tool.Name()
-	if toolName == "115 Cloud" || toolName == "PikPak" || toolName == "Thunder" {
+	return toolName == "115 Cloud" || toolName == "PikPak" || toolName == "Thunder" || toolName == "BaiduNetdisk"
+}
+
+func (t *DownloadTask) Transfer() error {
+	if t.cloudTool() {
 		// 如果不是直接下载到目标路径，则进行转存
 		if t.TempDir != t.DstDirPath {
 			return transferObj(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
@@ -177,4 +237,8 @@ func (t *DownloadTask) GetStatus() string {
 	return t.Status
 }
 
-var DownloadTaskManager *tache.Manager[*DownloadTask]
+var (
+	DownloadTaskManager *tache.Manager[*DownloadTask]
+	// DownloadQueue admits the runs of DownloadTaskManager, the queued tasks can be bumped to the front
+	DownloadQueue *task.Queue
+)
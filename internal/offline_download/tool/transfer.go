@@ -3,9 +3,11 @@ package tool
 import (
 	"context"
 	"fmt"
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -29,18 +31,42 @@ type TransferTask struct {
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
 	DeletePolicy DeletePolicy  `json:"delete_policy"`
+	// RetainUntil is when the temp file kept for retrying the failed upload is removed
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
 }
 
 func (t *TransferTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
+	t.Status = "queued"
+	release, err := t.WaitQueue(TransferQueue)
+	if err != nil {
+		return err
+	}
+	defer release()
+	t.Status = ""
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
+	// the storages are not persisted, an empty SrcStorageMp means the temp file is local
+	if t.DstStorage == nil {
+		t.DstStorage, err = op.GetStorageByMountPath(t.DstStorageMp)
+	}
+	if t.SrcStorage == nil && t.SrcStorageMp != "" && err == nil {
+		t.SrcStorage, err = op.GetStorageByMountPath(t.SrcStorageMp)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "upload failed: failed get storage")
+	}
 	if t.SrcStorage == nil {
-		return transferStdPath(t)
+		err = transferStdPath(t)
 	} else {
-		return transferObjPath(t)
+		err = transferObjPath(t)
 	}
+	if err != nil {
+		return errors.WithMessage(err, "upload failed")
+	}
+	t.RetainUntil = nil
+	return nil
 }
 
 func (t *TransferTask) GetName() string {
@@ -53,26 +79,59 @@ func (t *TransferTask) GetStatus() string {
 
 func (t *TransferTask) OnSucceeded() {
 	if t.DeletePolicy == DeleteOnUploadSucceed || t.DeletePolicy == DeleteAlways {
-		if t.SrcStorage == nil {
-			removeStdTemp(t)
-		} else {
-			removeObjTemp(t)
-		}
+		t.removeTemp()
 	}
 }
 
+// OnFailed keeps the temp file for offline_download_temp_retention hours before removing it by the delete policy,
+// a retry in the meantime uploads it again without downloading, and resumes the upload if the driver supports it
 func (t *TransferTask) OnFailed() {
-	if t.DeletePolicy == DeleteOnUploadFailed || t.DeletePolicy == DeleteAlways {
-		if t.SrcStorage == nil {
-			removeStdTemp(t)
-		} else {
-			removeObjTemp(t)
+	if t.DeletePolicy != DeleteOnUploadFailed && t.DeletePolicy != DeleteAlways {
+		return
+	}
+	retention := time.Duration(setting.GetInt(conf.OfflineDownloadTempRetention, 24)) * time.Hour
+	if retention <= 0 {
+		t.removeTemp()
+		return
+	}
+	until := time.Now().Add(retention)
+	t.RetainUntil = &until
+	t.Status = fmt.Sprintf("upload failed, the temp file is kept for retry until %s", until.Format(time.DateTime))
+	t.Persist()
+	time.AfterFunc(retention, t.expireTemp)
+}
+
+// expireTemp removes the kept temp file, unless the task is retried or failed again later
+func (t *TransferTask) expireTemp() {
+	if t.GetState() != tache.StateFailed || t.RetainUntil == nil || time.Now().Before(*t.RetainUntil) {
+		return
+	}
+	t.RetainUntil = nil
+	t.removeTemp()
+}
+
+func (t *TransferTask) removeTemp() {
+	if t.SrcStorage == nil {
+		removeStdTemp(t)
+	} else {
+		removeObjTemp(t)
+	}
+}
+
+// ScheduleTempExpiration schedules the removal of the temp files kept by the failed transfer tasks
+// recovered after a restart
+func ScheduleTempExpiration() {
+	for _, t := range TransferTaskManager.GetByState(tache.StateFailed) {
+		if t.RetainUntil != nil {
+			time.AfterFunc(time.Until(*t.RetainUntil), t.expireTemp)
 		}
 	}
 }
 
 var (
 	TransferTaskManager *tache.Manager[*TransferTask]
+	// TransferQueue admits the runs of TransferTaskManager, the queued tasks can be bumped to the front
+	TransferQueue *task.Queue
 )
 
 func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
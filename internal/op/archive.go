@@ -15,6 +15,7 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -137,7 +138,11 @@ func getArchiveMeta(ctx context.Context, storage driver.Driver, path string, arg
 		if obj.IsDir() {
 			return nil, nil, errors.WithStack(errs.NotFile)
 		}
+		ctx, cancel := withTimeout(ctx, storage, "archive_meta")
+		defer cancel()
+		done := metrics.Observe(storage, "archive_meta")
 		meta, err := storageAr.GetArchiveMeta(ctx, obj, args.ArchiveArgs)
+		done(err)
 		if !errors.Is(err, errs.NotImplement) {
 			archiveMetaProvider := &model.ArchiveMetaProvider{ArchiveMeta: meta, DriverProviding: true}
 			if meta != nil && meta.GetTree() != nil {
@@ -213,12 +218,9 @@ func ListArchive(ctx context.Context, storage driver.Driver, path string, args m
 			}
 		}
 		// warp obj name
-		model.WrapObjsName(files)
+		wrapObjsName(storage, files)
 		// sort objs
-		if storage.Config().LocalSort {
-			model.SortFiles(files, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
-		}
-		model.ExtractFolder(files, storage.GetStorage().ExtractFolder)
+		sortObjs(storage, files)
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
@@ -243,7 +245,11 @@ func _listArchive(ctx context.Context, storage driver.Driver, path string, args
 		if obj.IsDir() {
 			return nil, nil, errors.WithStack(errs.NotFile)
 		}
+		ctx, cancel := withTimeout(ctx, storage, "archive_list")
+		defer cancel()
+		done := metrics.Observe(storage, "archive_list")
 		files, err := storageAr.ListArchive(ctx, obj, args.ArchiveInnerArgs)
+		done(err)
 		if !errors.Is(err, errs.NotImplement) {
 			return obj, files, err
 		}
@@ -345,7 +351,7 @@ func ArchiveGet(ctx context.Context, storage driver.Driver, path string, args mo
 	if g, ok := storage.(driver.ArchiveGetter); ok {
 		obj, err := g.ArchiveGet(ctx, af, args.ArchiveInnerArgs)
 		if err == nil {
-			return af, model.WrapObjName(obj), nil
+			return af, wrapObjName(storage, obj), nil
 		}
 	}
 
@@ -437,7 +443,9 @@ func driverExtract(ctx context.Context, storage driver.Driver, path string, args
 	if extracted.IsDir() {
 		return nil, errors.WithStack(errs.NotFile)
 	}
+	done := metrics.Observe(storage, "archive_extract")
 	link, err := storageAr.Extract(ctx, archiveFile, args)
+	done(err)
 	return &extractLink{Link: link, Obj: extracted}, err
 }
 
@@ -482,6 +490,9 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	srcObj, err := GetUnwrap(ctx, storage, srcPath)
@@ -493,6 +504,7 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	done := metrics.Observe(storage, "archive_decompress")
 	switch s := storage.(type) {
 	case driver.ArchiveDecompressResult:
 		var newObjs []model.Obj
@@ -500,7 +512,7 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 		if err == nil {
 			if newObjs != nil && len(newObjs) > 0 {
 				for _, newObj := range newObjs {
-					addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+					addCacheObj(storage, dstDirPath, wrapObjName(storage, newObj))
 				}
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
@@ -514,5 +526,10 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 	default:
 		return errs.NotImplement
 	}
+	done(err)
+	if err == nil {
+		// the names of the decompressed objs are unknown
+		handleFsChange(FsChangeCreate, storage, dstDirPath, "")
+	}
 	return errors.WithStack(err)
 }
@@ -0,0 +1,240 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func getRawObjs(ctx context.Context, storage driver.Driver, paths []string) ([]model.Obj, []model.Obj, error) {
+	rawObjs := make([]model.Obj, 0, len(paths))
+	objs := make([]model.Obj, 0, len(paths))
+	for _, p := range paths {
+		rawObj, err := Get(ctx, storage, p)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed to get src object [%s]", p)
+		}
+		rawObjs = append(rawObjs, rawObj)
+		objs = append(objs, model.UnwrapObj(rawObj))
+	}
+	return rawObjs, objs, nil
+}
+
+func cleanPaths(paths []string) []string {
+	res := make([]string, len(paths))
+	for i, p := range paths {
+		res[i] = utils.FixAndCleanPath(p)
+	}
+	return res
+}
+
+// BatchMove move objs in one storage with driver.BatchMove, or one by one if not implemented
+func BatchMove(ctx context.Context, storage driver.Driver, srcPaths []string, dstDirPath string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
+	s, ok := storage.(driver.BatchMove)
+	if !ok || len(srcPaths) < 2 {
+		for i, p := range srcPaths {
+			if err := Move(ctx, storage, p, dstDirPath, len(srcPaths) > i+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srcPaths = cleanPaths(srcPaths)
+	dstDirPath = utils.FixAndCleanPath(dstDirPath)
+	rawObjs, srcObjs, err := getRawObjs(ctx, storage, srcPaths)
+	if err != nil {
+		return err
+	}
+	dstDir, err := GetUnwrap(ctx, storage, dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst dir")
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "batch_move")
+	defer cancel()
+	done := metrics.Observe(storage, "batch_move")
+	err = s.BatchMove(ctx, srcObjs, dstDir)
+	done(err)
+	release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, p := range srcPaths {
+		delCacheObj(storage, stdpath.Dir(p), rawObjs[i])
+		handleFsChange(FsChangeMove, storage, p, stdpath.Join(dstDirPath, srcObjs[i].GetName()))
+	}
+	ClearCache(storage, dstDirPath)
+	return nil
+}
+
+// BatchCopy copy objs in one storage with driver.BatchCopy, or one by one if not implemented
+func BatchCopy(ctx context.Context, storage driver.Driver, srcPaths []string, dstDirPath string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
+	s, ok := storage.(driver.BatchCopy)
+	if !ok || len(srcPaths) < 2 {
+		for i, p := range srcPaths {
+			if err := Copy(ctx, storage, p, dstDirPath, len(srcPaths) > i+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srcPaths = cleanPaths(srcPaths)
+	dstDirPath = utils.FixAndCleanPath(dstDirPath)
+	_, srcObjs, err := getRawObjs(ctx, storage, srcPaths)
+	if err != nil {
+		return err
+	}
+	dstDir, err := GetUnwrap(ctx, storage, dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst dir")
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "batch_copy")
+	defer cancel()
+	done := metrics.Observe(storage, "batch_copy")
+	err = s.BatchCopy(ctx, srcObjs, dstDir)
+	done(err)
+	release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ClearCache(storage, dstDirPath)
+	for _, obj := range srcObjs {
+		handleFsChange(FsChangeCreate, storage, stdpath.Join(dstDirPath, obj.GetName()), "")
+	}
+	return nil
+}
+
+// BatchRemove remove objs in one storage with driver.BatchRemove, or one by one if not implemented
+func BatchRemove(ctx context.Context, storage driver.Driver, paths []string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
+	s, ok := storage.(driver.BatchRemove)
+	if !ok || len(paths) < 2 {
+		for _, p := range paths {
+			if err := Remove(ctx, storage, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	paths = cleanPaths(paths)
+	for _, p := range paths {
+		if utils.PathEqual(p, "/") {
+			return errors.New("delete root folder is not allowed, please goto the manage page to delete the storage instead")
+		}
+	}
+	rawObjs := make([]model.Obj, 0, len(paths))
+	objs := make([]model.Obj, 0, len(paths))
+	removePaths := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rawObj, err := Get(ctx, storage, p)
+		if err != nil {
+			// if object not found, it's ok
+			if errs.IsObjectNotFound(err) {
+				continue
+			}
+			return errors.WithMessagef(err, "failed to get object [%s]", p)
+		}
+		rawObjs = append(rawObjs, rawObj)
+		objs = append(objs, model.UnwrapObj(rawObj))
+		removePaths = append(removePaths, p)
+	}
+	if len(objs) == 0 {
+		return nil
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "batch_remove")
+	defer cancel()
+	done := metrics.Observe(storage, "batch_remove")
+	err = s.BatchRemove(ctx, objs)
+	done(err)
+	release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, p := range removePaths {
+		delCacheObj(storage, stdpath.Dir(p), rawObjs[i])
+		if rawObjs[i].IsDir() {
+			ClearCache(storage, p)
+		}
+		handleFsChange(FsChangeRemove, storage, p, "")
+	}
+	return nil
+}
+
+// BatchRename rename srcPaths[i] to newNames[i] with driver.BatchRename, or one by one if not implemented
+func BatchRename(ctx context.Context, storage driver.Driver, srcPaths, newNames []string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
+	if len(srcPaths) != len(newNames) {
+		return errors.New("the count of names mismatch")
+	}
+	s, ok := storage.(driver.BatchRename)
+	if !ok || len(srcPaths) < 2 {
+		for i, p := range srcPaths {
+			if err := Rename(ctx, storage, p, newNames[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srcPaths = cleanPaths(srcPaths)
+	_, srcObjs, err := getRawObjs(ctx, storage, srcPaths)
+	if err != nil {
+		return err
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "batch_rename")
+	defer cancel()
+	done := metrics.Observe(storage, "batch_rename")
+	err = s.BatchRename(ctx, srcObjs, newNames)
+	done(err)
+	release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, p := range srcPaths {
+		ClearCache(storage, stdpath.Dir(p))
+		handleFsChange(FsChangeMove, storage, p, stdpath.Join(stdpath.Dir(p), newNames[i]))
+	}
+	return nil
+}
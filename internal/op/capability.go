@@ -0,0 +1,67 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+)
+
+// Capabilities describes which optional operations a loaded storage supports,
+// so that the frontend can disable those which would just fail
+type Capabilities struct {
+	Mkdir           bool `json:"mkdir"`
+	Move            bool `json:"move"`
+	Rename          bool `json:"rename"`
+	Copy            bool `json:"copy"`
+	Remove          bool `json:"remove"`
+	RemovePermanent bool `json:"remove_permanent"`
+	Upload          bool `json:"upload"`
+	PutURL          bool `json:"put_url"`
+	Other           bool `json:"other"`
+	Details         bool `json:"details"`
+	Archive         bool `json:"archive"`
+	Decompress      bool `json:"decompress"`
+	OfflineDownload bool `json:"offline_download"`
+	LocalSort       bool `json:"local_sort"`
+	MustProxy       bool `json:"must_proxy"`
+	NoCache         bool `json:"no_cache"`
+}
+
+func GetCapabilities(storage driver.Driver) Capabilities {
+	var c Capabilities
+	switch storage.(type) {
+	case driver.Mkdir, driver.MkdirResult:
+		c.Mkdir = true
+	}
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		c.Move = true
+	}
+	switch storage.(type) {
+	case driver.Rename, driver.RenameResult:
+		c.Rename = true
+	}
+	switch storage.(type) {
+	case driver.Copy, driver.CopyResult:
+		c.Copy = true
+	}
+	_, c.Remove = storage.(driver.Remove)
+	_, c.RemovePermanent = storage.(driver.RemovePermanently)
+	switch storage.(type) {
+	case driver.Put, driver.PutResult:
+		c.Upload = !storage.Config().NoUpload
+	}
+	switch storage.(type) {
+	case driver.PutURL, driver.PutURLResult:
+		c.PutURL = !storage.Config().NoUpload
+	}
+	_, c.Other = storage.(driver.Other)
+	_, c.Details = storage.(driver.WithDetails)
+	_, c.Archive = storage.(driver.ArchiveReader)
+	switch storage.(type) {
+	case driver.ArchiveDecompress, driver.ArchiveDecompressResult:
+		c.Decompress = true
+	}
+	c.LocalSort = storage.Config().LocalSort
+	c.MustProxy = storage.Config().MustProxy()
+	c.NoCache = storage.Config().NoCache
+	return c
+}
@@ -4,4 +4,7 @@ const (
 	WORK     = "work"
 	DISABLED = "disabled"
 	RootName = "root"
+
+	// DefaultMaxListEntries is the max objs of a dir listed at once if the storage doesn't set it
+	DefaultMaxListEntries = 10000
 )
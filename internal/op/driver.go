@@ -81,6 +81,12 @@ func getMainItems(config driver.Config) []driver.Item {
 			Help:     "The cache expiration time for this storage",
 		})
 	}
+	items = append(items, driver.Item{
+		Name:    "max_concurrency",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "Max concurrent requests to the driver, 0 means unlimited",
+	})
 	if !config.OnlyProxy && !config.OnlyLocal {
 		items = append(items, []driver.Item{{
 			Name: "web_proxy",
@@ -138,6 +144,12 @@ func getMainItems(config driver.Config) []driver.Item {
 		Type:    conf.TypeSelect,
 		Options: "front,back",
 	})
+	items = append(items, driver.Item{
+		Name:    "natural_sort",
+		Type:    conf.TypeBool,
+		Default: "false",
+		Help:    "sort the names naturally, 2 before 10, if the storage sorts by name",
+	})
 	items = append(items, driver.Item{
 		Name:     "disable_index",
 		Type:     conf.TypeBool,
@@ -160,34 +172,115 @@ func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
 			items = append(items, getAdditionalItems(field.Type, defaultRoot)...)
 			continue
 		}
-		tag := field.Tag
-		ignore, ok1 := tag.Lookup("ignore")
-		name, ok2 := tag.Lookup("json")
-		if (ok1 && ignore == "true") || !ok2 {
-			continue
+		if item, ok := getAdditionalItem(field, defaultRoot); ok {
+			items = append(items, item)
 		}
-		item := driver.Item{
-			Name:     name,
-			Type:     strings.ToLower(field.Type.Name()),
-			Default:  tag.Get("default"),
-			Options:  tag.Get("options"),
-			Required: tag.Get("required") == "true",
-			Help:     tag.Get("help"),
+	}
+	return items
+}
+
+// getAdditionalItem makes the item of the field, it's false if the field isn't an option
+func getAdditionalItem(field reflect.StructField, defaultRoot string) (driver.Item, bool) {
+	tag := field.Tag
+	ignore, ok1 := tag.Lookup("ignore")
+	name, ok2 := tag.Lookup("json")
+	if (ok1 && ignore == "true") || !ok2 {
+		return driver.Item{}, false
+	}
+	item := driver.Item{
+		Name:     name,
+		Type:     strings.ToLower(field.Type.Name()),
+		Default:  tag.Get("default"),
+		Options:  tag.Get("options"),
+		Required: tag.Get("required") == "true",
+		Help:     tag.Get("help"),
+	}
+	if tag.Get("type") != "" {
+		item.Type = tag.Get("type")
+	}
+	if item.Name == "root_folder_id" || item.Name == "root_folder_path" {
+		if item.Default == "" {
+			item.Default = defaultRoot
+		}
+		item.Required = item.Default != ""
+	}
+	// set default type to string
+	if item.Type == "" {
+		item.Type = "string"
+	}
+	return item, true
+}
+
+// GetDriverSchema describes the options of the driver by reflection over its Addition,
+// in the same order and with the same defaults as the items of the driver info
+func GetDriverSchema(name string) ([]driver.Field, error) {
+	constructor, err := GetDriver(name)
+	if err != nil {
+		return nil, err
+	}
+	d := constructor()
+	var fields []driver.Field
+	for _, item := range getMainItems(d.Config()) {
+		fields = append(fields, itemField("common", "", item))
+	}
+	t := reflect.TypeOf(d.GetAddition())
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return append(fields, getAdditionalFields(t, d.Config().DefaultRoot)...), nil
+}
+
+func getAdditionalFields(t reflect.Type, defaultRoot string) []driver.Field {
+	var fields []driver.Field
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			fields = append(fields, getAdditionalFields(field.Type, defaultRoot)...)
+			continue
 		}
-		if tag.Get("type") != "" {
-			item.Type = tag.Get("type")
+		item, ok := getAdditionalItem(field, defaultRoot)
+		if !ok {
+			continue
 		}
-		if item.Name == "root_folder_id" || item.Name == "root_folder_path" {
-			if item.Default == "" {
-				item.Default = defaultRoot
-			}
-			item.Required = item.Default != ""
+		f := itemField("additional", field.Name, item)
+		if field.Tag.Get("type") == "" {
+			f.Type = kindType(field.Type.Kind())
 		}
-		// set default type to string
-		if item.Type == "" {
-			item.Type = "string"
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func itemField(group, name string, item driver.Item) driver.Field {
+	var options []string
+	for _, o := range strings.Split(item.Options, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
 		}
-		items = append(items, item)
 	}
-	return items
+	return driver.Field{
+		Name:     name,
+		Key:      item.Name,
+		Group:    group,
+		Type:     item.Type,
+		Options:  options,
+		Default:  item.Default,
+		Required: item.Required,
+		Help:     item.Help,
+	}
+}
+
+// kindType maps the go kind to the type of the config form
+func kindType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return conf.TypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return conf.TypeNumber
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	default:
+		return conf.TypeString
+	}
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	stdpath "path"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
@@ -20,10 +23,14 @@ import (
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var listCache = newLRUListCache()
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
+	delCachedObjs(storage, path)
+	if oldObj.IsDir() {
+		delCachedObjs(storage, stdpath.Join(path, oldObj.GetName()))
+	}
 	key := Key(storage, path)
 	objs, ok := listCache.Get(key)
 	if ok {
@@ -36,14 +43,54 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 		for i, obj := range objs {
 			if obj.GetName() == oldObj.GetName() {
 				objs[i] = newObj
+				// a new name or attribute may change its place in the sorted list
+				resortCacheObj(storage, objs, i)
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, time.Minute*time.Duration(storage.GetStorage().CacheExpiration))
+	}
+}
+
+// resortCacheObj moves objs[i] to its place in the sorted objs, the others keep their order
+func resortCacheObj(storage driver.Driver, objs []model.Obj, i int) {
+	orderBy, orderDirection := localOrder(storage)
+	if orderBy == "" {
+		if s, ok := storage.(driver.ListOrder); ok {
+			orderBy, orderDirection = s.ListOrder()
+		}
+	}
+	if orderBy == "" {
+		return
+	}
+	less := model.ObjLess(orderBy, orderDirection)
+	obj := objs[i]
+	rest := slices.Delete(slices.Clone(objs), i, i+1)
+	// compare with the objs of the same kind only, since the dirs and files may be separated
+	j, last := len(rest), -1
+	for k, o := range rest {
+		if o.IsDir() != obj.IsDir() {
+			continue
+		}
+		if less(obj, o) {
+			j = k
+			break
+		}
+		last = k
+	}
+	if j == len(rest) && last >= 0 {
+		j = last + 1
+	} else if j == len(rest) {
+		j = i
 	}
+	copy(objs, slices.Insert(rest, j, obj))
 }
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
+	delCachedObjs(storage, path)
+	if obj.IsDir() {
+		delCachedObjs(storage, stdpath.Join(path, obj.GetName()))
+	}
 	key := Key(storage, path)
 	objs, ok := listCache.Get(key)
 	if ok {
@@ -53,13 +100,14 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, time.Minute*time.Duration(storage.GetStorage().CacheExpiration))
 	}
 }
 
 var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
+	delCachedObjs(storage, path)
 	key := Key(storage, path)
 	objs, ok := listCache.Get(key)
 	if ok {
@@ -77,21 +125,22 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 			objs = append([]model.Obj{newObj}, objs...)
 		}
 
-		if storage.Config().LocalSort {
+		if orderBy, _ := localOrder(storage); orderBy != "" {
 			debounce, _ := addSortDebounceMap.LoadOrStore(key, utils.NewDebounce(time.Minute))
 			log.Debug("addCacheObj: wait start sort")
 			debounce(func() {
 				log.Debug("addCacheObj: start sort")
-				model.SortFiles(objs, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
+				sortObjs(storage, objs)
 				addSortDebounceMap.Delete(key)
 			})
 		}
 
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		listCache.Set(key, objs, time.Minute*time.Duration(storage.GetStorage().CacheExpiration))
 	}
 }
 
 func ClearCache(storage driver.Driver, path string) {
+	delCachedObjs(storage, path)
 	objs, ok := listCache.Get(Key(storage, path))
 	if ok {
 		for _, obj := range objs {
@@ -103,6 +152,39 @@ func ClearCache(storage driver.Driver, path string) {
 	listCache.Del(Key(storage, path))
 }
 
+// InvalidateCache drops the cached listing of path and the cached links of the files in it, and those of
+// the cached sub dirs if recursive, so that the next List and Link hit the storage. path can also be a file,
+// then its parent listing is dropped. The links cached per IP are not dropped
+func InvalidateCache(storage driver.Driver, path string, recursive bool) {
+	path = utils.FixAndCleanPath(path)
+	key := Key(storage, path)
+	linkCache.Del(key)
+	delCachedObjs(storage, path)
+	objs, ok := listCache.GetStale(key)
+	if !ok {
+		delCachedObjs(storage, stdpath.Dir(path))
+		listCache.Del(Key(storage, stdpath.Dir(path)))
+		return
+	}
+	for _, obj := range objs {
+		p := stdpath.Join(path, obj.GetName())
+		if !obj.IsDir() {
+			linkCache.Del(Key(storage, p))
+		} else if recursive {
+			InvalidateCache(storage, p, true)
+		}
+	}
+	listCache.Del(key)
+}
+
+// checkWritable refuse any write to a read-only storage, whichever front-end the request comes from
+func checkWritable(storage driver.Driver) error {
+	if storage.GetStorage().ReadOnly {
+		return errors.WithMessagef(errs.PermissionDenied, "storage [%s] is read-only", storage.GetStorage().MountPath)
+	}
+	return nil
+}
+
 func Key(storage driver.Driver, path string) string {
 	return stdpath.Join(storage.GetStorage().MountPath, utils.FixAndCleanPath(path))
 }
@@ -112,11 +194,19 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if args.NameFilter != nil {
+		return listByName(ctx, storage, path, args)
+	}
+	if !args.ModifiedSince.IsZero() {
+		return listModifiedSince(ctx, storage, path, args)
+	}
 	path = utils.FixAndCleanPath(path)
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !args.Refresh {
-		if files, ok := listCache.Get(key); ok {
+		files, ok := listCache.Get(key)
+		metrics.CacheLookup(storage, "list", ok)
+		if ok {
 			log.Debugf("use cache when list %s", path)
 			return files, nil
 		}
@@ -130,7 +220,16 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		ctx, release, err := acquireStorage(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := withTimeout(ctx, storage, "list")
+		defer cancel()
+		done := metrics.Observe(storage, "list")
 		files, err := storage.List(ctx, dir, args)
+		done(err)
+		release()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -141,22 +240,20 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 			}
 		}
 		// warp obj name
-		model.WrapObjsName(files)
+		wrapObjsName(storage, files)
 		// call hooks
 		go func(reqPath string, files []model.Obj) {
 			HandleObjsUpdateHook(reqPath, files)
 		}(utils.GetFullPath(storage.GetStorage().MountPath, path), files)
 
 		// sort objs
-		if storage.Config().LocalSort {
-			model.SortFiles(files, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
-		}
-		model.ExtractFolder(files, storage.GetStorage().ExtractFolder)
+		sortObjs(storage, files)
 
 		if !storage.Config().NoCache {
+			cacheObjs(storage, path, files)
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				listCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				listCache.Set(key, files, time.Minute*time.Duration(storage.GetStorage().CacheExpiration))
 			} else {
 				log.Debugf("del cache: %s", key)
 				listCache.Del(key)
@@ -164,19 +261,103 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		}
 		return files, nil
 	})
+	if err != nil && errs.IsTransient(err) {
+		// a brief failure of the storage shouldn't empty the dir which was listed moments ago
+		if files, ok := listCache.GetStale(key); ok {
+			log.Warnf("failed list %s, use the stale cache: %v", path, err)
+			if stale, ok := ctx.Value(conf.StaleListKey).(*bool); ok {
+				*stale = true
+			}
+			return files, nil
+		}
+	}
 	return objs, err
 }
 
+// ListRecursive lists the objs under path with the recursive list of the driver, see driver.ListRecursive.
+// errs.NotImplement is returned if the driver doesn't support it, the caller should walk the dirs then
+func ListRecursive(ctx context.Context, storage driver.Driver, path string, depth int, fn func(path string, obj model.Obj) error) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	s, ok := storage.(driver.ListRecursive)
+	if !ok {
+		return errs.NotImplement
+	}
+	path = utils.FixAndCleanPath(path)
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return errors.WithStack(errs.NotFolder)
+	}
+	callCtx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	// the storage is only held by the backend calls, not while fn takes the objs, e.g. streams them to the client
+	held := true
+	defer func() {
+		if held {
+			release()
+		}
+	}()
+	walk := fn
+	fn = func(path string, obj model.Obj) error {
+		release()
+		held = false
+		if err := walk(path, obj); err != nil {
+			return err
+		}
+		if _, release, err = acquireStorage(ctx, storage); err != nil {
+			return err
+		}
+		held = true
+		return nil
+	}
+	// the walk takes as long as the dir needs, so the timeout is of each backend call
+	callCtx = driver.WithCallTimeout(callCtx, requestTimeout(storage, "list_recursive"))
+	done := metrics.Observe(storage, "list_recursive")
+	if storage.GetStorage().NameNormalization != model.NameNormNone {
+		list := fn
+		fn = func(path string, obj model.Obj) error {
+			return list(normalizeName(storage, path), &model.ObjWrapName{Name: normalizeName(storage, obj.GetName()), Obj: obj})
+		}
+	}
+	err = s.ListRecursive(callCtx, dir, depth, fn)
+	done(err)
+	return err
+}
+
 // Get object from list of files
 func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
 	log.Debugf("op.Get %s", path)
 
+	if path != "/" {
+		obj, ok := getCachedObj(storage, path)
+		metrics.CacheLookup(storage, "obj", ok)
+		if ok {
+			log.Debugf("use cache when get %s", path)
+			return obj, nil
+		}
+	}
+
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
+		ctx, release, err := acquireStorage(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := withTimeout(ctx, storage, "get")
+		defer cancel()
+		done := metrics.Observe(storage, "get")
 		obj, err := g.Get(ctx, path)
+		done(err)
+		release()
 		if err == nil {
-			return model.WrapObjName(obj), nil
+			return wrapObjName(storage, obj), nil
 		}
 	}
 
@@ -184,7 +365,12 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 	if utils.PathEqual(path, "/") {
 		var rootObj model.Obj
 		if getRooter, ok := storage.(driver.GetRooter); ok {
+			ctx, release, err := acquireStorage(ctx, storage)
+			if err != nil {
+				return nil, err
+			}
 			obj, err := getRooter.GetRoot(ctx)
+			release()
 			if err != nil {
 				return nil, errors.WithMessage(err, "failed get root obj")
 			}
@@ -246,6 +432,14 @@ func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.O
 var linkCache = cache.NewMemCache(cache.WithShards[*model.Link](16))
 var linkG singleflight.Group[*model.Link]
 
+// linkExpireMargin the cached link expires this earlier than the link itself,
+// so that a link got from the cache is still valid when it's used
+const linkExpireMargin = time.Minute
+
+func linkCacheTTL(expiration time.Duration) time.Duration {
+	return expiration - min(linkExpireMargin, expiration/10)
+}
+
 // Link get link, if is an url. should have an expiry time
 func Link(ctx context.Context, storage driver.Driver, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
@@ -258,45 +452,99 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 	if file.IsDir() {
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
+	link, err := linkFile(ctx, storage, path, file, args)
+	return link, file, err
+}
+
+// linkFile gets the link of the unwrapped file at path, the link is cached by the path
+// unless it's of a type, e.g. the parsed one or a history version, which the path doesn't identify
+func linkFile(ctx context.Context, storage driver.Driver, path string, file model.Obj, args model.LinkArgs) (*model.Link, error) {
 	key := Key(storage, path)
-	if link, ok := linkCache.Get(key); ok {
-		return link, file, nil
+	cacheable := args.Type == ""
+	if cacheable {
+		link, ok := linkCache.Get(key)
+		metrics.CacheLookup(storage, "link", ok)
+		if ok {
+			return link, nil
+		}
 	}
 	fn := func() (*model.Link, error) {
+		ctx, release, err := acquireStorage(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := withTimeout(ctx, storage, "link")
+		defer cancel()
+		done := metrics.Observe(storage, "link")
 		link, err := storage.Link(ctx, file, args)
+		done(err)
+		release()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
-		if link.Expiration != nil {
+		if cacheable && link.Expiration != nil && *link.Expiration > 0 {
 			if link.IPCacheKey {
 				key = key + ":" + args.IP
 			}
-			linkCache.Set(key, link, cache.WithEx[*model.Link](*link.Expiration))
+			linkCache.Set(key, link, cache.WithEx[*model.Link](linkCacheTTL(*link.Expiration)))
 		}
 		return link, nil
 	}
 
 	if storage.Config().OnlyLocal {
-		link, err := fn()
-		return link, file, err
+		return fn()
 	}
 
-	link, err, _ := linkG.Do(key, fn)
-	return link, file, err
+	// the link may depend on the query, e.g. a segment of a playlist
+	sfKey := key
+	if args.HttpReq != nil && args.HttpReq.URL != nil {
+		sfKey += "?" + args.HttpReq.URL.RawQuery
+	}
+	link, err, _ := linkG.Do(sfKey, fn)
+	return link, err
+}
+
+// OtherKind is the kind of the method of driver.Other, driver.OtherRead if the driver doesn't tell
+func OtherKind(storage driver.Driver, method string) int {
+	if k, ok := storage.(driver.OtherKind); ok {
+		return k.OtherKind(strings.ToLower(strings.TrimSpace(method)))
+	}
+	return driver.OtherRead
+}
+
+// CheckOtherWritable refuses the method of driver.Other which changes the data of a read-only storage
+func CheckOtherWritable(storage driver.Driver, method string) error {
+	if OtherKind(storage, method) == driver.OtherRead {
+		return nil
+	}
+	return checkWritable(storage)
 }
 
 // Other api
 func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (interface{}, error) {
+	if err := CheckOtherWritable(storage, args.Method); err != nil {
+		return nil, err
+	}
 	obj, err := GetUnwrap(ctx, storage, args.Path)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed to get obj")
 	}
 	if o, ok := storage.(driver.Other); ok {
-		return o.Other(ctx, model.OtherArgs{
+		ctx, release, err := acquireStorage(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		ctx, cancel := withTimeout(ctx, storage, "other")
+		defer cancel()
+		done := metrics.Observe(storage, "other")
+		res, err := o.Other(ctx, model.OtherArgs{
 			Obj:    obj,
 			Method: args.Method,
 			Data:   args.Data,
 		})
+		done(err)
+		return res, err
 	} else {
 		return nil, errs.NotImplement
 	}
@@ -308,61 +556,164 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	path = utils.FixAndCleanPath(path)
 	key := Key(storage, path)
 	_, err, _ := mkdirG.Do(key, func() (interface{}, error) {
-		// check if dir exists
-		f, err := GetUnwrap(ctx, storage, path)
-		if err != nil {
-			if errs.IsObjectNotFound(err) {
-				parentPath, dirName := stdpath.Split(path)
-				err = MakeDir(ctx, storage, parentPath)
-				if err != nil {
-					return nil, errors.WithMessagef(err, "failed to make parent dir [%s]", parentPath)
+		// check if dir exists, only in the cached listing if the driver fails for an existing dir itself
+		if storage.Config().IdempotentMkdir && !utils.PathEqual(path, "/") {
+			if f, ok := cachedChild(storage, path); ok && f != nil {
+				if f.IsDir() {
+					return nil, nil
 				}
-				parentDir, err := GetUnwrap(ctx, storage, parentPath)
-				// this should not happen
-				if err != nil {
-					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
+				return nil, errors.WithStack(errs.FileExists)
+			}
+		} else {
+			f, err := GetUnwrap(ctx, storage, path)
+			if err == nil {
+				if f.IsDir() {
+					return nil, nil
 				}
+				// dir to make is a file
+				return nil, errors.WithStack(errs.FileExists)
+			}
+			if !errs.IsObjectNotFound(err) {
+				return nil, errors.WithMessage(err, "failed to check if dir exists")
+			}
+		}
+		parentPath, dirName := stdpath.Split(path)
+		if s, ok := storage.(driver.MkdirAll); ok {
+			return nil, makeDirAll(ctx, s, storage, path, lazyCache...)
+		}
+		parentDir, err := GetUnwrap(ctx, storage, parentPath)
+		if errs.IsObjectNotFound(err) {
+			err = MakeDir(ctx, storage, parentPath)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to make parent dir [%s]", parentPath)
+			}
+			parentDir, err = GetUnwrap(ctx, storage, parentPath)
+		}
+		// this should not happen
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
+		}
 
-				switch s := storage.(type) {
-				case driver.MkdirResult:
-					var newObj model.Obj
-					newObj, err = s.MakeDir(ctx, parentDir, dirName)
-					if err == nil {
-						if newObj != nil {
-							addCacheObj(storage, parentPath, model.WrapObjName(newObj))
-						} else if !utils.IsBool(lazyCache...) {
-							ClearCache(storage, parentPath)
-						}
-					}
-				case driver.Mkdir:
-					err = s.MakeDir(ctx, parentDir, dirName)
-					if err == nil && !utils.IsBool(lazyCache...) {
-						ClearCache(storage, parentPath)
-					}
-				default:
-					return nil, errs.NotImplement
+		ctx, release, err := acquireStorage(ctx, storage)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := withTimeout(ctx, storage, "make_dir")
+		defer cancel()
+		done := metrics.Observe(storage, "make_dir")
+		switch s := storage.(type) {
+		case driver.MkdirResult:
+			var newObj model.Obj
+			newObj, err = s.MakeDir(ctx, parentDir, dirName)
+			if err == nil {
+				if newObj != nil {
+					addCacheObj(storage, parentPath, wrapObjName(storage, newObj))
+				} else if !utils.IsBool(lazyCache...) {
+					ClearCache(storage, parentPath)
 				}
-				return nil, errors.WithStack(err)
 			}
-			return nil, errors.WithMessage(err, "failed to check if dir exists")
+		case driver.Mkdir:
+			err = s.MakeDir(ctx, parentDir, dirName)
+			if err == nil && !utils.IsBool(lazyCache...) {
+				ClearCache(storage, parentPath)
+			}
+		default:
+			release()
+			return nil, errs.NotImplement
 		}
-		// dir exists
-		if f.IsDir() {
-			return nil, nil
+		done(err)
+		release()
+		if errors.Is(err, errs.ObjectExists) {
+			return nil, existingDir(ctx, storage, path)
+		}
+		if err == nil {
+			handleFsChange(FsChangeCreate, storage, path, "")
 		}
-		// dir to make is a file
-		return nil, errors.New("file exists")
+		return nil, errors.WithStack(err)
 	})
 	return err
 }
 
+// cachedChild finds path in the cached listing of its parent, ok is false if the parent isn't cached
+func cachedChild(storage driver.Driver, path string) (model.Obj, bool) {
+	objs, ok := listCache.Get(Key(storage, stdpath.Dir(path)))
+	if !ok {
+		return nil, false
+	}
+	for _, obj := range objs {
+		if obj.GetName() == stdpath.Base(path) {
+			return obj, true
+		}
+	}
+	return nil, true
+}
+
+// existingDir checks the obj the driver failed to make for errs.ObjectExists is a dir, nothing is created
+func existingDir(ctx context.Context, storage driver.Driver, path string) error {
+	f, err := GetUnwrap(ctx, storage, path)
+	if errs.IsObjectNotFound(err) {
+		// the cached listing of the parent is stale
+		ClearCache(storage, stdpath.Dir(path))
+		f, err = GetUnwrap(ctx, storage, path)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to get the existing dir")
+	}
+	if !f.IsDir() {
+		return errors.WithStack(errs.FileExists)
+	}
+	return nil
+}
+
+// makeDirAll creates the dir with its missing parents in one call of the driver, instead of one level at a time
+func makeDirAll(ctx context.Context, s driver.MkdirAll, storage driver.Driver, path string, lazyCache ...bool) error {
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "make_dir")
+	defer cancel()
+	done := metrics.Observe(storage, "make_dir")
+	newObj, err := s.MakeDirAll(ctx, path)
+	done(err)
+	release()
+	if errors.Is(err, errs.ObjectExists) {
+		return existingDir(ctx, storage, path)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	parentPath := stdpath.Dir(path)
+	if newObj != nil {
+		addCacheObj(storage, parentPath, wrapObjName(storage, newObj))
+	} else if !utils.IsBool(lazyCache...) {
+		ClearCache(storage, parentPath)
+	}
+	// the cached listings of the created parents are stale, up to the first one listing its child
+	for child, dir := parentPath, stdpath.Dir(parentPath); child != "/"; child, dir = dir, stdpath.Dir(dir) {
+		objs, ok := listCache.Get(Key(storage, dir))
+		if ok && slices.ContainsFunc(objs, func(obj model.Obj) bool { return obj.GetName() == stdpath.Base(child) }) {
+			break
+		}
+		ClearCache(storage, dir)
+	}
+	handleFsChange(FsChangeCreate, storage, path, "")
+	return nil
+}
+
 func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string, lazyCache ...bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	srcRawObj, err := Get(ctx, storage, srcPath)
@@ -376,6 +727,13 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	}
 	srcDirPath := stdpath.Dir(srcPath)
 
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "move")
+	defer cancel()
+	done := metrics.Observe(storage, "move")
 	switch s := storage.(type) {
 	case driver.MoveResult:
 		var newObj model.Obj
@@ -383,7 +741,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObjName(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -397,8 +755,14 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	default:
+		release()
 		return errs.NotImplement
 	}
+	done(err)
+	release()
+	if err == nil {
+		handleFsChange(FsChangeMove, storage, srcPath, stdpath.Join(dstDirPath, srcObj.GetName()))
+	}
 	return errors.WithStack(err)
 }
 
@@ -406,7 +770,11 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	srcPath = utils.FixAndCleanPath(srcPath)
+	dstName = normalizeName(storage, dstName)
 	srcRawObj, err := Get(ctx, storage, srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get src object")
@@ -414,13 +782,24 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	srcObj := model.UnwrapObj(srcRawObj)
 	srcDirPath := stdpath.Dir(srcPath)
 
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "rename")
+	defer cancel()
+	done := metrics.Observe(storage, "rename")
 	switch s := storage.(type) {
 	case driver.RenameResult:
 		var newObj model.Obj
 		newObj, err = s.Rename(ctx, srcObj, dstName)
 		if err == nil {
 			if newObj != nil {
-				updateCacheObj(storage, srcDirPath, srcRawObj, model.WrapObjName(newObj))
+				updateCacheObj(storage, srcDirPath, srcRawObj, wrapObjName(storage, newObj))
+				if srcObj.IsDir() {
+					// the listings under the old path are gone
+					ClearCache(storage, srcPath)
+				}
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, srcDirPath)
 			}
@@ -431,8 +810,16 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 			ClearCache(storage, srcDirPath)
 		}
 	default:
+		release()
 		return errs.NotImplement
 	}
+	done(err)
+	release()
+	if err == nil {
+		// the link of the old path is not valid any more, the driver may keep its own link cache by the id
+		linkCache.Del(Key(storage, srcPath))
+		handleFsChange(FsChangeMove, storage, srcPath, stdpath.Join(srcDirPath, dstName))
+	}
 	return errors.WithStack(err)
 }
 
@@ -441,6 +828,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	srcPath = utils.FixAndCleanPath(srcPath)
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	srcObj, err := GetUnwrap(ctx, storage, srcPath)
@@ -452,13 +842,20 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "copy")
+	defer cancel()
+	done := metrics.Observe(storage, "copy")
 	switch s := storage.(type) {
 	case driver.CopyResult:
 		var newObj model.Obj
 		newObj, err = s.Copy(ctx, srcObj, dstDir)
 		if err == nil {
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObjName(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -469,15 +866,105 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			ClearCache(storage, dstDirPath)
 		}
 	default:
+		release()
 		return errs.NotImplement
 	}
+	done(err)
+	release()
+	if err == nil {
+		handleFsChange(FsChangeCreate, storage, stdpath.Join(dstDirPath, srcObj.GetName()), "")
+	}
+	return errors.WithStack(err)
+}
+
+// IsSameBackend check if the two storages belong to the same backend account
+func IsSameBackend(srcStorage, dstStorage driver.Driver) bool {
+	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+		return true
+	}
+	if srcStorage.Config().Name != dstStorage.Config().Name {
+		return false
+	}
+	s, ok := dstStorage.(driver.SameBackend)
+	return ok && s.SameBackend(srcStorage)
+}
+
+// CopyBetween copy file[s] between two storages of the same backend with the native copy of the driver
+func CopyBetween(ctx context.Context, srcStorage, dstStorage driver.Driver, srcPath, dstDirPath string, lazyCache ...bool) error {
+	if srcStorage.Config().CheckStatus && srcStorage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", srcStorage.GetStorage().Status)
+	}
+	if dstStorage.Config().CheckStatus && dstStorage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", dstStorage.GetStorage().Status)
+	}
+	if err := checkWritable(dstStorage); err != nil {
+		return err
+	}
+	if !IsSameBackend(srcStorage, dstStorage) {
+		return errs.NotSupport
+	}
+	srcPath = utils.FixAndCleanPath(srcPath)
+	dstDirPath = utils.FixAndCleanPath(dstDirPath)
+	srcObj, err := GetUnwrap(ctx, srcStorage, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get src object")
+	}
+	dstDir, err := GetUnwrap(ctx, dstStorage, dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst dir")
+	}
+
+	ctx, release, err := acquireStorage(ctx, dstStorage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, dstStorage, "copy")
+	defer cancel()
+	done := metrics.Observe(dstStorage, "copy")
+	switch s := dstStorage.(type) {
+	case driver.CopyResult:
+		var newObj model.Obj
+		newObj, err = s.Copy(ctx, srcObj, dstDir)
+		if err == nil {
+			if newObj != nil {
+				addCacheObj(dstStorage, dstDirPath, wrapObjName(dstStorage, newObj))
+			} else if !utils.IsBool(lazyCache...) {
+				ClearCache(dstStorage, dstDirPath)
+			}
+		}
+	case driver.Copy:
+		err = s.Copy(ctx, srcObj, dstDir)
+		if err == nil && !utils.IsBool(lazyCache...) {
+			ClearCache(dstStorage, dstDirPath)
+		}
+	default:
+		release()
+		return errs.NotImplement
+	}
+	done(err)
+	release()
+	if err == nil {
+		handleFsChange(FsChangeCreate, dstStorage, stdpath.Join(dstDirPath, srcObj.GetName()), "")
+	}
 	return errors.WithStack(err)
 }
 
 func Remove(ctx context.Context, storage driver.Driver, path string) error {
+	return remove(ctx, storage, path, false)
+}
+
+// RemovePermanently skip the recycle bin if the driver has one
+func RemovePermanently(ctx context.Context, storage driver.Driver, path string) error {
+	return remove(ctx, storage, path, true)
+}
+
+func remove(ctx context.Context, storage driver.Driver, path string, permanent bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	if utils.PathEqual(path, "/") {
 		return errors.New("delete root folder is not allowed, please goto the manage page to delete the storage instead")
 	}
@@ -493,9 +980,20 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	}
 	dirPath := stdpath.Dir(path)
 
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "remove")
+	defer cancel()
+	done := metrics.Observe(storage, "remove")
 	switch s := storage.(type) {
 	case driver.Remove:
-		err = s.Remove(ctx, model.UnwrapObj(rawObj))
+		if p, ok := storage.(driver.RemovePermanently); ok && permanent {
+			err = p.RemovePermanently(ctx, model.UnwrapObj(rawObj))
+		} else {
+			err = s.Remove(ctx, model.UnwrapObj(rawObj))
+		}
 		if err == nil {
 			delCacheObj(storage, dirPath, rawObj)
 			// clear folder cache recursively
@@ -504,8 +1002,14 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 			}
 		}
 	default:
+		release()
 		return errs.NotImplement
 	}
+	done(err)
+	release()
+	if err == nil {
+		handleFsChange(FsChangeRemove, storage, path, "")
+	}
 	return errors.WithStack(err)
 }
 
@@ -513,11 +1017,28 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	defer func() {
 		if err := file.Close(); err != nil {
 			log.Errorf("failed to close file streamer, %v", err)
 		}
 	}()
+	if err := LimitUploadSize(storage, file); err != nil {
+		return err
+	}
+	// all the puts are counted against the upload quota here, the tasks carry the user of their creator
+	ctx, quotaUser, releaseQuota, err := reserveUploadOf(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer releaseQuota()
+	if name := normalizeName(storage, file.GetName()); name != file.GetName() {
+		if s, ok := file.(interface{ SetName(string) }); ok {
+			s.SetName(name)
+		}
+	}
 	// UrlTree PUT
 	if storage.GetStorage().Driver == "UrlTree" {
 		var link string
@@ -527,10 +1048,34 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	// if file exist and size = 0, delete it
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	dstPath := stdpath.Join(dstDirPath, file.GetName())
+	unlock, err := lockPutPath(ctx, storage, dstPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	tempName := file.GetName() + ".alist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
-	if err == nil {
+	if err == nil && stream.SkipIfMatch(file) && sameContent(file, fi) {
+		log.Debugf("skip put file [%s], the existing one has the same content", dstPath)
+		return nil
+	}
+	conflict := stream.Conflict(file)
+	if err == nil && conflict == stream.ConflictSkip {
+		log.Debugf("skip put file [%s], it exists", dstPath)
+		return nil
+	}
+	if err == nil && conflict == stream.ConflictNewer && !fi.IsDir() && !NewerOrChanged(file, fi) {
+		log.Debugf("skip put file [%s], the existing one is the same or newer", dstPath)
+		return nil
+	}
+	if err == nil && conflict == stream.ConflictRename {
+		// the driver names the uploaded file, the existing one is untouched
+		if !storage.Config().RenameUpload {
+			return errors.WithMessage(errs.NotSupport, "keep both files on upload conflict")
+		}
+		fi = nil
+	} else if err == nil {
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
@@ -560,26 +1105,55 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		up = func(p float64) {}
 	}
 
-	switch s := storage.(type) {
-	case driver.PutResult:
-		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
-		if err == nil {
-			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
-			} else if !utils.IsBool(lazyCache...) {
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	rapidObj, rapid := putRapid(ctx, storage, parentDir, file)
+	ctx, cancel := withTimeout(ctx, storage, "put")
+	defer cancel()
+	done := metrics.Observe(storage, "put")
+	newObj := rapidObj
+	if rapid {
+		if rapidObj != nil {
+			addCacheObj(storage, dstDirPath, wrapObjName(storage, rapidObj))
+		} else if !utils.IsBool(lazyCache...) {
+			ClearCache(storage, dstDirPath)
+		}
+	} else {
+		switch s := storage.(type) {
+		case driver.PutResult:
+			newObj, err = s.Put(ctx, parentDir, file, up)
+			if err == nil {
+				if newObj != nil {
+					addCacheObj(storage, dstDirPath, wrapObjName(storage, newObj))
+				} else if !utils.IsBool(lazyCache...) {
+					ClearCache(storage, dstDirPath)
+				}
+			}
+		case driver.Put:
+			err = s.Put(ctx, parentDir, file, up)
+			if err == nil && !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
+		default:
+			release()
+			return errs.NotImplement
 		}
-	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
-		if err == nil && !utils.IsBool(lazyCache...) {
-			ClearCache(storage, dstDirPath)
-		}
-	default:
-		return errs.NotImplement
 	}
+	done(err)
+	if err == nil && !rapid {
+		metrics.AddUploadBytes(storage, file.GetSize())
+	}
+	release()
 	log.Debugf("put file [%s] done", file.GetName())
+	if err == nil {
+		if quotaUser != nil {
+			RecordUpload(quotaUser, utils.GetFullPath(storage.GetStorage().MountPath, dstPath),
+				uploadedSize(ctx, storage, dstPath, file, newObj))
+		}
+		handleFsChange(FsChangeCreate, storage, dstPath, "")
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -601,11 +1175,74 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	return errors.WithStack(err)
 }
 
+// sameContent reports whether file has the same size as obj and all the hashes of the types both have are the same,
+// it's false if they have no hash of the same type to compare. A hash not of the width of its type isn't compared
+func sameContent(file model.FileStreamer, obj model.Obj) bool {
+	if file.GetSize() != obj.GetSize() || obj.IsDir() {
+		return false
+	}
+	objHash := obj.GetHash()
+	compared := false
+	for ht, v := range file.GetHash().All() {
+		if w := objHash.GetHash(ht); len(v) == ht.Width && len(w) == ht.Width {
+			if !strings.EqualFold(v, w) {
+				return false
+			}
+			compared = true
+		}
+	}
+	return compared
+}
+
+// NewerOrChanged tells whether src should replace the existing dst by stream.ConflictNewer: src is newer by the mtime
+// in seconds, or their sizes or the hashes of a common type differ. A src without the mtime is always newer
+func NewerOrChanged(src, dst model.Obj) bool {
+	if src.ModTime().IsZero() || src.ModTime().Unix() > dst.ModTime().Unix() || src.GetSize() != dst.GetSize() {
+		return true
+	}
+	dstHash := dst.GetHash()
+	for ht, v := range src.GetHash().All() {
+		if w := dstHash.GetHash(ht); len(v) == ht.Width && len(w) == ht.Width {
+			return !strings.EqualFold(v, w)
+		}
+	}
+	return false
+}
+
+// putRapid tries the rapid upload of the driver if the stream carries all the hashes it needs,
+// it reports false if the driver doesn't support it or the upload should fall back to Put
+func putRapid(ctx context.Context, storage driver.Driver, parentDir model.Obj, file model.FileStreamer) (model.Obj, bool) {
+	r, ok := storage.(driver.PutRapid)
+	if !ok || file.GetSize() <= 0 {
+		return nil, false
+	}
+	hash := file.GetHash()
+	for _, ht := range r.RapidHashTypes() {
+		if len(hash.GetHash(ht)) != ht.Width {
+			return nil, false
+		}
+	}
+	ctx, cancel := withTimeout(ctx, storage, "put_rapid")
+	defer cancel()
+	done := metrics.Observe(storage, "put_rapid")
+	newObj, err := r.PutRapid(ctx, parentDir, file)
+	done(err)
+	if err != nil {
+		log.Debugf("rapid upload of [%s] failed, fallback to put: %v", file.GetName(), err)
+		return nil, false
+	}
+	return newObj, true
+}
+
 func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url string, lazyCache ...bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
+	dstName = normalizeName(storage, dstName)
 	_, err := GetUnwrap(ctx, storage, stdpath.Join(dstDirPath, dstName))
 	if err == nil {
 		return errors.New("obj already exists")
@@ -618,13 +1255,20 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if err != nil {
 		return errors.WithMessagef(err, "failed to put url")
 	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "put_url")
+	defer cancel()
+	done := metrics.Observe(storage, "put_url")
 	switch s := storage.(type) {
 	case driver.PutURLResult:
 		var newObj model.Obj
 		newObj, err = s.PutURL(ctx, dstDir, dstName, url)
 		if err == nil {
 			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				addCacheObj(storage, dstDirPath, wrapObjName(storage, newObj))
 			} else if !utils.IsBool(lazyCache...) {
 				ClearCache(storage, dstDirPath)
 			}
@@ -635,8 +1279,14 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 			ClearCache(storage, dstDirPath)
 		}
 	default:
+		release()
 		return errs.NotImplement
 	}
+	done(err)
+	release()
 	log.Debugf("put url [%s](%s) done", dstName, url)
+	if err == nil {
+		handleFsChange(FsChangeCreate, storage, stdpath.Join(dstDirPath, dstName), "")
+	}
 	return errors.WithStack(err)
 }
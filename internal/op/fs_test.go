@@ -0,0 +1,37 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestNewerOrChanged(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	md5a := utils.NewHashInfo(utils.MD5, "0cc175b9c0f1b6a831c399e269772661")
+	md5b := utils.NewHashInfo(utils.MD5, "92eb5ffee6ae2fec3ad71c777531578f")
+	sha1 := utils.NewHashInfo(utils.SHA1, "86f7e437faa5a7fce15d1ddcb9eaeaea377667b8")
+	datas := []struct {
+		name     string
+		src, dst model.Object
+		result   bool
+	}{
+		{"same", model.Object{Size: 1, Modified: now}, model.Object{Size: 1, Modified: now}, false},
+		{"newer", model.Object{Size: 1, Modified: now.Add(time.Second)}, model.Object{Size: 1, Modified: now}, true},
+		{"older", model.Object{Size: 1, Modified: now}, model.Object{Size: 1, Modified: now.Add(time.Hour)}, false},
+		{"newer within a second", model.Object{Size: 1, Modified: now.Add(500 * time.Millisecond)}, model.Object{Size: 1, Modified: now}, false},
+		{"no mtime", model.Object{Size: 1}, model.Object{Size: 1, Modified: now}, true},
+		{"size changed", model.Object{Size: 2, Modified: now}, model.Object{Size: 1, Modified: now.Add(time.Hour)}, true},
+		{"hash changed", model.Object{Size: 1, Modified: now, HashInfo: md5a}, model.Object{Size: 1, Modified: now, HashInfo: md5b}, true},
+		{"hash same", model.Object{Size: 1, Modified: now, HashInfo: md5a}, model.Object{Size: 1, Modified: now, HashInfo: md5a}, false},
+		{"no common hash", model.Object{Size: 1, Modified: now, HashInfo: md5a}, model.Object{Size: 1, Modified: now, HashInfo: sha1}, false},
+	}
+	for _, data := range datas {
+		if got := op.NewerOrChanged(&data.src, &data.dst); got != data.result {
+			t.Errorf("%s: NewerOrChanged = %v, expect %v", data.name, got, data.result)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthFailed   = "failed"
+)
+
+type HealthCheck struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Skipped  bool   `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration"` // milliseconds
+}
+
+type StorageHealth struct {
+	ID        uint          `json:"id"`
+	MountPath string        `json:"mount_path"`
+	Driver    string        `json:"driver"`
+	Status    string        `json:"status"`
+	Checks    []HealthCheck `json:"checks"`
+}
+
+func runHealthCheck(name string, fn func() error) HealthCheck {
+	start := time.Now()
+	err := fn()
+	c := HealthCheck{Name: name, OK: err == nil, Duration: time.Since(start).Milliseconds()}
+	if errors.Is(err, errs.NotImplement) {
+		c.OK, c.Skipped = true, true
+	} else if err != nil {
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// CheckStorageHealth runs a read-only self-test of the storage: list the root, get the link of a file in it
+// and get the quota. It's failed if the root can't be listed, and degraded if any other check fails.
+func CheckStorageHealth(ctx context.Context, storage driver.Driver, timeout time.Duration) *StorageHealth {
+	s := storage.GetStorage()
+	h := &StorageHealth{ID: s.ID, MountPath: s.MountPath, Driver: s.Driver, Status: HealthOK}
+	if storage.Config().CheckStatus && s.Status != WORK {
+		h.Status = HealthFailed
+		h.Checks = append(h.Checks, HealthCheck{Name: "status", Error: s.Status})
+		return h
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var objs []model.Obj
+	list := runHealthCheck("list", func() (err error) {
+		// refresh to really call the driver instead of reading the cache
+		objs, err = List(ctx, storage, "/", model.ListArgs{Refresh: true})
+		return err
+	})
+	h.Checks = append(h.Checks, list)
+	if !list.OK {
+		h.Status = HealthFailed
+		return h
+	}
+	h.Checks = append(h.Checks, runHealthCheck("link", func() error {
+		for _, obj := range objs {
+			if !obj.IsDir() {
+				_, _, err := Link(ctx, storage, stdpath.Join("/", obj.GetName()), model.LinkArgs{})
+				return err
+			}
+		}
+		// no file to test in the root
+		return errs.NotImplement
+	}))
+	h.Checks = append(h.Checks, runHealthCheck("details", func() error {
+		_, err := GetStorageDetails(ctx, storage)
+		return err
+	}))
+	for _, c := range h.Checks {
+		if !c.OK {
+			h.Status = HealthDegraded
+		}
+	}
+	return h
+}
@@ -1,6 +1,7 @@
 package op
 
 import (
+	stdpath "path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +31,37 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+// FsChange
+const (
+	// FsChangeCreate the obj at path is created or overwritten, including a new dir
+	FsChangeCreate = "create"
+	// FsChangeRemove the obj at path is removed
+	FsChangeRemove = "remove"
+	// FsChangeMove the obj at path is moved or renamed to dstPath
+	FsChangeMove = "move"
+)
+
+// FsChangeHook is called after a successful write through op, the paths are mount paths
+type FsChangeHook = func(typ, path, dstPath string)
+
+var fsChangeHooks = make([]FsChangeHook, 0)
+
+func RegisterFsChangeHook(hook FsChangeHook) {
+	fsChangeHooks = append(fsChangeHooks, hook)
+}
+
+func handleFsChange(typ string, storage driver.Driver, actualPath, dstActualPath string) {
+	mountPath := storage.GetStorage().MountPath
+	path := stdpath.Join(mountPath, actualPath)
+	dstPath := ""
+	if dstActualPath != "" {
+		dstPath = stdpath.Join(mountPath, dstActualPath)
+	}
+	for _, hook := range fsChangeHooks {
+		hook(typ, path, dstPath)
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -79,6 +111,23 @@ var settingItemHooks = map[string]SettingItemHook{
 		log.Debugf("filename char mapping: %+v", conf.FilenameCharMap)
 		return nil
 	},
+	conf.MimeTypes: func(item *model.SettingItem) error {
+		m := make(map[string]string)
+		err := utils.Json.UnmarshalFromString(item.Value, &m)
+		if err != nil {
+			return err
+		}
+		mimeTypes := make(map[string]string, len(m))
+		for ext, t := range m {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			mimeTypes[ext] = strings.TrimSpace(t)
+		}
+		conf.MimeTypesMap = mimeTypes
+		return nil
+	},
 	conf.IgnoreDirectLinkParams: func(item *model.SettingItem) error {
 		conf.SlicesMap[conf.IgnoreDirectLinkParams] = strings.Split(item.Value, ",")
 		return nil
@@ -0,0 +1,59 @@
+package op
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// GetByID gets the obj by its id by driver.GetByID without resolving its path, path is where it's in the storage.
+// errs.NotImplement if the driver isn't one
+func GetByID(ctx context.Context, storage driver.Driver, id string) (model.Obj, string, error) {
+	obj, path, err := getByID(ctx, storage, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapObjName(storage, obj), path, nil
+}
+
+// LinkByID gets the link of the file by its id like Link, the file is got by GetByID
+func LinkByID(ctx context.Context, storage driver.Driver, id string, args model.LinkArgs) (*model.Link, model.Obj, string, error) {
+	file, path, err := getByID(ctx, storage, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if file.IsDir() {
+		return nil, nil, "", errors.WithStack(errs.NotFile)
+	}
+	link, err := linkFile(ctx, storage, path, file, args)
+	return link, wrapObjName(storage, file), path, err
+}
+
+func getByID(ctx context.Context, storage driver.Driver, id string) (model.Obj, string, error) {
+	g, ok := storage.(driver.GetByID)
+	if !ok {
+		return nil, "", errs.NotImplement
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, "", errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "get_by_id")
+	defer cancel()
+	done := metrics.Observe(storage, "get_by_id")
+	obj, path, err := g.GetByID(ctx, id)
+	done(err)
+	release()
+	if err != nil {
+		return nil, "", errors.WithMessagef(err, "failed get obj by id [%s]", id)
+	}
+	return obj, utils.FixAndCleanPath(path), nil
+}
@@ -0,0 +1,71 @@
+package op
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+)
+
+type storageLimiter struct {
+	limit int
+	sem   chan struct{}
+}
+
+var storageLimiters generic_sync.MapOf[uint, *storageLimiter]
+
+func getStorageLimiter(storage driver.Driver) *storageLimiter {
+	s := storage.GetStorage()
+	if s.MaxConcurrency <= 0 {
+		return nil
+	}
+	l, _ := storageLimiters.LoadOrStore(s.ID, &storageLimiter{
+		limit: s.MaxConcurrency,
+		sem:   make(chan struct{}, s.MaxConcurrency),
+	})
+	if l.limit != s.MaxConcurrency {
+		// the limit has been changed, calls holding the old one will release to it
+		l = &storageLimiter{
+			limit: s.MaxConcurrency,
+			sem:   make(chan struct{}, s.MaxConcurrency),
+		}
+		storageLimiters.Store(s.ID, l)
+	}
+	return l
+}
+
+// heldStorages are the storages whose slots are held by the call of a ctx, so that the nested calls of
+// the same storage, e.g. of a driver calling op, don't wait for a slot held by themselves
+type heldStorages struct {
+	id     uint
+	parent *heldStorages
+}
+
+type heldStoragesKey struct{}
+
+func holdsStorage(ctx context.Context, id uint) bool {
+	for h, _ := ctx.Value(heldStoragesKey{}).(*heldStorages); h != nil; h = h.parent {
+		if h.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// acquireStorage waits until the storage has a free slot for a driver call, unless ctx already holds one
+// of the storage. The returned ctx marks the slot as held and must be passed to the driver,
+// the returned func must be called to release the slot
+func acquireStorage(ctx context.Context, storage driver.Driver) (context.Context, func(), error) {
+	l := getStorageLimiter(storage)
+	id := storage.GetStorage().ID
+	if l == nil || holdsStorage(ctx, id) {
+		return ctx, func() {}, nil
+	}
+	select {
+	case l.sem <- struct{}{}:
+		parent, _ := ctx.Value(heldStoragesKey{}).(*heldStorages)
+		return context.WithValue(ctx, heldStoragesKey{}, &heldStorages{id: id, parent: parent}), func() { <-l.sem }, nil
+	case <-ctx.Done():
+		return ctx, nil, ctx.Err()
+	}
+}
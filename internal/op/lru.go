@@ -0,0 +1,154 @@
+package op
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// ListCacheStats is the statistics of the list cache
+type ListCacheStats struct {
+	Entries    int    `json:"entries"`
+	Objects    int    `json:"objects"`
+	MaxEntries int    `json:"max_entries"`
+	MaxObjects int    `json:"max_objects"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+	Evictions  uint64 `json:"evictions"`
+}
+
+type listCacheEntry struct {
+	key    string
+	objs   []model.Obj
+	expire time.Time
+}
+
+// lruListCache caches the objs of dirs, the least recently used dirs are evicted first
+// if the count of dirs or the total count of objs exceeds the limits of conf.Conf.ListCache
+type lruListCache struct {
+	mu        sync.Mutex
+	ll        *list.List
+	items     map[string]*list.Element
+	objects   int
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newLRUListCache() *lruListCache {
+	return &lruListCache{
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func listCacheLimits() (maxEntries, maxObjects int) {
+	if conf.Conf == nil {
+		return 0, 0
+	}
+	return conf.Conf.ListCache.MaxEntries, conf.Conf.ListCache.MaxObjects
+}
+
+func listCacheStale() time.Duration {
+	if conf.Conf == nil {
+		return 0
+	}
+	return time.Duration(conf.Conf.ListCache.StaleSeconds) * time.Second
+}
+
+func (c *lruListCache) Get(key string) ([]model.Obj, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	entry := e.Value.(*listCacheEntry)
+	if !time.Now().Before(entry.expire) {
+		// an expired entry is kept until it's too stale to be a fallback
+		if !time.Now().Before(entry.expire.Add(listCacheStale())) {
+			c.remove(e)
+		}
+		c.misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	c.hits++
+	return entry.objs, true
+}
+
+// Set cache the objs for ttl, it's not cached if the objs alone exceed the max objects
+func (c *lruListCache) Set(key string, objs []model.Obj, ttl time.Duration) {
+	maxEntries, maxObjects := listCacheLimits()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	if maxObjects > 0 && len(objs) > maxObjects {
+		return
+	}
+	c.items[key] = c.ll.PushFront(&listCacheEntry{
+		key:    key,
+		objs:   objs,
+		expire: time.Now().Add(ttl),
+	})
+	c.objects += len(objs)
+	for c.ll.Len() > 1 && ((maxEntries > 0 && c.ll.Len() > maxEntries) || (maxObjects > 0 && c.objects > maxObjects)) {
+		c.remove(c.ll.Back())
+		c.evictions++
+	}
+}
+
+// GetStale returns the objs even if they are expired, as long as they are within the stale window
+func (c *lruListCache) GetStale(key string) ([]model.Obj, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*listCacheEntry)
+	if !time.Now().Before(entry.expire.Add(listCacheStale())) {
+		return nil, false
+	}
+	return entry.objs, true
+}
+
+func (c *lruListCache) Del(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+}
+
+func (c *lruListCache) remove(e *list.Element) {
+	entry := c.ll.Remove(e).(*listCacheEntry)
+	delete(c.items, entry.key)
+	c.objects -= len(entry.objs)
+}
+
+func (c *lruListCache) Stats() ListCacheStats {
+	maxEntries, maxObjects := listCacheLimits()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ListCacheStats{
+		Entries:    c.ll.Len(),
+		Objects:    c.objects,
+		MaxEntries: maxEntries,
+		MaxObjects: maxObjects,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+}
+
+// GetListCacheStats returns the statistics of the list cache, for tuning its size
+func GetListCacheStats() ListCacheStats {
+	return listCache.Stats()
+}
@@ -0,0 +1,67 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// listByName filters the objs of List, so that the listing is cached and the filters of the same dir are served
+// from the cache. driver.SearchName is only used for the recursive ones, which would walk the whole tree
+func listByName(ctx context.Context, storage driver.Driver, path string, args model.ListArgs) ([]model.Obj, error) {
+	filter := args.NameFilter
+	args.NameFilter = nil
+	objs, err := List(ctx, storage, path, args)
+	if err != nil {
+		return nil, err
+	}
+	return model.FilterByName(objs, filter), nil
+}
+
+// SearchName calls driver.SearchName, it returns errs.NotImplement if the driver doesn't support it
+func SearchName(ctx context.Context, storage driver.Driver, path, keyword string, recursive bool) ([]model.Obj, error) {
+	s, ok := storage.(driver.SearchName)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	log.Debugf("op.SearchName %s, keyword: %s, recursive: %t", path, keyword, recursive)
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "search_name")
+	defer cancel()
+	done := metrics.Observe(storage, "search_name")
+	files, err := s.SearchName(ctx, dir, keyword, recursive)
+	done(err)
+	release()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to search objs")
+	}
+	for _, f := range files {
+		if s, ok := f.(model.SetPath); ok && f.GetPath() == "" && dir.GetPath() != "" {
+			s.SetPath(stdpath.Join(dir.GetPath(), f.GetName()))
+		}
+	}
+	wrapObjsName(storage, files)
+	model.ExtractFolder(files, extractFolder(storage))
+	return files, nil
+}
@@ -0,0 +1,33 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// normalizeName converts the name or the path from a request to the normalization form of the storage,
+// see model.Storage.NameNormalization
+func normalizeName(storage driver.Driver, name string) string {
+	return model.NormalizeName(storage.GetStorage().NameNormalization, name)
+}
+
+// wrapObjName is model.WrapObjName with the name converted to the normalization form of the storage,
+// so that the listed names match the normalized names of the requests
+func wrapObjName(storage driver.Driver, obj model.Obj) model.Obj {
+	w := model.WrapObjName(obj)
+	if storage.GetStorage().NameNormalization != model.NameNormNone {
+		w.(*model.ObjWrapName).Name = normalizeName(storage, w.GetName())
+	}
+	return w
+}
+
+// wrapObjsName is model.WrapObjsName with the names converted to the normalization form of the storage
+func wrapObjsName(storage driver.Driver, objs []model.Obj) {
+	model.WrapObjsName(objs)
+	if storage.GetStorage().NameNormalization == model.NameNormNone {
+		return
+	}
+	for _, obj := range objs {
+		obj.(*model.ObjWrapName).Name = normalizeName(storage, obj.GetName())
+	}
+}
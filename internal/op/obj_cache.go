@@ -0,0 +1,49 @@
+package op
+
+import (
+	stdpath "path"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// objCache keeps the objs of the dirs listed moments ago by name, so that a Get right after the List,
+// as Link and Remove often do, is served without another request to the storage.
+// Any mutation in a dir drops the objs of the dir
+var objCache = cache.NewMemCache[map[string]model.Obj]()
+
+func objCacheTTL() time.Duration {
+	if conf.Conf == nil {
+		return 0
+	}
+	return time.Duration(conf.Conf.ListCache.ObjSeconds) * time.Second
+}
+
+func cacheObjs(storage driver.Driver, dirPath string, objs []model.Obj) {
+	ttl := objCacheTTL()
+	if ttl <= 0 {
+		return
+	}
+	m := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+	}
+	objCache.Set(Key(storage, dirPath), m, cache.WithEx[map[string]model.Obj](ttl))
+}
+
+func getCachedObj(storage driver.Driver, path string) (model.Obj, bool) {
+	dir, name := stdpath.Split(path)
+	objs, ok := objCache.Get(Key(storage, dir))
+	if !ok {
+		return nil, false
+	}
+	obj, ok := objs[name]
+	return obj, ok
+}
+
+func delCachedObjs(storage driver.Driver, dirPath string) {
+	objCache.Del(Key(storage, dirPath))
+}
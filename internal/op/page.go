@@ -0,0 +1,128 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// cachedCursorPrefix marks the cursor of a ListPager as an offset in the full listing,
+// which is used when the first page is served from the list cache
+const cachedCursorPrefix = "cached:"
+
+// ListPage list a page of the dir. If the driver doesn't implement driver.ListPager,
+// the objs of List are returned in pages, and the cursor is the offset.
+// If the full listing of the dir is cached, the pages are sliced from it as well
+func ListPage(ctx context.Context, storage driver.Driver, path string, args model.ListPageArgs) ([]model.Obj, string, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, "", errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	pager, ok := storage.(driver.ListPager)
+	// the objs must be sorted locally, so all of them are needed
+	if !ok || storage.Config().LocalSort {
+		return listPageOfAll(ctx, storage, path, args, "")
+	}
+	if offset, ok := strings.CutPrefix(args.Cursor, cachedCursorPrefix); ok {
+		// the following pages of the cached listing, it's listed again if the cache has expired
+		args.Cursor = offset
+		return listPageOfAll(ctx, storage, path, args, cachedCursorPrefix)
+	}
+	if args.Cursor == "" && !args.Refresh {
+		_, ok := listCache.Get(Key(storage, path))
+		metrics.CacheLookup(storage, "list_page", ok)
+		if ok {
+			return listPageOfAll(ctx, storage, path, args, cachedCursorPrefix)
+		}
+	}
+	log.Debugf("op.ListPage %s, cursor: %s", path, args.Cursor)
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return nil, "", errors.WithStack(errs.NotFolder)
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := withTimeout(ctx, storage, "list_page")
+	defer cancel()
+	done := metrics.Observe(storage, "list_page")
+	files, next, err := pager.ListPage(ctx, dir, args)
+	done(err)
+	release()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to list objs")
+	}
+	for _, f := range files {
+		if s, ok := f.(model.SetPath); ok && f.GetPath() == "" && dir.GetPath() != "" {
+			s.SetPath(stdpath.Join(dir.GetPath(), f.GetName()))
+		}
+	}
+	wrapObjsName(storage, files)
+	model.ExtractFolder(files, extractFolder(storage))
+	return files, next, nil
+}
+
+// ListCursor returns the cursor of ListPage to list the objs of the dir after the first offset ones of List
+func ListCursor(storage driver.Driver, offset int) string {
+	if _, ok := storage.(driver.ListPager); ok && !storage.Config().LocalSort {
+		return cachedCursorPrefix + strconv.Itoa(offset)
+	}
+	return strconv.Itoa(offset)
+}
+
+// MaxListEntries returns the max objs of a dir of the storage listed at once, 0 for unlimited.
+// A larger dir should be listed by ListPage
+func MaxListEntries(storage driver.Driver) int {
+	switch n := storage.GetStorage().MaxListEntries; {
+	case n < 0:
+		return 0
+	case n == 0:
+		return DefaultMaxListEntries
+	default:
+		return n
+	}
+}
+
+// listPageOfAll slices the page from the objs of List, the cursor is the offset with the prefix
+func listPageOfAll(ctx context.Context, storage driver.Driver, path string, args model.ListPageArgs, prefix string) ([]model.Obj, string, error) {
+	// only refresh with the first page, the following pages are sliced from the same list
+	objs, err := List(ctx, storage, path, model.ListArgs{
+		ReqPath: args.ReqPath,
+		Refresh: args.Refresh && args.Cursor == "",
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	start := 0
+	if args.Cursor != "" {
+		start, err = strconv.Atoi(args.Cursor)
+		if err != nil || start < 0 {
+			return nil, "", errors.Errorf("invalid cursor: %s", args.Cursor)
+		}
+	}
+	if start >= len(objs) {
+		return []model.Obj{}, "", nil
+	}
+	end := len(objs)
+	if args.Limit > 0 && start+args.Limit < end {
+		end = start + args.Limit
+	}
+	next := ""
+	if end < len(objs) {
+		next = prefix + strconv.Itoa(end)
+	}
+	return objs[start:end], next, nil
+}
@@ -14,6 +14,10 @@ import (
 // for path: remove the mount path prefix and join the actual root folder if exists
 func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath string, err error) {
 	rawPath = utils.FixAndCleanPath(rawPath)
+	// a path in a shortcut is the one in its target, for the reads and the writes
+	if rawPath, err = ResolveShortcut(rawPath); err != nil {
+		return
+	}
 	storage = GetBalancedStorage(rawPath)
 	if storage == nil {
 		if rawPath == "/" {
@@ -25,7 +29,7 @@ func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath
 	}
 	log.Debugln("use storage: ", storage.GetStorage().MountPath)
 	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
-	actualPath = utils.FixAndCleanPath(strings.TrimPrefix(rawPath, mountPath))
+	actualPath = normalizeName(storage, utils.FixAndCleanPath(strings.TrimPrefix(rawPath, mountPath)))
 	return
 }
 
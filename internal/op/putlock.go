@@ -0,0 +1,63 @@
+package op
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/pkg/errors"
+)
+
+type pathLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+var (
+	pathLocksMu sync.Mutex
+	pathLocks   = make(map[string]*pathLock)
+)
+
+// lockPutPath makes the uploads to the same path of a storage one by one, since most drivers
+// can't handle the concurrent uploads of a file. The second upload waits for the first one,
+// or fails at once if the put_conflict_policy is fail. The returned func must be called to unlock.
+func lockPutPath(ctx context.Context, storage driver.Driver, path string) (func(), error) {
+	key := Key(storage, path)
+	pathLocksMu.Lock()
+	l, ok := pathLocks[key]
+	if !ok {
+		l = &pathLock{ch: make(chan struct{}, 1)}
+		pathLocks[key] = l
+	}
+	l.refs++
+	pathLocksMu.Unlock()
+	unref := func() {
+		pathLocksMu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(pathLocks, key)
+		}
+		pathLocksMu.Unlock()
+	}
+	if item, _ := GetSettingItemByKey(conf.PutConflictPolicy); item != nil && item.Value == "fail" {
+		select {
+		case l.ch <- struct{}{}:
+		default:
+			unref()
+			return nil, errors.WithMessagef(errs.UploadInProgress, "[%s]", key)
+		}
+	} else {
+		select {
+		case l.ch <- struct{}{}:
+		case <-ctx.Done():
+			unref()
+			return nil, ctx.Err()
+		}
+	}
+	return func() {
+		<-l.ch
+		unref()
+	}, nil
+}
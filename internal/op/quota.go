@@ -0,0 +1,118 @@
+package op
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	uploadQuotaMu  sync.Mutex
+	uploadReserved = make(map[uint]int64) // bytes of the running uploads of each user
+)
+
+// ReserveUploadQuota reserves size bytes of the upload quota of user until release is called, so that concurrent
+// uploads can't exceed it together. An unknown size (< 0) is allowed if the quota isn't used up
+func ReserveUploadQuota(user *model.User, size int64) (release func(), err error) {
+	if user == nil || user.UploadQuota <= 0 {
+		return func() {}, nil
+	}
+	uploadQuotaMu.Lock()
+	defer uploadQuotaMu.Unlock()
+	used, _, err := db.GetUploadUsage(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	used += uploadReserved[user.ID]
+	if used+max(size, 0) > user.UploadQuota || size < 0 && used >= user.UploadQuota {
+		return nil, errors.Wrapf(errs.UploadQuotaExceeded, "%d of %d bytes used", used, user.UploadQuota)
+	}
+	if size <= 0 {
+		return func() {}, nil
+	}
+	uploadReserved[user.ID] += size
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			uploadQuotaMu.Lock()
+			defer uploadQuotaMu.Unlock()
+			if uploadReserved[user.ID] -= size; uploadReserved[user.ID] <= 0 {
+				delete(uploadReserved, user.ID)
+			}
+		})
+	}, nil
+}
+
+type quotaCountedKey struct{}
+
+// reserveUploadOf reserves the upload quota of the user of ctx for file, the returned ctx marks the quota as counted,
+// so that a put nested in the driver, e.g. of crypt or alias, isn't counted again. user is nil if nothing is counted
+func reserveUploadOf(ctx context.Context, file model.FileStreamer) (context.Context, *model.User, func(), error) {
+	user, _ := ctx.Value("user").(*model.User)
+	if user == nil || ctx.Value(quotaCountedKey{}) != nil {
+		return ctx, nil, func() {}, nil
+	}
+	release, err := ReserveUploadQuota(user, file.GetSize())
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	return context.WithValue(ctx, quotaCountedKey{}, struct{}{}), user, release, nil
+}
+
+// uploadedSize is the size of the put file, a stream of an unknown size is counted by the uploaded obj
+func uploadedSize(ctx context.Context, storage driver.Driver, dstPath string, file model.FileStreamer, newObj model.Obj) int64 {
+	if file.GetSize() >= 0 {
+		return file.GetSize()
+	}
+	if newObj == nil {
+		obj, err := GetUnwrap(ctx, storage, dstPath)
+		if err != nil {
+			log.Warnf("failed get the uploaded [%s] to count its size: %+v", dstPath, err)
+			return 0
+		}
+		newObj = obj
+	}
+	return newObj.GetSize()
+}
+
+// CheckUploadQuota checks whether user can upload size more bytes
+func CheckUploadQuota(user *model.User, size int64) error {
+	release, err := ReserveUploadQuota(user, size)
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// RecordUpload counts the uploaded file of path against the upload quota of user
+func RecordUpload(user *model.User, path string, size int64) {
+	if user == nil || size < 0 {
+		return
+	}
+	if err := db.SaveUploadRecord(&model.UploadRecord{UserID: user.ID, Path: path, Size: size}); err != nil {
+		log.Errorf("failed record upload of [%s] by %s: %+v", path, user.Username, err)
+	}
+}
+
+// CreditRemoved gives the quota used by path and the files under it back to the uploaders.
+// Files moved or renamed after uploading are not tracked
+func CreditRemoved(path string) {
+	if err := db.DeleteUploadRecordsUnder(path); err != nil {
+		log.Errorf("failed credit upload quota of [%s]: %+v", path, err)
+	}
+}
+
+func GetUploadUsage(user *model.User) (*model.UploadUsage, error) {
+	used, files, err := db.GetUploadUsage(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UploadUsage{Quota: user.UploadQuota, Used: used, Files: files}, nil
+}
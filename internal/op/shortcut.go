@@ -0,0 +1,174 @@
+package op
+
+import (
+	stdpath "path"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// MaxShortcutFollow is how many shortcuts a path can go through before errs.ShortcutLoop
+const MaxShortcutFollow = 8
+
+// the shortcuts are few, all of them are kept in memory once loaded
+var (
+	shortcutsMu     sync.RWMutex
+	shortcuts       []model.Shortcut
+	shortcutsLoaded bool
+)
+
+func GetShortcuts() ([]model.Shortcut, error) {
+	shortcutsMu.RLock()
+	if shortcutsLoaded {
+		defer shortcutsMu.RUnlock()
+		return shortcuts, nil
+	}
+	shortcutsMu.RUnlock()
+	shortcutsMu.Lock()
+	defer shortcutsMu.Unlock()
+	if !shortcutsLoaded {
+		s, err := db.GetShortcuts()
+		if err != nil {
+			return nil, err
+		}
+		shortcuts, shortcutsLoaded = s, true
+	}
+	return shortcuts, nil
+}
+
+func invalidateShortcuts() {
+	shortcutsMu.Lock()
+	defer shortcutsMu.Unlock()
+	shortcuts, shortcutsLoaded = nil, false
+}
+
+// matchShortcut returns the deepest shortcut which is path or contains it
+func matchShortcut(all []model.Shortcut, path string) *model.Shortcut {
+	var res *model.Shortcut
+	for i, s := range all {
+		if utils.IsSubPath(s.Path, path) && (res == nil || len(s.Path) > len(res.Path)) {
+			res = &all[i]
+		}
+	}
+	return res
+}
+
+// ResolveShortcut replaces the shortcut which path is in with its target, until path isn't in any shortcut.
+// errs.ShortcutLoop is returned if the shortcuts refer to each other or there are too many levels
+func ResolveShortcut(path string) (string, error) {
+	path = utils.FixAndCleanPath(path)
+	all, err := GetShortcuts()
+	if err != nil || len(all) == 0 {
+		return path, err
+	}
+	visited := make(map[string]struct{})
+	for i := 0; i < MaxShortcutFollow; i++ {
+		s := matchShortcut(all, path)
+		if s == nil {
+			return path, nil
+		}
+		if _, ok := visited[s.Path]; ok {
+			break
+		}
+		visited[s.Path] = struct{}{}
+		path = stdpath.Join(s.Target, strings.TrimPrefix(path, s.Path))
+	}
+	if matchShortcut(all, path) == nil {
+		return path, nil
+	}
+	return "", errors.WithStack(errs.ShortcutLoop)
+}
+
+// IsShortcut reports whether path is a shortcut itself, its target is reached by GetStorageAndActualPath
+func IsShortcut(path string) bool {
+	path = utils.FixAndCleanPath(path)
+	all, _ := GetShortcuts()
+	for _, s := range all {
+		if s.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// GetShortcutsByPath returns the shortcuts right in dir, and the virtual dirs in it which contain deeper shortcuts,
+// like GetStorageVirtualFilesByPath
+func GetShortcutsByPath(dir string) ([]model.Shortcut, []model.Obj) {
+	all, err := GetShortcuts()
+	if err != nil {
+		return nil, nil
+	}
+	dir = utils.FixAndCleanPath(dir)
+	var res []model.Shortcut
+	var dirs []model.Obj
+	names := make(map[string]struct{})
+	for _, s := range all {
+		if s.Path == dir || !utils.IsSubPath(dir, s.Path) {
+			continue
+		}
+		name, rest, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(s.Path, dir), "/"), "/")
+		if rest == "" {
+			res = append(res, s)
+			continue
+		}
+		if _, ok := names[name]; !ok {
+			names[name] = struct{}{}
+			dirs = append(dirs, &model.Object{Name: name, IsFolder: true})
+		}
+	}
+	return res, dirs
+}
+
+// reachesPath tells whether path can be reached under target, directly or through the shortcuts under it
+func reachesPath(all []model.Shortcut, target, path string, visited map[string]struct{}) bool {
+	if utils.IsSubPath(target, path) {
+		return true
+	}
+	for _, s := range all {
+		if _, ok := visited[s.Path]; ok || !utils.IsSubPath(target, s.Path) {
+			continue
+		}
+		visited[s.Path] = struct{}{}
+		next, err := ResolveShortcut(s.Target)
+		if err == nil && reachesPath(all, next, path, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func CreateShortcut(s *model.Shortcut) error {
+	s.Path, s.Target = utils.FixAndCleanPath(s.Path), utils.FixAndCleanPath(s.Target)
+	if s.Path == "/" {
+		return errors.New("the root can't be a shortcut")
+	}
+	all, err := GetShortcuts()
+	if err != nil {
+		return err
+	}
+	for _, o := range all {
+		if utils.IsSubPath(o.Path, s.Path) || utils.IsSubPath(s.Path, o.Path) {
+			return errors.Errorf("[%s] conflicts with the shortcut [%s]", s.Path, o.Path)
+		}
+	}
+	target, err := ResolveShortcut(s.Target)
+	if err != nil {
+		return err
+	}
+	// the shortcut would contain itself
+	if utils.IsSubPath(s.Path, target) || reachesPath(all, target, s.Path, make(map[string]struct{})) {
+		return errors.WithStack(errs.ShortcutLoop)
+	}
+	defer invalidateShortcuts()
+	return db.CreateShortcut(s)
+}
+
+func DeleteShortcutById(id uint) error {
+	defer invalidateShortcuts()
+	return db.DeleteShortcutById(id)
+}
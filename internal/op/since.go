@@ -0,0 +1,66 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// FilterModifiedSince keep the dirs and the files modified at or after since
+func FilterModifiedSince(objs []model.Obj, since time.Time) []model.Obj {
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if obj.IsDir() || !obj.ModTime().Before(since) {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
+// listModifiedSince use driver.ListModifiedSince if implemented, or filter the objs of List.
+// The objs of the driver are partial, so they are neither cached nor passed to the update hooks
+func listModifiedSince(ctx context.Context, storage driver.Driver, path string, args model.ListArgs) ([]model.Obj, error) {
+	since := args.ModifiedSince
+	s, ok := storage.(driver.ListModifiedSince)
+	if !ok {
+		args.ModifiedSince = time.Time{}
+		objs, err := List(ctx, storage, path, args)
+		if err != nil {
+			return nil, err
+		}
+		return FilterModifiedSince(objs, since), nil
+	}
+	path = utils.FixAndCleanPath(path)
+	log.Debugf("op.ListModifiedSince %s, since: %s", path, since)
+	dir, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dir")
+	}
+	if !dir.IsDir() {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.ListModifiedSince(ctx, dir, since)
+	release()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list objs")
+	}
+	for _, f := range files {
+		if s, ok := f.(model.SetPath); ok && f.GetPath() == "" && dir.GetPath() != "" {
+			s.SetPath(stdpath.Join(dir.GetPath(), f.GetName()))
+		}
+	}
+	wrapObjsName(storage, files)
+	model.ExtractFolder(files, extractFolder(storage))
+	return files, nil
+}
@@ -0,0 +1,46 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// localOrder returns how the objs listed from the storage are sorted by alist, empty orderBy keeps the order of the driver.
+// The natural sort puts 2.mp4 before 10.mp4, it's opt-in for the storages which sort the names by themselves,
+// and doesn't apply if the driver sorts by something else
+func localOrder(storage driver.Driver) (orderBy, orderDirection string) {
+	s := storage.GetStorage()
+	if storage.Config().LocalSort {
+		orderBy, orderDirection = s.OrderBy, s.OrderDirection
+	} else if o, ok := storage.(driver.ListOrder); ok && s.NaturalSort {
+		orderBy, orderDirection = o.ListOrder()
+		if orderBy != "" && orderBy != "name" {
+			return "", ""
+		}
+	}
+	if s.NaturalSort && (orderBy == "" || orderBy == "name") {
+		orderBy = "name"
+	}
+	return orderBy, orderDirection
+}
+
+// extractFolder returns where the folders of the storage are put, the global folders_first applies
+// if the storage doesn't set it
+func extractFolder(storage driver.Driver) string {
+	if f := storage.GetStorage().ExtractFolder; f != "" {
+		return f
+	}
+	if item, _ := GetSettingItemByKey(conf.FoldersFirst); item != nil && item.Value == "true" {
+		return "front"
+	}
+	return ""
+}
+
+// sortObjs sorts the objs listed from the storage, see localOrder and extractFolder
+func sortObjs(storage driver.Driver, objs []model.Obj) {
+	if orderBy, orderDirection := localOrder(storage); orderBy != "" {
+		model.SortFiles(objs, orderBy, orderDirection)
+	}
+	model.ExtractFolder(objs, extractFolder(storage))
+}
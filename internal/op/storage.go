@@ -64,6 +64,12 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	//}
 
 	var err error
+	if _, err = model.NewIgnorePatterns(storage.IgnorePatterns); err != nil {
+		return 0, err
+	}
+	if err = model.CheckNameNormalization(storage.NameNormalization); err != nil {
+		return 0, err
+	}
 	// check driver first
 	driverName := storage.Driver
 	driverNew, err := GetDriver(driverName)
@@ -109,6 +115,18 @@ func getCurrentGoroutineStack() string {
 	return string(buf[:n])
 }
 
+// GetStorageDetails get the quota of the storage if the driver reports it
+func GetStorageDetails(ctx context.Context, storage driver.Driver) (*model.StorageDetails, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	wd, ok := storage.(driver.WithDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	return wd.GetDetails(ctx)
+}
+
 // initStorage initialize the driver and store to storagesMap
 func initStorage(ctx context.Context, storage model.Storage, storageDriver driver.Driver) (err error) {
 	storageDriver.SetStorage(storage)
@@ -225,6 +243,12 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	//if storage.MountPath == "/" {
 	//	return errors.New("Mount path cannot be '/'")
 	//}
+	if _, err = model.NewIgnorePatterns(storage.IgnorePatterns); err != nil {
+		return err
+	}
+	if err = model.CheckNameNormalization(storage.NameNormalization); err != nil {
+		return err
+	}
 	err = db.UpdateStorage(&storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
@@ -318,6 +342,7 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 		}
 		// delete the storage in the memory
 		storagesMap.Delete(storage.MountPath)
+		storageLimiters.Delete(storage.ID)
 		go callStorageHooks("del", storageDriver)
 	}
 	// delete the storage in the database
@@ -0,0 +1,41 @@
+package op
+
+import (
+	"cmp"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+)
+
+// withTimeout limits the driver call of op, e.g. "list", by the request timeout of the storage, so that a hung
+// backend fails the call instead of blocking it forever. See requestTimeout
+func withTimeout(ctx context.Context, storage driver.Driver, op string) (context.Context, context.CancelFunc) {
+	if t := requestTimeout(storage, op); t > 0 {
+		return context.WithTimeout(ctx, t)
+	}
+	return ctx, func() {}
+}
+
+// requestTimeout is the timeout of op: the one of the storage, or the global one of op, or the global default.
+// The uploads take as long as the files need, so they are only limited by conf.RequestTimeout.Put
+func requestTimeout(storage driver.Driver, op string) time.Duration {
+	if conf.Conf == nil {
+		return 0
+	}
+	c := conf.Conf.RequestTimeout
+	var seconds int
+	switch {
+	case strings.HasPrefix(op, "put"):
+		seconds = c.Put
+	case op == "list" || op == "list_page" || op == "list_recursive":
+		seconds = cmp.Or(storage.GetStorage().RequestTimeout, c.List, c.Default)
+	case op == "link":
+		seconds = cmp.Or(storage.GetStorage().RequestTimeout, c.Link, c.Default)
+	default:
+		seconds = cmp.Or(storage.GetStorage().RequestTimeout, c.Default)
+	}
+	return time.Duration(max(seconds, 0)) * time.Second
+}
@@ -0,0 +1,76 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Touch sets the mtime of the existing file by driver.Toucher, errs.NotImplement if the driver isn't one
+func Touch(ctx context.Context, storage driver.Driver, path string, mtime time.Time) error {
+	s, ok := storage.(driver.Toucher)
+	if !ok {
+		return errs.NotImplement
+	}
+	return changeFile(ctx, storage, path, "touch", func(file model.Obj) (model.Obj, error) {
+		return s.Touch(ctx, file, mtime)
+	})
+}
+
+// Truncate changes the size of the file by driver.Truncater, errs.NotImplement if the driver isn't one
+func Truncate(ctx context.Context, storage driver.Driver, path string, size int64) error {
+	s, ok := storage.(driver.Truncater)
+	if !ok {
+		return errs.NotImplement
+	}
+	return changeFile(ctx, storage, path, "truncate", func(file model.Obj) (model.Obj, error) {
+		return s.Truncate(ctx, file, size)
+	})
+}
+
+// changeFile calls change with the file at path and replaces it in the list cache with the returned one
+func changeFile(ctx context.Context, storage driver.Driver, path, name string, change func(file model.Obj) (model.Obj, error)) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if err := checkWritable(storage); err != nil {
+		return err
+	}
+	path = utils.FixAndCleanPath(path)
+	file, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get file")
+	}
+	if file.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	ctx, release, err := acquireStorage(ctx, storage)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, storage, name)
+	defer cancel()
+	done := metrics.Observe(storage, name)
+	newObj, err := change(file)
+	done(err)
+	release()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dirPath := stdpath.Dir(path)
+	if newObj != nil {
+		updateCacheObj(storage, dirPath, file, wrapObjName(storage, newObj))
+	} else {
+		ClearCache(storage, dirPath)
+	}
+	linkCache.Del(Key(storage, path))
+	handleFsChange(FsChangeCreate, storage, path, "")
+	return nil
+}
@@ -0,0 +1,64 @@
+package op
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// uploadHashTTL is how long a hash is kept after it is saved
+	uploadHashTTL = 30 * 24 * time.Hour
+	// uploadHashMax is the max number of the kept hashes, the least recently saved ones are deleted first
+	uploadHashMax = 10000
+)
+
+func uploadHashKey(scope, src string) string {
+	h := sha1.Sum([]byte(scope + "\x00" + src))
+	return hex.EncodeToString(h[:])
+}
+
+// GetUploadHash returns the hash saved by SaveUploadHash for src of the scope if the size and the mtime
+// of src are unchanged. A stale hash is deleted
+func GetUploadHash(scope, src string, size int64, mtime time.Time) (string, bool) {
+	if src == "" || mtime.IsZero() {
+		return "", false
+	}
+	key := uploadHashKey(scope, src)
+	h, err := db.GetUploadHash(key)
+	if err != nil {
+		return "", false
+	}
+	if h.Size != size || !h.Modified.Equal(mtime.Truncate(time.Second)) || time.Since(h.Updated) > uploadHashTTL {
+		if err := db.DeleteUploadHash(key); err != nil {
+			log.Warnf("failed delete the stale upload hash of %s: %+v", src, err)
+		}
+		return "", false
+	}
+	return h.Hash, true
+}
+
+// SaveUploadHash saves the hash computed by a driver for src, scope tells the drivers and their settings
+// which compute the hash differently apart
+func SaveUploadHash(scope, src string, size int64, mtime time.Time, hash string) {
+	if src == "" || mtime.IsZero() {
+		return
+	}
+	if err := db.DeleteUploadHashes(time.Now().Add(-uploadHashTTL), uploadHashMax-1); err != nil {
+		log.Warnf("failed delete the expired upload hashes: %+v", err)
+	}
+	err := db.SaveUploadHash(&model.UploadHash{
+		Src:      uploadHashKey(scope, src),
+		Size:     size,
+		Modified: mtime.Truncate(time.Second),
+		Hash:     hash,
+		Updated:  time.Now(),
+	})
+	if err != nil {
+		log.Warnf("failed save the upload hash of %s: %+v", src, err)
+	}
+}
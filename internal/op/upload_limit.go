@@ -0,0 +1,45 @@
+package op
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// LimitUploadSize checks the size of the file uploaded to the storage against its max_upload_size. A file of unknown
+// size (< 0) is checked as its content is read, the reading fails once it exceeds the limit, so that the upload
+// is aborted and the temp files being written are removed
+func LimitUploadSize(storage driver.Driver, file model.FileStreamer) error {
+	maxSize := int64(storage.GetStorage().MaxUploadSize) * utils.MB
+	if maxSize <= 0 {
+		return nil
+	}
+	if size := file.GetSize(); size > maxSize {
+		return errors.Wrapf(errs.UploadTooLarge, "%s is %d bytes, the max is %d MB", file.GetName(), size, storage.GetStorage().MaxUploadSize)
+	} else if size >= 0 {
+		return nil
+	}
+	l, ok := file.(interface{ LimitSize(max int64, err error) })
+	if !ok {
+		return nil
+	}
+	l.LimitSize(maxSize, errors.Wrapf(errs.UploadTooLarge, "%s exceeds %d MB", file.GetName(), storage.GetStorage().MaxUploadSize))
+	return nil
+}
+
+// CheckUploadSize checks the declared size of a file to upload to path against the max_upload_size of its storage
+// and the upload quota of user, before the content is received by a front-end which buffers it first, e.g. tus
+func CheckUploadSize(user *model.User, path string, size int64) error {
+	storage, _, err := GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if maxSize := int64(storage.GetStorage().MaxUploadSize) * utils.MB; maxSize > 0 && size > maxSize {
+		return errors.Wrapf(errs.UploadTooLarge, "%s is %d bytes, the max is %d MB", stdpath.Base(path), size, storage.GetStorage().MaxUploadSize)
+	}
+	return CheckUploadQuota(user, size)
+}
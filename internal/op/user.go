@@ -112,6 +112,9 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := db.DeleteUploadRecordsByUser(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -43,6 +43,7 @@ func Init(mode string) error {
 		log.Errorf("init searcher error: %+v", err)
 	} else {
 		instance = i
+		startAutoCrawl()
 	}
 	return err
 }
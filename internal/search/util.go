@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"strings"
 
 	"github.com/alist-org/alist/v3/drivers/alist_v3"
@@ -10,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -92,4 +94,45 @@ func init() {
 			updateIgnorePaths(setting.GetStr(conf.IgnorePaths))
 		}
 	})
+	op.RegisterStorageHook(reindexOnListFilterChange)
+}
+
+var listFilterKeys generic_sync.MapOf[string, string]
+
+// reindexOnListFilterChange rebuild the index of a storage whose list filter has changed,
+// so that objs which are filtered out now are dropped from the index
+func reindexOnListFilterChange(typ string, storage driver.Driver) {
+	f, ok := storage.(driver.ListFilter)
+	if !ok {
+		return
+	}
+	mountPath := storage.GetStorage().MountPath
+	if typ == "del" {
+		listFilterKeys.Delete(mountPath)
+		return
+	}
+	key := f.ListFilterKey()
+	oldKey, loaded := listFilterKeys.Load(mountPath)
+	listFilterKeys.Store(mountPath, key)
+	if typ != "update" || !loaded || oldKey == key {
+		return
+	}
+	if instance == nil || storage.GetStorage().DisableIndex || isIgnorePath(mountPath) {
+		return
+	}
+	if progress, err := Progress(); err != nil || !progress.IsDone {
+		return
+	}
+	log.Infof("list filter of storage [%s] changed, rebuild index", mountPath)
+	op.ClearCache(storage, "/")
+	ctx := context.Background()
+	if err := Del(ctx, mountPath); err != nil {
+		log.Errorf("failed delete index of [%s]: %+v", mountPath, err)
+		return
+	}
+	err := BuildIndex(ctx, []string{mountPath}, conf.SlicesMap[conf.IgnorePaths],
+		setting.GetInt(conf.MaxIndexDepth, 20)-strings.Count(mountPath, "/"), false)
+	if err != nil {
+		log.Errorf("failed rebuild index of [%s]: %+v", mountPath, err)
+	}
 }
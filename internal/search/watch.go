@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+type fsChange struct {
+	typ     string
+	path    string
+	dstPath string
+}
+
+var (
+	fsChangesMu    sync.Mutex
+	fsChanges      []fsChange
+	fsChangesSet   = make(map[fsChange]struct{})
+	fsChangesFirst time.Time
+	fsChangesTimer *time.Timer
+)
+
+func autoUpdateEnabled() bool {
+	return instance != nil && instance.Config().AutoUpdate && setting.GetBool(conf.AutoUpdateIndex)
+}
+
+// onFsChange queues the change, the queue is flushed when no change comes within the debounce time,
+// or at most 10 times of it since the first queued change, so that a burst of changes is coalesced
+func onFsChange(typ, path, dstPath string) {
+	if !autoUpdateEnabled() {
+		return
+	}
+	debounce := time.Duration(setting.GetInt(conf.IndexUpdateDebounce, 5)) * time.Second
+	fsChangesMu.Lock()
+	defer fsChangesMu.Unlock()
+	c := fsChange{typ: typ, path: path, dstPath: dstPath}
+	if _, ok := fsChangesSet[c]; !ok {
+		fsChangesSet[c] = struct{}{}
+		fsChanges = append(fsChanges, c)
+	}
+	if fsChangesTimer == nil {
+		fsChangesFirst = time.Now()
+		fsChangesTimer = time.AfterFunc(debounce, flushFsChanges)
+	} else if time.Since(fsChangesFirst) < debounce*10 {
+		fsChangesTimer.Reset(debounce)
+	}
+}
+
+func flushFsChanges() {
+	fsChangesMu.Lock()
+	if Running() {
+		// wait for the running build, the changes may not be walked by it
+		fsChangesTimer.Reset(time.Duration(setting.GetInt(conf.IndexUpdateDebounce, 5)) * time.Second)
+		fsChangesMu.Unlock()
+		return
+	}
+	changes := fsChanges
+	fsChanges, fsChangesSet, fsChangesTimer = nil, make(map[fsChange]struct{}), nil
+	fsChangesMu.Unlock()
+	if !autoUpdateEnabled() {
+		return
+	}
+	// only update when index have built
+	if progress, err := Progress(); err != nil || !progress.IsDone {
+		return
+	}
+	ctx := context.Background()
+	for _, c := range changes {
+		var err error
+		switch c.typ {
+		case op.FsChangeCreate:
+			err = reindexPath(ctx, c.path)
+		case op.FsChangeRemove:
+			err = Del(ctx, c.path)
+		case op.FsChangeMove:
+			err = repathIndex(ctx, c.path, c.dstPath)
+		}
+		if err != nil {
+			log.Errorf("failed update index of %s [%s]: %+v", c.typ, c.path, err)
+		}
+	}
+}
+
+func indexable(p string) bool {
+	if isIgnorePath(p) {
+		return false
+	}
+	storage, _, err := op.GetStorageAndActualPath(p)
+	return err == nil && !storage.GetStorage().DisableIndex
+}
+
+// reindexPath drops the indexed nodes of p and indexes it again, a dir is walked
+func reindexPath(ctx context.Context, p string) error {
+	if err := Del(ctx, p); err != nil {
+		return err
+	}
+	if !indexable(p) {
+		return nil
+	}
+	obj, err := fs.Get(ctx, p, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !obj.IsDir() {
+		return Index(ctx, path.Dir(p), obj)
+	}
+	return BuildIndex(ctx, []string{p}, conf.SlicesMap[conf.IgnorePaths],
+		setting.GetInt(conf.MaxIndexDepth, 20)-strings.Count(p, "/"), false)
+}
+
+// repathIndex moves the indexed nodes of src to dst without walking the storage
+func repathIndex(ctx context.Context, src, dst string) error {
+	if !indexable(dst) {
+		return Del(ctx, src)
+	}
+	siblings, err := instance.Get(ctx, path.Dir(src))
+	if err != nil {
+		return err
+	}
+	var node *model.SearchNode
+	for i := range siblings {
+		if siblings[i].Name == path.Base(src) {
+			node = &siblings[i]
+			break
+		}
+	}
+	if node == nil {
+		// src is not indexed, e.g. it was in an ignored path
+		return reindexPath(ctx, dst)
+	}
+	nodes := []model.SearchNode{{
+		Parent: path.Dir(dst),
+		Name:   path.Base(dst),
+		IsDir:  node.IsDir,
+		Size:   node.Size,
+	}}
+	if node.IsDir {
+		parents := []string{src}
+		for len(parents) > 0 {
+			parent := parents[0]
+			parents = parents[1:]
+			children, err := instance.Get(ctx, parent)
+			if err != nil {
+				return err
+			}
+			newParent := dst + strings.TrimPrefix(parent, src)
+			for _, child := range children {
+				if child.IsDir {
+					parents = append(parents, path.Join(parent, child.Name))
+				}
+				child.Parent = newParent
+				nodes = append(nodes, child)
+			}
+		}
+	}
+	if err = Del(ctx, src); err != nil {
+		return err
+	}
+	if err = Del(ctx, dst); err != nil {
+		return err
+	}
+	return instance.BatchIndex(ctx, nodes)
+}
+
+var autoCrawlOnce sync.Once
+
+// startAutoCrawl rebuilds the whole index periodically if auto_crawl_interval is set,
+// for the changes made outside of alist
+func startAutoCrawl() {
+	autoCrawlOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				autoCrawl()
+			}
+		}()
+	})
+}
+
+func autoCrawl() {
+	interval := time.Duration(setting.GetInt(conf.AutoCrawlInterval, 0)) * time.Hour
+	if interval <= 0 || instance == nil || Running() {
+		return
+	}
+	progress, err := Progress()
+	if err != nil {
+		return
+	}
+	if progress.LastDoneTime != nil && time.Since(*progress.LastDoneTime) < interval {
+		return
+	}
+	log.Infof("start the periodic crawl of the index")
+	ctx := context.Background()
+	if err = Clear(ctx); err != nil {
+		log.Errorf("clear index error: %+v", err)
+		return
+	}
+	err = BuildIndex(ctx, []string{"/"}, conf.SlicesMap[conf.IgnorePaths], setting.GetInt(conf.MaxIndexDepth, 20), true)
+	if err != nil {
+		log.Errorf("periodic crawl of the index error: %+v", err)
+	}
+}
+
+func init() {
+	op.RegisterFsChangeHook(onFsChange)
+}
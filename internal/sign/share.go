@@ -0,0 +1,34 @@
+package sign
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+)
+
+var onceShare sync.Once
+var instanceShare sign.Sign
+
+// the sign of share links is separated from the download sign, all of them are revoked by resetting the token
+
+func WithDurationShare(data string, d time.Duration) string {
+	onceShare.Do(InstanceShare)
+	return instanceShare.Sign(data, time.Now().Add(d).Unix())
+}
+
+func NotExpiredShare(data string) string {
+	onceShare.Do(InstanceShare)
+	return instanceShare.Sign(data, 0)
+}
+
+func VerifyShare(data string, sign string) error {
+	onceShare.Do(InstanceShare)
+	return instanceShare.Verify(data, sign)
+}
+
+func InstanceShare() {
+	instanceShare = sign.NewHMACSign([]byte(setting.GetStr(conf.Token) + "-share"))
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// HashingReader computes the hashes of the bytes read through it, and writes them to the extra writers,
+// e.g. the hashers of the slices of a driver, so that each hash is computed once as the content flows
+type HashingReader struct {
+	r      io.Reader
+	hasher *utils.MultiHasher
+	w      []io.Writer
+}
+
+func NewHashingReader(r io.Reader, types []*utils.HashType, w ...io.Writer) *HashingReader {
+	return &HashingReader{r: r, hasher: utils.NewMultiHasher(types), w: w}
+}
+
+func (h *HashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n > 0 {
+		_, _ = h.hasher.Write(p[:n])
+		for _, w := range h.w {
+			if _, werr := w.Write(p[:n]); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
+
+// Size is how many bytes are hashed
+func (h *HashingReader) Size() int64 {
+	return h.hasher.Size()
+}
+
+// HashInfo is the hashes of the bytes read so far
+func (h *HashingReader) HashInfo() utils.HashInfo {
+	return *h.hasher.GetHashInfo()
+}
+
+// KnownHashes is the valid hashes of file, normalized to lower case
+func KnownHashes(file model.FileStreamer) map[*utils.HashType]string {
+	h := make(map[*utils.HashType]string)
+	for ht, v := range file.GetHash().All() {
+		if len(v) == ht.Width {
+			h[ht] = strings.ToLower(v)
+		}
+	}
+	return h
+}
+
+// CacheFullInTempFileAndHashes caches the stream in a temp file, or reads the existing cache, computing the hashes
+// of types in the same pass, the extra writers also get the content. The computed hashes are set to the stream,
+// so that the later users, such as the rapid upload of the driver and the verify after the upload, get them
+// by GetHash instead of reading the content again
+func CacheFullInTempFileAndHashes(stream model.FileStreamer, types []*utils.HashType, w ...io.Writer) (model.File, utils.HashInfo, error) {
+	hr := NewHashingReader(stream, types, w...)
+	cache := stream.GetFile()
+	if cache != nil {
+		if _, err := cache.Seek(0, io.SeekStart); err != nil {
+			return nil, utils.HashInfo{}, err
+		}
+		hr.r = cache
+		if _, err := utils.CopyWithBuffer(io.Discard, hr); err != nil {
+			return nil, utils.HashInfo{}, err
+		}
+		if _, err := cache.Seek(0, io.SeekStart); err != nil {
+			return nil, utils.HashInfo{}, err
+		}
+	} else {
+		tmpF, err := utils.CreateTempFile(hr, stream.GetSize())
+		if err != nil {
+			return nil, utils.HashInfo{}, err
+		}
+		stream.SetTmpFile(tmpF)
+		cache = tmpF
+	}
+	if size := stream.GetSize(); size >= 0 && hr.Size() != size {
+		return nil, utils.HashInfo{}, fmt.Errorf("hashed size mismatch: %d != %d", hr.Size(), size)
+	}
+	computed := hr.HashInfo()
+	h := KnownHashes(stream)
+	for ht, v := range computed.All() {
+		h[ht] = v
+	}
+	if s, ok := stream.(interface{ SetHash(utils.HashInfo) }); ok {
+		s.SetHash(utils.NewHashInfoByMap(h))
+	}
+	return cache, computed, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"golang.org/x/time/rate"
 	"io"
+	"math"
 	"time"
 )
 
@@ -34,6 +35,43 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+// BlockBurstLimiter waits for n tokens burst by burst, so that n can be larger than the burst
+type BlockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l BlockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
+// NewSpeedLimiter returns a limiter of bytesPerSec bytes per second, nil if bytesPerSec <= 0
+func NewSpeedLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := int(min(bytesPerSec, math.MaxInt32))
+	return rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+}
+
+// SpeedLimiter wraps l for the RateLimit readers and writers, nil if l is nil
+func SpeedLimiter(l *rate.Limiter) Limiter {
+	if l == nil {
+		return nil
+	}
+	return BlockBurstLimiter{Limiter: l}
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
@@ -24,10 +24,27 @@ type FileStream struct {
 	Mimetype          string
 	WebPutAsTask      bool
 	ForceStreamUpload bool
+	SkipIfMatch       bool      //skip the upload if the file existed in the destination has the same size and hash
+	Conflict          string    //what to do if the file exists in the destination, one of the Conflict*, empty for the default of the storage
 	Exist             model.Obj //the file existed in the destination, we can reuse some info since we wil overwrite it
+	Source            string    //identifies the src of the content, e.g. the mount path and the path of the copied file, empty if unknown
 	utils.Closers
 	tmpFile  *os.File //if present, tmpFile has full content, it will be deleted at last
 	peekBuff *bytes.Reader
+	hash     *utils.HashInfo //overrides the hash of Obj if set
+}
+
+func (f *FileStream) GetHash() utils.HashInfo {
+	if f.hash != nil {
+		return *f.hash
+	}
+	return f.Obj.GetHash()
+}
+
+// SetHash sets the hash of the content known before uploading, e.g. computed by the copy task,
+// so that the driver can use it, such as the rapid upload
+func (f *FileStream) SetHash(h utils.HashInfo) {
+	f.hash = &h
 }
 
 func (f *FileStream) GetSize() int64 {
@@ -52,6 +69,54 @@ func (f *FileStream) IsForceStreamUpload() bool {
 	return f.ForceStreamUpload
 }
 
+func (f *FileStream) IsSkipIfMatch() bool {
+	return f.SkipIfMatch
+}
+
+func (f *FileStream) GetSource() string {
+	return f.Source
+}
+
+// Source returns the identity of the src of file, which stays the same for the same src, empty if unknown
+func Source(file model.FileStreamer) string {
+	s, ok := file.(interface{ GetSource() string })
+	if !ok {
+		return ""
+	}
+	return s.GetSource()
+}
+
+// SkipIfMatch reports whether the upload of file should be skipped if the existing dst obj has the same content
+func SkipIfMatch(file model.FileStreamer) bool {
+	s, ok := file.(interface{ IsSkipIfMatch() bool })
+	return ok && s.IsSkipIfMatch()
+}
+
+const (
+	ConflictOverwrite = "overwrite" // replace the existing file
+	ConflictRename    = "rename"    // keep both, the storage gives the uploaded file a new name
+	ConflictSkip      = "skip"      // keep the existing file and don't upload
+	// ConflictNewer replace the existing file only if the uploaded one is newer by the mtime or differs by the hash
+	ConflictNewer = "newer"
+)
+
+func IsValidConflict(conflict string) bool {
+	return conflict == "" || conflict == ConflictOverwrite || conflict == ConflictRename || conflict == ConflictSkip ||
+		conflict == ConflictNewer
+}
+
+func (f *FileStream) GetConflict() string {
+	return f.Conflict
+}
+
+// Conflict returns what to do if the dst file of the upload exists, empty for the default of the storage
+func Conflict(file model.FileStreamer) string {
+	if s, ok := file.(interface{ GetConflict() string }); ok {
+		return s.GetConflict()
+	}
+	return ""
+}
+
 func (f *FileStream) Close() error {
 	var err1, err2 error
 
@@ -71,6 +136,35 @@ func (f *FileStream) Close() error {
 	return errors.Join(err1, err2)
 }
 
+// LimitSize fails the reading of the content with err once more than max bytes are read,
+// it's for the streams of unknown size, which can't be checked before reading
+func (f *FileStream) LimitSize(max int64, err error) {
+	if f.GetFile() != nil {
+		return
+	}
+	f.Reader = &sizeLimitReader{r: f.Reader, max: max, err: err}
+}
+
+type sizeLimitReader struct {
+	r    io.Reader
+	read int64
+	max  int64
+	err  error
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if l.read += int64(n); l.read > l.max {
+		return n, l.err
+	}
+	return n, err
+}
+
+// SetName renames the uploaded file, e.g. to the normalized name, the src obj is kept
+func (f *FileStream) SetName(name string) {
+	f.Obj = &model.ObjWrapName{Name: name, Obj: f.Obj}
+}
+
 func (f *FileStream) GetExist() model.Obj {
 	return f.Exist
 }
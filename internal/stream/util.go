@@ -18,48 +18,69 @@ func GetRangeReadCloserFromLink(size int64, link *model.Link) (model.RangeReadCl
 	if len(link.URL) == 0 {
 		return nil, fmt.Errorf("can't create RangeReadCloser since URL is empty in link")
 	}
+	links := link.Links()
 	rangeReaderFunc := func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
-		if link.Concurrency != 0 || link.PartSize != 0 {
-			header := net.ProcessHeader(nil, link.Header)
-			down := net.NewDownloader(func(d *net.Downloader) {
-				d.Concurrency = link.Concurrency
-				d.PartSize = link.PartSize
-			})
-			req := &net.HttpRequestParams{
-				URL:       link.URL,
-				Range:     r,
-				Size:      size,
-				HeaderRef: header,
+		var (
+			rc  io.ReadCloser
+			err error
+		)
+		// try the candidates of the link in turn, see model.Link.Candidates
+		for i, l := range links {
+			if !l.CanRange(r, size) {
+				continue
 			}
-			rc, err := down.Download(ctx, req)
-			return rc, err
-
-		}
-		response, err := RequestRangedHttp(ctx, link, r.Start, r.Length)
-		if err != nil {
-			if response == nil {
-				return nil, fmt.Errorf("http request failure, err:%s", err)
+			if rc, err = rangeReadLink(ctx, l, size, r); err == nil || ctx.Err() != nil {
+				return rc, err
 			}
-			return nil, err
-		}
-		if r.Start == 0 && (r.Length == -1 || r.Length == size) || response.StatusCode == http.StatusPartialContent ||
-			checkContentRange(&response.Header, r.Start) {
-			return response.Body, nil
-		} else if response.StatusCode == http.StatusOK {
-			log.Warnf("remote http server not supporting range request, expect low perfromace!")
-			readCloser, err := net.GetRangedHttpReader(response.Body, r.Start, r.Length)
-			if err != nil {
-				return nil, err
+			if i < len(links)-1 {
+				log.Warnf("failed request link %d of %d, try the next one: %v", i+1, len(links), err)
 			}
-			return readCloser, nil
 		}
-
-		return response.Body, nil
+		return nil, err
 	}
 	resultRangeReadCloser := model.RangeReadCloser{RangeReader: rangeReaderFunc}
 	return &resultRangeReadCloser, nil
 }
 
+func rangeReadLink(ctx context.Context, link *model.Link, size int64, r http_range.Range) (io.ReadCloser, error) {
+	if link.Concurrency != 0 || link.PartSize != 0 {
+		header := net.ProcessHeader(nil, link.Header)
+		down := net.NewDownloader(func(d *net.Downloader) {
+			d.Concurrency = link.Concurrency
+			d.PartSize = link.PartSize
+		})
+		req := &net.HttpRequestParams{
+			URL:       link.URL,
+			Range:     r,
+			Size:      size,
+			HeaderRef: header,
+		}
+		rc, err := down.Download(ctx, req)
+		return rc, err
+
+	}
+	response, err := RequestRangedHttp(ctx, link, r.Start, r.Length)
+	if err != nil {
+		if response == nil {
+			return nil, fmt.Errorf("http request failure, err:%s", err)
+		}
+		return nil, err
+	}
+	if r.Start == 0 && (r.Length == -1 || r.Length == size) || response.StatusCode == http.StatusPartialContent ||
+		checkContentRange(&response.Header, r.Start) {
+		return response.Body, nil
+	} else if response.StatusCode == http.StatusOK {
+		log.Warnf("remote http server not supporting range request, expect low perfromace!")
+		readCloser, err := net.GetRangedHttpReader(response.Body, r.Start, r.Length)
+		if err != nil {
+			return nil, err
+		}
+		return readCloser, nil
+	}
+
+	return response.Body, nil
+}
+
 func RequestRangedHttp(ctx context.Context, link *model.Link, offset, length int64) (*http.Response, error) {
 	header := net.ProcessHeader(nil, link.Header)
 	header = http_range.ApplyRangeToHttpHeader(http_range.Range{Start: offset, Length: length}, header)
@@ -17,6 +17,11 @@ type TaskExtension struct {
 	startTime    *time.Time
 	endTime      *time.Time
 	totalBytes   int64
+
+	speedMu      sync.Mutex
+	speed        int64 // bytes per second
+	lastDone     int64
+	lastDoneTime time.Time
 }
 
 func (t *TaskExtension) SetCreator(creator *model.User) {
@@ -56,6 +61,33 @@ func (t *TaskExtension) GetTotalBytes() int64 {
 	return t.totalBytes
 }
 
+// SetProgress also updates the speed from the done bytes if the total bytes is known
+func (t *TaskExtension) SetProgress(progress float64) {
+	t.Base.SetProgress(progress)
+	if t.totalBytes <= 0 {
+		return
+	}
+	done := int64(progress / 100 * float64(t.totalBytes))
+	now := time.Now()
+	t.speedMu.Lock()
+	defer t.speedMu.Unlock()
+	if t.lastDoneTime.IsZero() || done < t.lastDone {
+		t.lastDone, t.lastDoneTime = done, now
+		return
+	}
+	if elapsed := now.Sub(t.lastDoneTime); elapsed >= time.Second {
+		t.speed = int64(float64(done-t.lastDone) / elapsed.Seconds())
+		t.lastDone, t.lastDoneTime = done, now
+	}
+}
+
+// GetSpeed returns the bytes per second of the last progress updates
+func (t *TaskExtension) GetSpeed() int64 {
+	t.speedMu.Lock()
+	defer t.speedMu.Unlock()
+	return t.speed
+}
+
 func (t *TaskExtension) Ctx() context.Context {
 	if t.ctx == nil {
 		t.ctxInitMutex.Lock()
@@ -87,4 +119,5 @@ type TaskExtensionInfo interface {
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
+	GetSpeed() int64
 }
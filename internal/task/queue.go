@@ -0,0 +1,110 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// QueueWorkers is the number of workers of a manager whose runs are admitted by a Queue, the tasks beyond it
+// wait in the FIFO queue of tache and can't be bumped until they get a worker
+const QueueWorkers = 1024
+
+// Queue admits the runs of the tasks of a manager up to a limit, in the order they wait except the bumped ones,
+// which go first. The pending queue of tache is FIFO and can't be reordered, so the manager gives every task
+// a worker at once and the task waits in the Queue at the start of its Run instead
+type Queue struct {
+	limit func() int
+
+	mu      sync.Mutex
+	running int
+	waiting []*queued
+}
+
+type queued struct {
+	id    string
+	ready chan struct{}
+}
+
+// NewQueue returns a Queue running at most limit() tasks at once, none if it's not positive
+func NewQueue(limit func() int) *Queue {
+	return &Queue{limit: limit}
+}
+
+// Wait blocks until the task id is admitted or ctx is done, the returned func must be called once the run is done,
+// calling it again does nothing
+func (q *Queue) Wait(ctx context.Context, id string) (func(), error) {
+	w := &queued{id: id, ready: make(chan struct{})}
+	q.mu.Lock()
+	q.waiting = append(q.waiting, w)
+	q.admit()
+	q.mu.Unlock()
+	select {
+	case <-w.ready:
+		return sync.OnceFunc(q.release), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-w.ready:
+			// admitted meanwhile
+			q.running--
+			q.admit()
+		default:
+			q.waiting = slices.DeleteFunc(q.waiting, func(e *queued) bool { return e == w })
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *Queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.admit()
+}
+
+// admit runs the first waiting tasks while the limit allows, q.mu must be held
+func (q *Queue) admit() {
+	for len(q.waiting) > 0 && q.running < q.limit() {
+		close(q.waiting[0].ready)
+		q.waiting = q.waiting[1:]
+		q.running++
+	}
+}
+
+// Notify admits the waiting tasks after the limit is raised
+func (q *Queue) Notify() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.admit()
+}
+
+// Bump moves the waiting task id to the front, it reports false if the task isn't waiting, e.g. already running
+func (q *Queue) Bump(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.IndexFunc(q.waiting, func(e *queued) bool { return e.id == id })
+	if i < 0 {
+		return false
+	}
+	w := q.waiting[i]
+	copy(q.waiting[1:i+1], q.waiting[:i])
+	q.waiting[0] = w
+	return true
+}
+
+// Position is the 1-based position of the waiting task id, 0 if it isn't waiting
+func (q *Queue) Position(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.IndexFunc(q.waiting, func(e *queued) bool { return e.id == id }) + 1
+}
+
+// WaitQueue waits for the turn of the task in q at the start of its Run, a nil q admits it at once
+func (t *TaskExtension) WaitQueue(q *Queue) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	return q.Wait(t.Ctx(), t.GetID())
+}
@@ -0,0 +1,70 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueBump(t *testing.T) {
+	q := NewQueue(func() int { return 1 })
+	release, err := q.Wait(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admitted := make(chan string, 2)
+	for _, id := range []string{"b", "c"} {
+		go func() {
+			release, err := q.Wait(context.Background(), id)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			admitted <- id
+			release()
+		}()
+	}
+	for q.Position("b") == 0 || q.Position("c") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !q.Bump("c") {
+		t.Fatal("c should be queued")
+	}
+	if q.Bump("a") {
+		t.Fatal("a is running, it can't be bumped")
+	}
+	release()
+	if first := <-admitted; first != "c" {
+		t.Errorf("expect the bumped c first, got %s", first)
+	}
+	<-admitted
+}
+
+func TestQueueCancel(t *testing.T) {
+	q := NewQueue(func() int { return 1 })
+	release, _ := q.Wait(context.Background(), "a")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Wait(ctx, "b"); err == nil {
+		t.Fatal("expect the canceled wait to fail")
+	}
+	if q.Position("b") != 0 {
+		t.Error("the canceled task should leave the queue")
+	}
+	release()
+	// a second call mustn't admit another task beyond the limit
+	release()
+	release, err := q.Wait(context.Background(), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.running != 1 {
+		t.Fatalf("expect only c running, got %d", q.running)
+	}
+	release()
+	release, err = q.Wait(context.Background(), "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
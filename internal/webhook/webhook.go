@@ -0,0 +1,231 @@
+// Package webhook posts the fs changes made through op to the configured urls
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxAttempts    = 6
+	minBackoff     = time.Second
+	maxBackoff     = time.Minute
+	requestTimeout = 10 * time.Second
+	// workers post the queued events, an event is dropped if the queue is full, e.g. when a hook keeps failing
+	workers   = 4
+	queueSize = 1024
+)
+
+// Hook is an item of the webhooks setting
+type Hook struct {
+	URL string `json:"url"`
+	// Secret signs the payload, the X-Alist-Signature header is sha256=hex(hmac-sha256(secret, body))
+	Secret string `json:"secret"`
+	// PathPrefix only posts the changes under it, empty for all
+	PathPrefix string `json:"path_prefix"`
+	// Events are the op.FsChange* to post, empty for all
+	Events []string `json:"events"`
+}
+
+func (h *Hook) match(e *Event) bool {
+	if len(h.Events) > 0 && !utils.SliceContains(h.Events, e.Event) {
+		return false
+	}
+	if h.PathPrefix == "" {
+		return true
+	}
+	return utils.IsSubPath(h.PathPrefix, e.Path) || (e.DstPath != "" && utils.IsSubPath(h.PathPrefix, e.DstPath))
+}
+
+// Event is the json payload of a post
+type Event struct {
+	ID      string    `json:"id"`
+	Event   string    `json:"event"`
+	Path    string    `json:"path"`
+	DstPath string    `json:"dst_path,omitempty"`
+	Storage string    `json:"storage"` // mount path of the storage
+	Driver  string    `json:"driver"`
+	Object  *Object   `json:"object,omitempty"` // the created obj, or the obj at dst_path after a move
+	Time    time.Time `json:"time"`
+}
+
+type Object struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+	HashInfo string    `json:"hash_info"`
+}
+
+type job struct {
+	e     *Event
+	hooks []Hook
+}
+
+var (
+	hooks     atomic.Pointer[[]Hook]
+	client    = &http.Client{Timeout: requestTimeout}
+	queue     = make(chan job, queueSize)
+	startOnce sync.Once
+)
+
+func loadHooks(value string) error {
+	var hs []Hook
+	if value != "" {
+		if err := utils.Json.UnmarshalFromString(value, &hs); err != nil {
+			return errors.WithMessage(err, "invalid webhooks")
+		}
+	}
+	for i := range hs {
+		if hs[i].URL == "" {
+			return errors.Errorf("the url of webhook %d is empty", i)
+		}
+		if hs[i].PathPrefix != "" {
+			hs[i].PathPrefix = utils.FixAndCleanPath(hs[i].PathPrefix)
+		}
+	}
+	hooks.Store(&hs)
+	return nil
+}
+
+// onFsChange doesn't block the op, the event is queued and posted by the workers
+func onFsChange(typ, path, dstPath string) {
+	hs := hooks.Load()
+	if hs == nil || len(*hs) == 0 {
+		return
+	}
+	e := &Event{
+		ID:      uuid.NewString(),
+		Event:   typ,
+		Path:    path,
+		DstPath: dstPath,
+		Time:    time.Now(),
+	}
+	var matched []Hook
+	for _, h := range *hs {
+		if h.match(e) {
+			matched = append(matched, h)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+	startOnce.Do(func() {
+		for range workers {
+			go work()
+		}
+	})
+	select {
+	case queue <- job{e: e, hooks: matched}:
+	default:
+		log.Warnf("the webhook queue is full, drop %s [%s]", e.Event, e.Path)
+	}
+}
+
+func work() {
+	for j := range queue {
+		fillEvent(j.e)
+		body, err := utils.Json.Marshal(j.e)
+		if err != nil {
+			log.Errorf("failed marshal webhook event: %+v", err)
+			continue
+		}
+		for _, h := range j.hooks {
+			deliver(h, j.e, body)
+		}
+	}
+}
+
+func fillEvent(e *Event) {
+	if storage, _, err := op.GetStorageAndActualPath(e.Path); err == nil {
+		e.Storage = storage.GetStorage().MountPath
+		e.Driver = storage.Config().Name
+	}
+	objPath := e.Path
+	switch e.Event {
+	case op.FsChangeRemove:
+		return
+	case op.FsChangeMove:
+		objPath = e.DstPath
+	}
+	obj, err := fs.Get(context.Background(), objPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		log.Debugf("failed get obj [%s] of webhook event: %v", objPath, err)
+		return
+	}
+	e.Object = &Object{
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		IsDir:    obj.IsDir(),
+		Modified: obj.ModTime(),
+		HashInfo: obj.GetHash().String(),
+	}
+}
+
+// deliver posts body to h, retrying with exponential backoff on network errors, 5xx and 429
+func deliver(h Hook, e *Event, body []byte) {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := post(h, e, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= maxAttempts {
+			log.Warnf("failed post webhook %s of %s [%s] after %d attempts: %v", h.URL, e.Event, e.Path, attempt, err)
+			return
+		}
+		log.Debugf("failed post webhook %s, retry in %s: %v", h.URL, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func post(h Hook, e *Event, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "alist/"+conf.Version)
+	req.Header.Set("X-Alist-Event", e.Event)
+	req.Header.Set("X-Alist-Delivery", e.ID)
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Alist-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("responds %s", res.Status)
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests, err
+}
+
+func init() {
+	op.RegisterSettingItemHook(conf.Webhooks, func(item *model.SettingItem) error {
+		return loadHooks(item.Value)
+	})
+	op.RegisterFsChangeHook(onFsChange)
+}
@@ -0,0 +1,124 @@
+package retry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxRetryAfter caps a Retry-After from the server if Options.MaxRetryAfter is 0
+const DefaultMaxRetryAfter = 5 * time.Minute
+
+type Options struct {
+	// Attempts is the max number of calls, including the first one
+	Attempts int
+	// BaseDelay is the delay before the first retry, it doubles on every retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay between two attempts, a Retry-After from the server isn't capped by it
+	MaxDelay time.Duration
+	// MaxRetryAfter caps the delay asked by the server with a Retry-After, 0 for DefaultMaxRetryAfter
+	MaxRetryAfter time.Duration
+	// Retryable reports whether err should be retried, all errors except context errors are retried if nil
+	Retryable func(err error) bool
+	// OnRetry is called before every retry if not nil, attempt starts from 0
+	OnRetry func(attempt int, err error)
+}
+
+// AfterError tells Do to wait for the duration given by the server, such as Retry-After on 429/503
+type AfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *AfterError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Err, e.After)
+}
+
+func (e *AfterError) Unwrap() error {
+	return e.Err
+}
+
+// NewAfterError returns an AfterError if the status of resp means the request should be retried later
+func NewAfterError(resp *http.Response, err error) error {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return err
+	}
+	if err == nil {
+		err = errors.New(resp.Status)
+	}
+	return &AfterError{Err: err, After: ParseAfter(resp.Header.Get("Retry-After"))}
+}
+
+// ParseAfter parse the Retry-After header, which is either seconds or a http date
+func ParseAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// BackoffDelay returns the exponential delay of the attempt (starts from 0) with equal jitter,
+// so that concurrent callers won't retry at the same time
+func BackoffDelay(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base
+	for i := 0; i < attempt && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// Do calls fn until it succeeds, the error is not retryable, or the attempts are used up
+func Do(ctx context.Context, opts Options, fn func() error) error {
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
+	var err error
+	for attempt := 0; attempt < opts.Attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		if opts.Retryable != nil && !opts.Retryable(err) {
+			return err
+		}
+		if attempt+1 == opts.Attempts {
+			break
+		}
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err)
+		}
+		delay := BackoffDelay(attempt, opts.BaseDelay, opts.MaxDelay)
+		var ra *AfterError
+		if errors.As(err, &ra) && ra.After > delay {
+			delay = max(delay, min(ra.After, cmp.Or(opts.MaxRetryAfter, DefaultMaxRetryAfter)))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
@@ -112,27 +112,32 @@ func CreateNestedFile(path string) (*os.File, error) {
 	return os.Create(path)
 }
 
-// CreateTempFile create temp file from io.ReadCloser, and seek to 0
-func CreateTempFile(r io.Reader, size int64) (*os.File, error) {
+// CreateTempFile create temp file from io.ReadCloser, and seek to 0,
+// the temp file is removed if failed, otherwise the caller should remove it after use
+func CreateTempFile(r io.Reader, size int64) (f *os.File, err error) {
 	if f, ok := r.(*os.File); ok {
 		return f, nil
 	}
-	f, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	f, err = os.CreateTemp(conf.Conf.StreamTempDir, "file-*")
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			f = nil
+		}
+	}()
 	readBytes, err := CopyWithBuffer(f, r)
 	if err != nil {
-		_ = os.Remove(f.Name())
 		return nil, errs.NewErr(err, "CreateTempFile failed")
 	}
 	if size > 0 && readBytes != size {
-		_ = os.Remove(f.Name())
 		return nil, errs.NewErr(err, "CreateTempFile failed, incoming stream actual size= %d, expect = %d ", readBytes, size)
 	}
 	_, err = f.Seek(0, io.SeekStart)
 	if err != nil {
-		_ = os.Remove(f.Name())
 		return nil, errs.NewErr(err, "CreateTempFile failed, can't seek to 0 ")
 	}
 	return f, nil
@@ -167,13 +172,24 @@ var extraMimeTypes = map[string]string{
 	".apk": "application/vnd.android.package-archive",
 }
 
-func GetMimeType(name string) string {
-	ext := path.Ext(name)
+// GetMimeTypeByExt returns the mime type of the file extension, custom types in settings come first,
+// it's empty if the extension is unknown
+func GetMimeTypeByExt(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		return ""
+	}
+	if m, ok := conf.MimeTypesMap[ext]; ok && m != "" {
+		return m
+	}
 	if m, ok := extraMimeTypes[ext]; ok {
 		return m
 	}
-	m := mime.TypeByExtension(ext)
-	if m != "" {
+	return mime.TypeByExtension(ext)
+}
+
+func GetMimeType(name string) string {
+	if m := GetMimeTypeByExt(name); m != "" {
 		return m
 	}
 	return "application/octet-stream"
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"hash"
+	"hash/crc32"
 	"io"
 	"iter"
 
@@ -85,8 +86,20 @@ var (
 
 	// SHA256 indicates SHA-256 support
 	SHA256 = RegisterHash("sha256", "SHA-256", 64, sha256.New)
+
+	// CRC32 indicates CRC-32 (IEEE) support
+	CRC32 = RegisterHash("crc32", "CRC-32", 8, func() hash.Hash { return crc32.NewIEEE() })
 )
 
+// GetHashByName get the registered hashType by its name or alias, e.g. "md5" or "MD5"
+func GetHashByName(name string) (*HashType, bool) {
+	if ht, ok := name2hash[name]; ok {
+		return ht, true
+	}
+	ht, ok := alias2hash[name]
+	return ht, ok
+}
+
 // HashData get hash of one hashType
 func HashData(hashType *HashType, data []byte, params ...any) string {
 	h := hashType.NewFunc(params...)
@@ -29,6 +29,19 @@ func IsLocalIPAddr(ip string) bool {
 	return IsLocalIP(net.ParseIP(ip))
 }
 
+// IsPublicIP reports whether ip is a global unicast address, which isn't loopback, private,
+// link local, unspecified or shared (100.64.0.0/10)
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return !(ip4[0] == 100 && ip4[1]&0xc0 == 64) && // 100.64.0.0/10
+			!(ip4[0] == 0) // 0.0.0.0/8
+	}
+	return true
+}
+
 func IsLocalIP(ip net.IP) bool {
 	if ip == nil {
 		return false
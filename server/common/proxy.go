@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
 
@@ -21,6 +20,8 @@ import (
 )
 
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
+	// the speed limit of the storage applies besides the one of the server
+	storageLimit := stream.SpeedLimiter(link.DownloadLimiter)
 	if link.MFile != nil {
 		defer link.MFile.Close()
 		attachHeader(w, file)
@@ -29,6 +30,13 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 			w.Header().Set("Content-Type", contentType)
 		}
 		mFile := link.MFile
+		if storageLimit != nil {
+			mFile = &stream.RateLimitFile{
+				File:    mFile,
+				Limiter: storageLimit,
+				Ctx:     r.Context(),
+			}
+		}
 		if _, ok := mFile.(*os.File); !ok {
 			mFile = &stream.RateLimitFile{
 				File:    mFile,
@@ -41,61 +49,157 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 	} else if link.RangeReadCloser != nil {
 		attachHeader(w, file)
 		return net.ServeHTTP(w, r, file.GetName(), file.ModTime(), file.GetSize(), &stream.RateLimitRangeReadCloser{
-			RangeReadCloserIF: link.RangeReadCloser,
-			Limiter:           stream.ServerDownloadLimit,
+			RangeReadCloserIF: &stream.RateLimitRangeReadCloser{
+				RangeReadCloserIF: link.RangeReadCloser,
+				Limiter:           storageLimit,
+			},
+			Limiter: stream.ServerDownloadLimit,
 		})
 	} else if link.Concurrency != 0 || link.PartSize != 0 {
 		attachHeader(w, file)
 		size := file.GetSize()
+		links := link.Links()
 		rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
 			requestHeader := ctx.Value("request_header")
 			if requestHeader == nil {
 				requestHeader = http.Header{}
 			}
-			header := net.ProcessHeader(requestHeader.(http.Header), link.Header)
-			down := net.NewDownloader(func(d *net.Downloader) {
-				d.Concurrency = link.Concurrency
-				d.PartSize = link.PartSize
-			})
-			req := &net.HttpRequestParams{
-				URL:       link.URL,
-				Range:     httpRange,
-				Size:      size,
-				HeaderRef: header,
+			var (
+				rc  io.ReadCloser
+				err error
+			)
+			// fail over to the candidates of the link in turn
+			for i, l := range links {
+				if !l.CanRange(httpRange, size) {
+					continue
+				}
+				header := net.ProcessHeader(requestHeader.(http.Header), l.Header)
+				down := net.NewDownloader(func(d *net.Downloader) {
+					d.Concurrency = l.Concurrency
+					d.PartSize = l.PartSize
+					// a candidate without range support is downloaded sequentially
+					if l.Concurrency == 0 && l.PartSize == 0 {
+						d.Concurrency = 1
+					}
+				})
+				req := &net.HttpRequestParams{
+					URL:       l.URL,
+					Range:     httpRange,
+					Size:      size,
+					HeaderRef: header,
+				}
+				if rc, err = down.Download(ctx, req); err == nil || ctx.Err() != nil {
+					return rc, err
+				}
+				if i < len(links)-1 {
+					log.Warnf("failed proxy link %d of %d of [%s], try the next one: %v", i+1, len(links), file.GetName(), err)
+				}
 			}
-			rc, err := down.Download(ctx, req)
-			return rc, err
+			return nil, err
 		}
 		return net.ServeHTTP(w, r, file.GetName(), file.ModTime(), file.GetSize(), &stream.RateLimitRangeReadCloser{
-			RangeReadCloserIF: &model.RangeReadCloser{RangeReader: rangeReader},
-			Limiter:           stream.ServerDownloadLimit,
+			RangeReadCloserIF: &stream.RateLimitRangeReadCloser{
+				RangeReadCloserIF: &model.RangeReadCloser{RangeReader: rangeReader},
+				Limiter:           storageLimit,
+			},
+			Limiter: stream.ServerDownloadLimit,
 		})
 	} else {
-		//transparent proxy
-		header := net.ProcessHeader(r.Header, link.Header)
-		res, err := net.RequestHttp(r.Context(), r.Method, header, link.URL)
+		//transparent proxy, fail over to the candidates of the link in turn
+		var (
+			res *http.Response
+			err error
+		)
+		links := link.Links()
+		for i, l := range links {
+			header := net.ProcessHeader(r.Header, l.Header)
+			if res, err = net.RequestHttp(r.Context(), r.Method, header, l.URL); err == nil || r.Context().Err() != nil {
+				break
+			}
+			if i < len(links)-1 {
+				log.Warnf("failed proxy link %d of %d of [%s], try the next one: %v", i+1, len(links), file.GetName(), err)
+			}
+		}
 		if err != nil {
 			return err
 		}
 		defer res.Body.Close()
 
 		maps.Copy(w.Header(), res.Header)
+		// the upstream may name the file differently, or garble a non-ASCII name, so the name is replaced.
+		// It's only made an attachment if the download is asked by the filename query, a preview stays inline
+		if r.URL.Query().Has(DownloadNameQuery) {
+			w.Header().Set("Content-Disposition", ContentDisposition(file.GetName()))
+		} else if cd := res.Header.Get("Content-Disposition"); cd != "" {
+			typ, _, _ := strings.Cut(cd, ";")
+			w.Header().Set("Content-Disposition", contentDisposition(strings.TrimSpace(typ), file.GetName()))
+		}
+		// the upstream, such as baidu, often responds octet-stream which breaks the preview in browser
+		if ct := res.Header.Get("Content-Type"); ct == "" || strings.HasPrefix(ct, "application/octet-stream") {
+			if contentType := utils.GetMimeTypeByExt(file.GetName()); contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+		}
 		w.WriteHeader(res.StatusCode)
 		if r.Method == http.MethodHead {
 			return nil
 		}
 		_, err = utils.CopyWithBuffer(w, &stream.RateLimitReader{
-			Reader:  res.Body,
+			Reader: &stream.RateLimitReader{
+				Reader:  res.Body,
+				Limiter: storageLimit,
+				Ctx:     r.Context(),
+			},
 			Limiter: stream.ServerDownloadLimit,
 			Ctx:     r.Context(),
 		})
 		return err
 	}
 }
+
+// DownloadNameQuery asks for a proxied download as an attachment, its value overrides the name if not empty
+const DownloadNameQuery = "filename"
+
+// ContentDisposition returns the attachment header of name, filename is the ASCII fallback
+// for the old clients and filename* is the RFC 5987 encoding of the true name
+func ContentDisposition(name string) string {
+	return contentDisposition("attachment", name)
+}
+
+func contentDisposition(typ, name string) string {
+	if typ == "" {
+		typ = "attachment"
+	}
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, typ, fallback, encodeRFC5987(name))
+}
+
+// encodeRFC5987 percent-encodes the bytes which are not attr-char
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
 func attachHeader(w http.ResponseWriter, file model.Obj) {
 	fileName := file.GetName()
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fileName, url.PathEscape(fileName)))
-	w.Header().Set("Content-Type", utils.GetMimeType(fileName))
+	w.Header().Set("Content-Disposition", ContentDisposition(fileName))
+	// the content type is sniffed when serving if the extension is unknown
+	if contentType := utils.GetMimeTypeByExt(fileName); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
 	w.Header().Set("Etag", GetEtag(file))
 }
 func GetEtag(file model.Obj) string {
@@ -108,10 +212,21 @@ func GetEtag(file model.Obj) string {
 	if len(hash) > 0 {
 		return fmt.Sprintf(`"%s"`, hash)
 	}
+	// the id (e.g. fs_id of baidu) tells apart different objs at the same path
+	if id := file.GetID(); id != "" {
+		return fmt.Sprintf(`"%s-%x-%x"`, utils.GetMD5EncodeStr(id)[:16], file.ModTime().Unix(), file.GetSize())
+	}
 	// 参考nginx
 	return fmt.Sprintf(`"%x-%x"`, file.ModTime().Unix(), file.GetSize())
 }
 
+// CheckNotModified sends StatusNotModified if the conditional request matches the Etag or
+// the modified time of the file, so that the link needn't be got
+func CheckNotModified(w http.ResponseWriter, r *http.Request, file model.Obj) bool {
+	w.Header().Set("Etag", GetEtag(file))
+	return net.CheckNotModified(w, r, file.ModTime())
+}
+
 var NoProxyRange = &model.RangeReadCloser{}
 
 func ProxyRange(link *model.Link, size int64) {
@@ -150,6 +265,7 @@ func (iw *InterceptResponseWriter) Write(p []byte) (int, error) {
 type WrittenResponseWriter struct {
 	http.ResponseWriter
 	written bool
+	size    int64
 }
 
 func (ww *WrittenResponseWriter) Write(p []byte) (int, error) {
@@ -157,9 +273,15 @@ func (ww *WrittenResponseWriter) Write(p []byte) (int, error) {
 	if !ww.written && n > 0 {
 		ww.written = true
 	}
+	ww.size += int64(n)
 	return n, err
 }
 
 func (ww *WrittenResponseWriter) IsWritten() bool {
 	return ww.written
 }
+
+// Size returns the bytes of the body written
+func (ww *WrittenResponseWriter) Size() int64 {
+	return ww.size
+}
@@ -0,0 +1,26 @@
+package common
+
+import "testing"
+
+func TestEncodeRFC5987(t *testing.T) {
+	datas := []struct {
+		name   string
+		result string
+	}{
+		{"a-b_c.txt", "a-b_c.txt"},
+		{"a b.txt", "a%20b.txt"},
+		{"测试.mp4", "%E6%B5%8B%E8%AF%95.mp4"},
+		{`a"b\c`, "a%22b%5Cc"},
+		{"a;b,c=d%e", "a%3Bb%2Cc%3Dd%25e"},
+		{"!#$&+^`|~", "!#$&+^`|~"},
+		{"a'b(c)*", "a%27b%28c%29%2A"},
+	}
+	for _, data := range datas {
+		if got := encodeRFC5987(data.name); got != data.result {
+			t.Errorf("encodeRFC5987(%q) = %q, expect %q", data.name, got, data.result)
+		}
+	}
+	if got, expect := ContentDisposition("测试 \"a\".mp4"), `attachment; filename="__ _a_.mp4"; filename*=UTF-8''%E6%B5%8B%E8%AF%95%20%22a%22.mp4`; got != expect {
+		t.Errorf("ContentDisposition = %q, expect %q", got, expect)
+	}
+}
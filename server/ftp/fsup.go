@@ -49,7 +49,7 @@ func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy,
 	if err != nil {
 		return nil, err
 	}
-	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	tmpFile, err := os.CreateTemp(conf.Conf.StreamTempDir, "file-*")
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +73,14 @@ func (f *FileUploadProxy) Seek(offset int64, whence int) (int64, error) {
 	return f.buffer.Seek(offset, whence)
 }
 
-func (f *FileUploadProxy) Close() error {
+func (f *FileUploadProxy) Close() (err error) {
+	defer func() {
+		// the buffer is removed by the stream once the task is added
+		if err != nil {
+			_ = f.buffer.Close()
+			_ = os.Remove(f.buffer.Name())
+		}
+	}()
 	dir, name := stdpath.Split(f.path)
 	size, err := f.buffer.Seek(0, io.SeekCurrent)
 	if err != nil {
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/alist-org/alist/v3/internal/task"
-	"net/url"
 	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/archive/tool"
@@ -413,7 +412,7 @@ func ArchiveInternalExtract(c *gin.Context) {
 		"Cache-Control":   "max-age=0, no-cache, no-store, must-revalidate",
 	}
 	filename := stdpath.Base(innerPath)
-	headers["Content-Disposition"] = fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename))
+	headers["Content-Disposition"] = common.ContentDisposition(filename)
 	contentType := c.Request.Header.Get("Content-Type")
 	if contentType == "" {
 		contentType = utils.GetMimeType(filename)
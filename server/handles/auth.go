@@ -189,6 +189,16 @@ func CurrentUser(c *gin.Context) {
 	common.SuccessResp(c, userResp)
 }
 
+func CurrentUploadUsage(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	usage, err := op.GetUploadUsage(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, usage)
+}
+
 func UpdateCurrent(c *gin.Context) {
 	var req model.User
 	if err := c.ShouldBind(&req); err != nil {
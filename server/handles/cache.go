@@ -0,0 +1,11 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func GetListCacheStats(c *gin.Context) {
+	common.SuccessResp(c, op.GetListCacheStats())
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -32,7 +33,7 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	} else {
-		link, _, err := fs.Link(c, rawPath, model.LinkArgs{
+		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -43,6 +44,10 @@ func Down(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
+		if link.MustProxy {
+			proxyLink(c, storage, link, file)
+			return
+		}
 		down(c, link)
 	}
 }
@@ -55,16 +60,8 @@ func Proxy(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if canProxy(storage, filename) {
-		downProxyUrl := storage.GetStorage().DownProxyUrl
-		if downProxyUrl != "" {
-			_, ok := c.GetQuery("d")
-			if !ok {
-				URL := common.BuildDownProxyURL(downProxyUrl, rawPath, storage.GetStorage().DownProxySign)
-				c.Redirect(302, URL)
-				return
-			}
-		}
+	if !canProxy(storage, filename) {
+		// the links carrying a credential of the storage are proxied anyway, see model.Link.MustProxy
 		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			Header:  c.Request.Header,
 			Type:    c.Query("type"),
@@ -74,11 +71,46 @@ func Proxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500)
 			return
 		}
-		localProxy(c, link, file, storage.GetStorage().ProxyRange)
-	} else {
-		common.ErrorStrResp(c, "proxy not allowed", 403)
+		if !link.MustProxy {
+			common.ErrorStrResp(c, "proxy not allowed", 403)
+			return
+		}
+		proxyLink(c, storage, link, file)
+		return
+	}
+	downProxyUrl := storage.GetStorage().DownProxyUrl
+	if downProxyUrl != "" {
+		_, ok := c.GetQuery("d")
+		if !ok {
+			URL := common.BuildDownProxyURL(downProxyUrl, rawPath, storage.GetStorage().DownProxySign)
+			c.Redirect(302, URL)
+			return
+		}
+	}
+	// answer conditional requests before getting the link
+	if obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true}); err == nil && !obj.IsDir() &&
+		common.CheckNotModified(c.Writer, c.Request, obj) {
 		return
 	}
+	link, file, err := fs.Link(c, rawPath, model.LinkArgs{
+		Header:  c.Request.Header,
+		Type:    c.Query("type"),
+		HttpReq: c.Request,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	proxyLink(c, storage, link, file)
+}
+
+// proxyLink serves the file by the link through alist, the name query overrides the name of the file
+func proxyLink(c *gin.Context, storage driver.Driver, link *model.Link, file model.Obj) {
+	if name := stdpath.Base(c.Query(common.DownloadNameQuery)); name != "." && name != "/" {
+		file = &model.ObjWrapName{Name: name, Obj: file}
+	}
+	localProxy(c, link, file, storage.GetStorage().ProxyRange)
+	metrics.AddDownloadBytes(storage, int64(c.Writer.Size()))
 }
 
 func down(c *gin.Context, link *model.Link) {
@@ -93,6 +125,8 @@ func down(c *gin.Context, link *model.Link) {
 	}
 	c.Header("Referrer-Policy", "no-referrer")
 	c.Header("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
+	// the headers of a redirected download are sent by the upstream, so the saved name is decided by it.
+	// Use the proxy, /p, if the name must be right, where the Content-Disposition and the filename query apply
 	if setting.GetBool(conf.ForwardDirectLinkParams) {
 		query := c.Request.URL.Query()
 		for _, v := range conf.SlicesMap[conf.IgnoreDirectLinkParams] {
@@ -114,6 +148,7 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 		for _, v := range conf.SlicesMap[conf.IgnoreDirectLinkParams] {
 			query.Del(v)
 		}
+		query.Del(common.DownloadNameQuery)
 		link.URL, err = utils.InjectQuery(link.URL, query)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -149,6 +184,12 @@ func localProxy(c *gin.Context, link *model.Link, file model.Obj, proxyRange boo
 				}
 			}
 		}
+	} else if setting.GetBool(conf.ProxyGzip) && common.AcceptGzip(c.Request) {
+		gw := common.NewGzipResponseWriter(Writer)
+		err = common.Proxy(gw, c.Request, link, file)
+		if cerr := gw.Close(); err == nil {
+			err = cerr
+		}
 	} else {
 		err = common.Proxy(Writer, c.Request, link, file)
 	}
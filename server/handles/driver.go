@@ -16,6 +16,16 @@ func ListDriverNames(c *gin.Context) {
 	common.SuccessResp(c, op.GetDriverNames())
 }
 
+// GetDriverSchema returns the options of the driver with their types, defaults and help
+func GetDriverSchema(c *gin.Context) {
+	fields, err := op.GetDriverSchema(c.Query("driver"))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	common.SuccessResp(c, fields)
+}
+
 func GetDriverInfo(c *gin.Context) {
 	driverName := c.Query("driver")
 	infoMap := op.GetDriverInfoMap()
@@ -1,10 +1,13 @@
 package handles
 
 import (
+	"context"
 	"fmt"
+	stdpath "path"
 	"regexp"
 	"slices"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -129,16 +132,11 @@ func FsRecursiveMove(c *gin.Context) {
 
 	}
 
-	var count = 0
-	for i, fileName := range movingFileNames {
-		// move
-		err := fs.Move(c, fileName, dstDir, len(movingFileNames) > i+1)
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
-		}
-		count++
+	if err := fs.BatchMove(c, movingFileNames, dstDir); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
+	count := len(movingFileNames)
 
 	common.SuccessWithMsgResp(c, fmt.Sprintf("Successfully moved %d %s", count, common.Pluralize(count, "file", "files")))
 }
@@ -181,15 +179,17 @@ func FsBatchRename(c *gin.Context) {
 		}
 	}
 	c.Set("meta", meta)
+	var srcPaths, newNames []string
 	for _, renameObject := range req.RenameObjects {
 		if renameObject.SrcName == "" || renameObject.NewName == "" {
 			continue
 		}
-		filePath := fmt.Sprintf("%s/%s", reqPath, renameObject.SrcName)
-		if err := fs.Rename(c, filePath, renameObject.NewName); err != nil {
-			common.ErrorResp(c, err, 500)
-			return
-		}
+		srcPaths = append(srcPaths, fmt.Sprintf("%s/%s", reqPath, renameObject.SrcName))
+		newNames = append(newNames, renameObject.NewName)
+	}
+	if err := fs.BatchRename(c, srcPaths, newNames); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
 	common.SuccessResp(c)
 }
@@ -259,3 +259,76 @@ func FsRegexRename(c *gin.Context) {
 
 	common.SuccessResp(c)
 }
+
+type BatchMoveCopyReq struct {
+	SrcPaths  []string `json:"src_paths"`
+	DstDir    string   `json:"dst_dir"`
+	Overwrite bool     `json:"overwrite"`
+	// Verify only for copy between two storages, see fs.VerifyHash and fs.VerifyDownload
+	Verify string `json:"verify"`
+}
+
+func FsBatchMove(c *gin.Context) {
+	fsBatchMoveCopy(c, fs.BatchOpMove, common.PermMove)
+}
+
+func FsBatchCopy(c *gin.Context) {
+	fsBatchMoveCopy(c, fs.BatchOpCopy, common.PermCopy)
+}
+
+// fsBatchMoveCopy adds one task for the srcs of any dirs, the result of each src is in the task
+func fsBatchMoveCopy(c *gin.Context, opName string, permission uint) {
+	var req BatchMoveCopyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.SrcPaths) == 0 {
+		common.ErrorStrResp(c, "Empty src paths", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if opName == fs.BatchOpCopy && !fs.IsValidVerify(req.Verify) {
+		common.ErrorStrResp(c, "invalid verify mode", 400)
+		return
+	}
+	srcPaths := make([]string, len(req.SrcPaths))
+	for i, p := range req.SrcPaths {
+		srcPath, err := user.JoinPath(p)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		srcDir := stdpath.Dir(srcPath)
+		if !common.CheckPathLimitWithRoles(user, srcDir) ||
+			!common.HasPermission(common.MergeRolePermissions(user, srcDir), permission) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		if !req.Overwrite {
+			if res, _ := fs.Get(c, stdpath.Join(dstDir, stdpath.Base(srcPath)), &fs.GetArgs{NoLog: true}); res != nil {
+				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", stdpath.Base(srcPath)), 403)
+				return
+			}
+		}
+		srcPaths[i] = srcPath
+	}
+	ctx := context.WithValue(c, conf.CopyVerifyKey, req.Verify)
+	t, err := fs.BatchMoveCopy(ctx, opName, srcPaths, dstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
@@ -0,0 +1,118 @@
+package handles
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsGetByIDReq struct {
+	// Path is in the storage of the obj, e.g. its mount path
+	Path     string `json:"path" form:"path"`
+	ID       string `json:"id" form:"id" binding:"required"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsGetByIDResp struct {
+	ObjResp
+	// FullPath is where the obj is
+	FullPath string `json:"full_path"`
+	RawURL   string `json:"raw_url"`
+	Provider string `json:"provider"`
+}
+
+// FsGetByID gets the obj and the raw url of it by its id in the storage, without resolving its path
+func FsGetByID(c *gin.Context) {
+	var req FsGetByIDReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	obj, fullPath, err := fs.GetByID(c, reqPath, req.ID)
+	if err != nil {
+		if errs.IsNotSupportError(err) {
+			common.ErrorResp(c, err, 501)
+			return
+		}
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// the obj may be anywhere in the storage, so the permissions are checked by where it is
+	if !utils.IsSubPath(user.BasePath, fullPath) || !common.CheckPathLimitWithRoles(user, fullPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(fullPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !common.CanAccessWithRoles(user, meta, fullPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	var rawURL string
+	if !obj.IsDir() {
+		if storage.Config().MustProxy() || storage.GetStorage().WebProxy {
+			rawURL = proxyRawURL(c, storage, meta, fullPath)
+		} else if url, ok := model.GetUrl(obj); ok {
+			rawURL = url
+		} else {
+			link, _, _, err := fs.LinkByID(c, reqPath, req.ID, model.LinkArgs{
+				IP:       c.ClientIP(),
+				Header:   c.Request.Header,
+				HttpReq:  c.Request,
+				Redirect: true,
+			})
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			if link.MustProxy {
+				rawURL = proxyRawURL(c, storage, meta, fullPath)
+			} else {
+				rawURL = link.URL
+			}
+		}
+	}
+	thumb, _ := model.GetThumb(obj)
+	storageClass, _ := model.GetStorageClass(obj)
+	common.SuccessResp(c, FsGetByIDResp{
+		ObjResp: ObjResp{
+			Id:           obj.GetID(),
+			Path:         obj.GetPath(),
+			Name:         obj.GetName(),
+			Size:         obj.GetSize(),
+			IsDir:        obj.IsDir(),
+			Modified:     obj.ModTime(),
+			Created:      obj.CreateTime(),
+			HashInfoStr:  obj.GetHash().String(),
+			HashInfo:     obj.GetHash().Export(),
+			Sign:         common.Sign(obj, stdpath.Dir(fullPath), isEncrypt(meta, fullPath)),
+			Type:         utils.GetFileType(obj.GetName()),
+			Thumb:        thumb,
+			StorageClass: storageClass,
+		},
+		FullPath: fullPath,
+		RawURL:   rawURL,
+		Provider: storage.Config().Name,
+	})
+}
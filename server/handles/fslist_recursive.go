@@ -0,0 +1,101 @@
+package handles
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const listRecursiveFlushEvery = 100
+
+type ListRecursiveReq struct {
+	Path     string `json:"path" form:"path"`
+	MaxDepth int    `json:"max_depth" form:"max_depth"`
+	MaxCount int    `json:"max_count" form:"max_count"`
+	// Pattern only the objs whose names match are listed, a glob or a regex if Regex, see model.NameFilter
+	Pattern string `json:"pattern" form:"pattern"`
+	Regex   bool   `json:"regex" form:"regex"`
+}
+
+type ListRecursiveLine struct {
+	Path     string                     `json:"path"`
+	Name     string                     `json:"name"`
+	Size     int64                      `json:"size"`
+	IsDir    bool                       `json:"is_dir"`
+	Modified time.Time                  `json:"modified"`
+	HashInfo map[*utils.HashType]string `json:"hash_info"`
+}
+
+// FsListRecursive streams all the objects under path as newline-delimited JSON, one object per line.
+// An error after the response has started is written as a last line {"error": "..."}
+func FsListRecursive(c *gin.Context) {
+	var req ListRecursiveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.MaxDepth <= 0 {
+		req.MaxDepth = 20
+	}
+	if req.MaxCount <= 0 {
+		req.MaxCount = 100000
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	var nameFilter *model.NameFilter
+	if req.Pattern != "" {
+		if nameFilter, err = model.NewNameFilter(req.Pattern, req.Regex); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	started := false
+	enc := json.NewEncoder(c.Writer)
+	count := 0
+	err = fs.ListRecursive(c, reqPath, req.MaxDepth, nameFilter, func(path string, obj model.Obj) error {
+		if count >= req.MaxCount {
+			return filepath.SkipAll
+		}
+		if !started {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(200)
+			started = true
+		}
+		count++
+		err := enc.Encode(ListRecursiveLine{
+			Path:     path,
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			IsDir:    obj.IsDir(),
+			Modified: obj.ModTime(),
+			HashInfo: obj.GetHash().Export(),
+		})
+		if err == nil && count%listRecursiveFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return err
+	})
+	if !started {
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(200)
+		return
+	}
+	if err != nil {
+		_ = enc.Encode(gin.H{"error": err.Error()})
+	}
+	c.Writer.Flush()
+}
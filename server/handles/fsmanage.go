@@ -1,16 +1,18 @@
 package handles
 
 import (
+	"context"
 	"fmt"
-	"github.com/alist-org/alist/v3/internal/task"
 	"io"
 	stdpath "path"
 
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/generic"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
@@ -60,11 +62,109 @@ func FsMkdir(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+type InvalidateCacheReq struct {
+	Path      string `json:"path" form:"path"`
+	Recursive bool   `json:"recursive" form:"recursive"`
+}
+
+// FsInvalidateCache drops the cached listing and links of a path, for the changes made outside of alist.
+// It needs the same permission as listing with refresh
+func FsInvalidateCache(c *gin.Context) {
+	var req InvalidateCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if !common.HasPermission(perm, common.PermWrite) {
+		meta, err := op.GetNearestMeta(reqPath)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(meta, reqPath) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	if err := fs.InvalidateCache(reqPath, req.Recursive); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type ClipReq struct {
+	SrcPath string `json:"src_path"`
+	DstDir  string `json:"dst_dir"`
+	Name    string `json:"name"` // name of the new file, derived from the src if empty
+	Start   int64  `json:"start"`
+	Length  int64  `json:"length"`
+}
+
+// FsClip saves a byte range of a file as a new file by a task, it needs the copy permission
+func FsClip(c *gin.Context) {
+	var req ClipReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	srcPath, err := user.JoinPath(req.SrcPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, srcPath) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, srcPath)
+	if !common.HasPermission(perm, common.PermCopy) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if req.Name != "" && (stdpath.Base(req.Name) != req.Name || req.Name == "..") {
+		common.ErrorStrResp(c, "invalid name", 400)
+		return
+	}
+	t, err := fs.Clip(c, srcPath, dstDir, req.Name, req.Start, req.Length)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
 type MoveCopyReq struct {
 	SrcDir    string   `json:"src_dir"`
 	DstDir    string   `json:"dst_dir"`
 	Names     []string `json:"names"`
 	Overwrite bool     `json:"overwrite"`
+	DryRun    bool     `json:"dry_run"`
+	// Verify only for copy between two storages, see fs.VerifyHash and fs.VerifyDownload
+	Verify string `json:"verify"`
+	// Conflict only for copy, stream.ConflictNewer overwrites the existing files only if the src ones are newer
+	Conflict string `json:"conflict"`
 }
 
 func FsMove(c *gin.Context) {
@@ -109,12 +209,26 @@ func FsMove(c *gin.Context) {
 			}
 		}
 	}
-	for i, name := range req.Names {
-		err := fs.Move(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
+	if req.DryRun {
+		plan := &fs.Plan{}
+		for _, name := range req.Names {
+			p, err := fs.PlanMove(c, stdpath.Join(srcDir, name), dstDir)
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			plan.Merge(p)
 		}
+		common.SuccessResp(c, plan)
+		return
+	}
+	srcPaths := make([]string, len(req.Names))
+	for i, name := range req.Names {
+		srcPaths[i] = stdpath.Join(srcDir, name)
+	}
+	if err := fs.BatchMove(c, srcPaths, dstDir); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
 	common.SuccessResp(c)
 }
@@ -153,7 +267,15 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	if !req.Overwrite {
+	if !fs.IsValidVerify(req.Verify) {
+		common.ErrorStrResp(c, "invalid verify mode", 400)
+		return
+	}
+	if req.Conflict != "" && req.Conflict != stream.ConflictNewer {
+		common.ErrorStrResp(c, "invalid conflict policy", 400)
+		return
+	}
+	if !req.Overwrite && req.Conflict != stream.ConflictNewer {
 		for _, name := range req.Names {
 			if res, _ := fs.Get(c, stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil {
 				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
@@ -161,16 +283,29 @@ func FsCopy(c *gin.Context) {
 			}
 		}
 	}
-	var addedTasks []task.TaskExtensionInfo
-	for i, name := range req.Names {
-		t, err := fs.Copy(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
-		if t != nil {
-			addedTasks = append(addedTasks, t)
-		}
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
+	if req.DryRun {
+		plan := &fs.Plan{}
+		for _, name := range req.Names {
+			p, err := fs.PlanCopy(c, stdpath.Join(srcDir, name), dstDir)
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			plan.Merge(p)
 		}
+		common.SuccessResp(c, plan)
+		return
+	}
+	ctx := context.WithValue(c, conf.CopyVerifyKey, req.Verify)
+	ctx = context.WithValue(ctx, conf.CopyConflictKey, req.Conflict)
+	srcPaths := make([]string, len(req.Names))
+	for i, name := range req.Names {
+		srcPaths[i] = stdpath.Join(srcDir, name)
+	}
+	addedTasks, err := fs.BatchCopy(ctx, srcPaths, dstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
 	common.SuccessResp(c, gin.H{
 		"tasks": getTaskInfos(addedTasks),
@@ -223,6 +358,8 @@ func FsRename(c *gin.Context) {
 type RemoveReq struct {
 	Dir   string   `json:"dir"`
 	Names []string `json:"names"`
+	// Permanent skip the recycle bin of the driver
+	Permanent bool `json:"permanent"`
 }
 
 func FsRemove(c *gin.Context) {
@@ -250,9 +387,19 @@ func FsRemove(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	for _, name := range req.Names {
-		err := fs.Remove(c, stdpath.Join(reqDir, name))
-		if err != nil {
+	if req.Permanent {
+		for _, name := range req.Names {
+			if err := fs.RemovePermanently(c, stdpath.Join(reqDir, name)); err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+		}
+	} else {
+		paths := make([]string, len(req.Names))
+		for i, name := range req.Names {
+			paths[i] = stdpath.Join(reqDir, name)
+		}
+		if err := fs.BatchRemove(c, paths); err != nil {
 			common.ErrorResp(c, err, 500)
 			return
 		}
@@ -0,0 +1,92 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsPackReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	Format   string `json:"format" form:"format"`
+	Store    bool   `json:"store" form:"store"`
+	// Flatten packs all the files in the archive root instead of keeping the dirs
+	Flatten bool `json:"flatten" form:"flatten"`
+}
+
+// FsPack download the dir as a zip or tar archive, which is generated on the fly
+func FsPack(c *gin.Context) {
+	var req FsPackReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = fs.PackZip
+	}
+	if !fs.IsValidPackFormat(req.Format) {
+		common.ErrorStrResp(c, "invalid format", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	c.Set("meta", meta)
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	dir, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !dir.IsDir() {
+		common.ErrorStrResp(c, "not a dir", 400)
+		return
+	}
+	name := dir.GetName()
+	if name == "" || reqPath == "/" {
+		name = "root"
+	}
+	fileName := name + "." + req.Format
+	c.Header("Content-Disposition", common.ContentDisposition(fileName))
+	if req.Format == fs.PackZip {
+		c.Header("Content-Type", "application/zip")
+	} else {
+		c.Header("Content-Type", "application/x-tar")
+	}
+	c.Status(200)
+	// the response has been started, so an error can only be logged by fs.Pack
+	_ = fs.Pack(c, c.Writer, reqPath, fs.PackArgs{
+		Format:  req.Format,
+		Store:   req.Store,
+		Flatten: req.Flatten,
+		Filter: func(p string, obj model.Obj) bool {
+			if !obj.IsDir() {
+				return common.CanReadPathByRole(user, p)
+			}
+			// a sub dir may have its own password
+			m, err := op.GetNearestMeta(p)
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				return false
+			}
+			return common.CanAccessWithRoles(user, m, p, req.Password)
+		},
+	})
+}
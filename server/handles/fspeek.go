@@ -0,0 +1,77 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type PeekReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Size is the bytes to read from the start of the file, capped by the peek_max_size setting, the max if 0
+	Size int64 `json:"size" form:"size"`
+}
+
+type PeekResp struct {
+	Content []byte `json:"content"` // base64 encoded in the json
+	// Size is the size of the whole file
+	Size int64 `json:"size"`
+	// Truncated tells that Content isn't the whole file
+	Truncated bool `json:"truncated"`
+}
+
+// FsPeek returns the first bytes of a file without downloading the whole file
+func FsPeek(c *gin.Context) {
+	var req PeekReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Size < 0 {
+		common.ErrorStrResp(c, "invalid size", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	maxSize := int64(setting.GetInt(conf.PeekMaxSize, 1024)) * utils.KB
+	if maxSize <= 0 {
+		common.ErrorStrResp(c, "peek is disabled by the peek_max_size setting", 403)
+		return
+	}
+	if req.Size == 0 || req.Size > maxSize {
+		req.Size = maxSize
+	}
+	data, obj, err := fs.Peek(c, reqPath, req.Size)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, PeekResp{
+		Content:   data,
+		Size:      obj.GetSize(),
+		Truncated: int64(len(data)) < obj.GetSize(),
+	})
+}
@@ -1,12 +1,14 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -17,6 +19,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type ListReq struct {
@@ -24,6 +27,14 @@ type ListReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
 	Refresh  bool   `json:"refresh"`
+	// Paged list by cursor instead of page, per_page objs are returned each time
+	Paged  bool   `json:"paged" form:"paged"`
+	Cursor string `json:"cursor" form:"cursor"`
+	// ModifiedSince unix seconds, only the files modified at or after it are listed if set, it's ignored if paged
+	ModifiedSince int64 `json:"modified_since" form:"modified_since"`
+	// Pattern only the objs whose names match are listed, a glob or a regex if Regex, see model.NameFilter
+	Pattern string `json:"pattern" form:"pattern"`
+	Regex   bool   `json:"regex" form:"regex"`
 }
 
 type DirReq struct {
@@ -55,6 +66,13 @@ type FsListResp struct {
 	Header   string         `json:"header"`
 	Write    bool           `json:"write"`
 	Provider string         `json:"provider"`
+	// NextCursor is the cursor of the next page if paged or truncated, empty if it's the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Stale is true if the storage failed transiently and the cached listing is returned
+	Stale bool `json:"stale,omitempty"`
+	// Truncated is true if the dir has more objs than the max list entries of the storage,
+	// the rest can be fetched by the paged list from NextCursor
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 type ObjLabelResp struct {
@@ -104,7 +122,47 @@ func FsList(c *gin.Context) {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
-	objs, err := fs.List(c, reqPath, &fs.ListArgs{Refresh: req.Refresh})
+	var nameFilter *model.NameFilter
+	if req.Pattern != "" {
+		if nameFilter, err = model.NewNameFilter(req.Pattern, req.Regex); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	provider := "unknown"
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err == nil {
+		provider = storage.GetStorage().Driver
+	}
+	var (
+		objs       []model.Obj
+		nextCursor string
+		stale      bool
+		truncated  bool
+	)
+	maxEntries := 0
+	if storage != nil && nameFilter == nil && req.ModifiedSince == 0 {
+		maxEntries = op.MaxListEntries(storage)
+	}
+	if req.Paged {
+		objs, nextCursor, err = fs.ListPage(c, reqPath, &fs.ListPageArgs{
+			Cursor:  req.Cursor,
+			Limit:   req.PerPage,
+			Refresh: req.Refresh,
+		})
+	} else {
+		listArgs := &fs.ListArgs{Refresh: req.Refresh, NameFilter: nameFilter}
+		if req.ModifiedSince > 0 {
+			listArgs.ModifiedSince = time.Unix(req.ModifiedSince, 0)
+		}
+		objs, err = fs.List(context.WithValue(c, conf.StaleListKey, &stale), reqPath, listArgs)
+		// only the first objs of a large dir are returned, the rest can be fetched by the paged list from the next cursor,
+		// which is served from the cached listing
+		if err == nil && maxEntries > 0 && len(objs) > maxEntries {
+			objs, nextCursor, truncated = objs[:maxEntries], op.ListCursor(storage, maxEntries), true
+			log.Warnf("the list of %s is truncated to %d objs, the rest should be paged", reqPath, maxEntries)
+		}
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -112,23 +170,31 @@ func FsList(c *gin.Context) {
 	filtered := make([]model.Obj, 0, len(objs))
 	for _, obj := range objs {
 		childPath := stdpath.Join(reqPath, obj.GetName())
+		// a paged list is filtered page by page
+		if req.Paged && nameFilter != nil && !nameFilter.Match(obj.GetName()) {
+			continue
+		}
 		if common.CanReadPathByRole(user, childPath) {
 			filtered = append(filtered, obj)
 		}
 	}
-	total, objs := pagination(filtered, &req.PageReq)
-	provider := "unknown"
-	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
-	if err == nil {
-		provider = storage.GetStorage().Driver
+	var total int
+	if req.Paged {
+		// the total is unknown until the last page
+		total, objs = -1, filtered
+	} else {
+		total, objs = pagination(filtered, &req.PageReq)
 	}
 	common.SuccessResp(c, FsListResp{
-		Content:  toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
-		Total:    int64(total),
-		Readme:   getReadme(meta, reqPath),
-		Header:   getHeader(meta, reqPath),
-		Write:    common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath),
-		Provider: provider,
+		Content:    toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
+		Total:      int64(total),
+		Readme:     getReadme(meta, reqPath),
+		Header:     getHeader(meta, reqPath),
+		Write:      common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath),
+		Provider:   provider,
+		NextCursor: nextCursor,
+		Stale:      stale,
+		Truncated:  truncated,
 	})
 }
 
@@ -259,6 +325,7 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 		}
 		thumb, _ := model.GetThumb(obj)
 		storageClass, _ := model.GetStorageClass(obj)
+		hashInfo := fs.CachedHash(stdpath.Join(parent, obj.GetName()), obj).GetHash()
 		resp = append(resp, ObjLabelResp{
 			Id:           obj.GetID(),
 			Path:         obj.GetPath(),
@@ -267,8 +334,8 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 			IsDir:        obj.IsDir(),
 			Modified:     obj.ModTime(),
 			Created:      obj.CreateTime(),
-			HashInfoStr:  obj.GetHash().String(),
-			HashInfo:     obj.GetHash().Export(),
+			HashInfoStr:  hashInfo.String(),
+			HashInfo:     hashInfo.Export(),
 			Sign:         common.Sign(obj, parent, encrypt),
 			Thumb:        thumb,
 			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
@@ -282,6 +349,9 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 type FsGetReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
+	// Hash is the comma separated hashes to return, e.g. "md5,crc32", the ones the storage doesn't provide
+	// are computed by downloading the file
+	Hash string `json:"hash" form:"hash"`
 }
 
 type FsGetResp struct {
@@ -291,6 +361,27 @@ type FsGetResp struct {
 	Header   string         `json:"header"`
 	Provider string         `json:"provider"`
 	Related  []ObjLabelResp `json:"related"`
+	// ThumbURL is a signed link of the thumbnail which can be embedded in other pages, add &w= to choose the width
+	ThumbURL string `json:"thumb_url,omitempty"`
+}
+
+// proxyRawURL is the raw url of the file at reqPath proxied by the storage
+func proxyRawURL(c *gin.Context, storage driver.Driver, meta *model.Meta, reqPath string) string {
+	if storage.GetStorage().DownProxyUrl != "" {
+		return common.BuildDownProxyURL(
+			storage.GetStorage().DownProxyUrl,
+			reqPath,
+			storage.GetStorage().DownProxySign,
+		)
+	}
+	query := ""
+	if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
+		query = "?sign=" + sign.Sign(reqPath)
+	}
+	return fmt.Sprintf("%s/p%s%s",
+		common.GetApiUrl(c.Request),
+		utils.EncodePath(reqPath, true),
+		query)
 }
 
 func FsGet(c *gin.Context) {
@@ -322,6 +413,18 @@ func FsGet(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	hashInfo := fs.CachedHash(reqPath, obj).GetHash()
+	if req.Hash != "" && !obj.IsDir() {
+		types, err := fs.ParseHashTypes(req.Hash)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if hashInfo, err = fs.Hash(c, reqPath, types); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
 	var rawURL string
 
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
@@ -335,22 +438,7 @@ func FsGet(c *gin.Context) {
 			return
 		}
 		if storage.Config().MustProxy() || storage.GetStorage().WebProxy {
-			query := ""
-			if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
-				query = "?sign=" + sign.Sign(reqPath)
-			}
-			if storage.GetStorage().DownProxyUrl != "" {
-				rawURL = common.BuildDownProxyURL(
-					storage.GetStorage().DownProxyUrl,
-					reqPath,
-					storage.GetStorage().DownProxySign,
-				)
-			} else {
-				rawURL = fmt.Sprintf("%s/p%s%s",
-					common.GetApiUrl(c.Request),
-					utils.EncodePath(reqPath, true),
-					query)
-			}
+			rawURL = proxyRawURL(c, storage, meta, reqPath)
 		} else {
 			// file have raw url
 			if url, ok := model.GetUrl(obj); ok {
@@ -367,7 +455,11 @@ func FsGet(c *gin.Context) {
 					common.ErrorResp(c, err, 500)
 					return
 				}
-				rawURL = link.URL
+				if link.MustProxy {
+					rawURL = proxyRawURL(c, storage, meta, reqPath)
+				} else {
+					rawURL = link.URL
+				}
 			}
 		}
 	}
@@ -380,6 +472,11 @@ func FsGet(c *gin.Context) {
 	parentMeta, _ := op.GetNearestMeta(parentPath)
 	thumb, _ := model.GetThumb(obj)
 	storageClass, _ := model.GetStorageClass(obj)
+	var thumbURL string
+	if !obj.IsDir() && (thumb != "" || utils.GetFileType(obj.GetName()) == conf.IMAGE) {
+		thumbURL = fmt.Sprintf("%s/t%s?sign=%s", common.GetApiUrl(c.Request),
+			utils.EncodePath(reqPath, true), sign.SignThumb(reqPath))
+	}
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
 			Id:           obj.GetID(),
@@ -389,8 +486,8 @@ func FsGet(c *gin.Context) {
 			IsDir:        obj.IsDir(),
 			Modified:     obj.ModTime(),
 			Created:      obj.CreateTime(),
-			HashInfoStr:  obj.GetHash().String(),
-			HashInfo:     obj.GetHash().Export(),
+			HashInfoStr:  hashInfo.String(),
+			HashInfo:     hashInfo.Export(),
 			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
@@ -401,6 +498,7 @@ func FsGet(c *gin.Context) {
 		Header:   getHeader(meta, reqPath),
 		Provider: provider,
 		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath)),
+		ThumbURL: thumbURL,
 	})
 }
 
@@ -418,6 +516,21 @@ func filterRelated(objs []model.Obj, obj model.Obj) []model.Obj {
 	return related
 }
 
+// canCallOther tells whether the user has the permission the kind of the method of driver.Other needs
+func canCallOther(user *model.User, meta *model.Meta, reqPath string, kind int) bool {
+	if kind == driver.OtherRead {
+		return true
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		return false
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if kind == driver.OtherRemove {
+		return common.HasPermission(perm, common.PermRemove)
+	}
+	return common.HasPermission(perm, common.PermWrite) || common.CanWrite(meta, reqPath)
+}
+
 type FsOtherReq struct {
 	model.FsOtherArgs
 	Password string `json:"password" form:"password"`
@@ -448,6 +561,12 @@ func FsOther(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
+	if storage, err := fs.GetStorage(req.Path, &fs.GetStoragesArgs{}); err == nil {
+		if !canCallOther(user, meta, req.Path, op.OtherKind(storage, req.Method)) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
 	res, err := fs.Other(c, req.FsOtherArgs)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
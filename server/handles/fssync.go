@@ -0,0 +1,122 @@
+package handles
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type SyncReq struct {
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+	// Delete removes the objs only in the dst, only for sync
+	Delete bool `json:"delete"`
+	// Verify only for sync, see fs.VerifyHash and fs.VerifyDownload
+	Verify string `json:"verify"`
+	// Conflict is empty or stream.ConflictNewer to overwrite the changed files only if the src ones are newer
+	Conflict string `json:"conflict"`
+	// Ignore is the ignore patterns of the job matched relative to the dst dir, see model.IgnorePatterns
+	Ignore string `json:"ignore"`
+}
+
+// syncPaths resolves the dirs of the req for the user, false if the error has been responded
+func syncPaths(c *gin.Context, req *SyncReq) (string, string, bool) {
+	user := c.MustGet("user").(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", "", false
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", "", false
+	}
+	if !common.CheckPathLimitWithRoles(user, srcDir) || !common.CheckPathLimitWithRoles(user, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", "", false
+	}
+	// the objs under them are filtered by the metas while listing, see fs.ListRecursive
+	for _, dir := range []string{srcDir, dstDir} {
+		meta, err := op.GetNearestMeta(dir)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return "", "", false
+		}
+		if !common.CanAccessWithRoles(user, meta, dir, "") {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return "", "", false
+		}
+	}
+	if _, err := model.NewIgnorePatterns(req.Ignore); err != nil {
+		common.ErrorResp(c, err, 400)
+		return "", "", false
+	}
+	return srcDir, dstDir, true
+}
+
+func FsDiff(c *gin.Context) {
+	var req SyncReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	srcDir, dstDir, ok := syncPaths(c, &req)
+	if !ok {
+		return
+	}
+	ctx := context.WithValue(c, conf.CopyIgnoreKey, req.Ignore)
+	diff, err := fs.DiffDirs(ctx, srcDir, dstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, diff)
+}
+
+func FsSync(c *gin.Context) {
+	var req SyncReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	srcDir, dstDir, ok := syncPaths(c, &req)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !common.HasPermission(common.MergeRolePermissions(user, srcDir), common.PermCopy) ||
+		req.Delete && !common.HasPermission(common.MergeRolePermissions(user, dstDir), common.PermRemove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !fs.IsValidVerify(req.Verify) {
+		common.ErrorStrResp(c, "invalid verify mode", 400)
+		return
+	}
+	if req.Conflict != "" && req.Conflict != stream.ConflictNewer {
+		common.ErrorStrResp(c, "invalid conflict policy", 400)
+		return
+	}
+	ctx := context.WithValue(c, conf.CopyVerifyKey, req.Verify)
+	ctx = context.WithValue(ctx, conf.CopyConflictKey, req.Conflict)
+	ctx = context.WithValue(ctx, conf.CopyIgnoreKey, req.Ignore)
+	res, err := fs.SyncDirs(ctx, srcDir, dstDir, req.Delete)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"diff":    res.Diff,
+		"deleted": res.Deleted,
+		"tasks":   getTaskInfos(res.Tasks),
+	})
+}
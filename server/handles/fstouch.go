@@ -0,0 +1,107 @@
+package handles
+
+import (
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type TouchReq struct {
+	Path string `json:"path"`
+	// Mtime is the unix seconds to set, now if 0
+	Mtime int64 `json:"mtime"`
+}
+
+type TruncateReq struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// writablePath joins path to the base path of the user and checks the write permission like FsMkdir,
+// the error response is written if it returns false
+func writablePath(c *gin.Context, path string) (string, bool) {
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", false
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", false
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if !common.HasPermission(perm, common.PermWrite) {
+		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return "", false
+			}
+		}
+		if !common.CanWrite(meta, reqPath) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return "", false
+		}
+	}
+	return reqPath, true
+}
+
+// FsTouch creates an empty file, or sets the mtime of the existing one
+func FsTouch(c *gin.Context) {
+	var req TouchReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, ok := writablePath(c, req.Path)
+	if !ok {
+		return
+	}
+	mtime := time.Now()
+	if req.Mtime > 0 {
+		mtime = time.Unix(req.Mtime, 0)
+	}
+	if err := fs.Touch(c, reqPath, mtime); err != nil {
+		if errs.IsNotSupportError(err) {
+			common.ErrorResp(c, err, 501)
+			return
+		}
+		common.ErrorResp(c, err, putErrCode(err))
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// FsTruncate changes the size of a file
+func FsTruncate(c *gin.Context) {
+	var req TruncateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Size < 0 {
+		common.ErrorStrResp(c, "invalid size", 400)
+		return
+	}
+	reqPath, ok := writablePath(c, req.Path)
+	if !ok {
+		return
+	}
+	if err := fs.Truncate(c, reqPath, req.Size); err != nil {
+		if errors.Is(errors.Cause(err), errs.TruncateNotSupported) {
+			common.ErrorResp(c, err, 501)
+			return
+		}
+		common.ErrorResp(c, err, putErrCode(err))
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -7,13 +7,16 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func getLastModified(c *gin.Context) time.Time {
@@ -36,13 +39,21 @@ func FsStream(c *gin.Context) {
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
+	// skip the upload if the existing file has the same size and hash
+	skipIfMatch := c.GetHeader("Skip-If-Match") == "true"
+	// overwrite, rename (keep both) or skip if the file exists, overrides the Overwrite header
+	conflict := c.GetHeader("Conflict")
+	if !stream.IsValidConflict(conflict) {
+		common.ErrorStrResp(c, "invalid conflict: "+conflict, 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !overwrite {
+	if !overwrite && conflict == "" {
 		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
 			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
 			common.ErrorStrResp(c, "file exists", 403)
@@ -80,6 +91,8 @@ func FsStream(c *gin.Context) {
 		Reader:       c.Request.Body,
 		Mimetype:     mimetype,
 		WebPutAsTask: asTask,
+		SkipIfMatch:  skipIfMatch,
+		Conflict:     conflict,
 	}
 	var t task.TaskExtensionInfo
 	if asTask {
@@ -89,7 +102,7 @@ func FsStream(c *gin.Context) {
 	}
 	defer c.Request.Body.Close()
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
 	if t == nil {
@@ -113,13 +126,21 @@ func FsForm(c *gin.Context) {
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
+	// skip the upload if the existing file has the same size and hash
+	skipIfMatch := c.GetHeader("Skip-If-Match") == "true"
+	// overwrite, rename (keep both) or skip if the file exists, overrides the Overwrite header
+	conflict := c.GetHeader("Conflict")
+	if !stream.IsValidConflict(conflict) {
+		common.ErrorStrResp(c, "invalid conflict: "+conflict, 400)
+		return
+	}
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !overwrite {
+	if !overwrite && conflict == "" {
 		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
 			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
 			common.ErrorStrResp(c, "file exists", 403)
@@ -171,6 +192,8 @@ func FsForm(c *gin.Context) {
 		Reader:       f,
 		Mimetype:     mimetype,
 		WebPutAsTask: asTask,
+		SkipIfMatch:  skipIfMatch,
+		Conflict:     conflict,
 	}
 	var t task.TaskExtensionInfo
 	if asTask {
@@ -182,7 +205,7 @@ func FsForm(c *gin.Context) {
 		err = fs.PutDirectly(c, dir, &s, true)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
 	if t == nil {
@@ -193,3 +216,73 @@ func FsForm(c *gin.Context) {
 		"task": getTaskInfo(t),
 	})
 }
+
+type PutURLReq struct {
+	Path string `json:"path" binding:"required"`
+	Name string `json:"name"`
+	Url  string `json:"url" binding:"required"`
+}
+
+// FsPutURL fetch the url by alist and stream it to the dir in a upload task
+func FsPutURL(c *gin.Context) {
+	var req PutURLReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Name != "" && stdpath.Base(req.Name) != req.Name {
+		common.ErrorStrResp(c, "invalid name", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	// alist fetches the url as an offline download does
+	if !common.HasPermission(perm, common.PermAddOfflineDownload) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(perm, common.PermWrite) {
+		meta, err := op.GetNearestMeta(reqPath)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		if !common.CanWrite(meta, reqPath) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	}
+	t, err := fs.PutURLAsTask(c, reqPath, req.Name, req.Url)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+// putErrCode is 403 if the user is out of upload quota
+func putErrCode(err error) int {
+	if errors.Is(err, errs.UploadQuotaExceeded) {
+		return 403
+	}
+	if errors.Is(err, errs.UploadTooLarge) {
+		return 413
+	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return 507
+	}
+	return 500
+}
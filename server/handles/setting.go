@@ -41,6 +41,8 @@ func ResetToken(c *gin.Context) {
 		return
 	}
 	sign.Instance()
+	sign.InstanceShare()
+	sign.InstanceThumb()
 	common.SuccessResp(c, token)
 }
 
@@ -56,6 +58,8 @@ func SetToken(c *gin.Context) {
 		return
 	}
 	sign.Instance()
+	sign.InstanceShare()
+	sign.InstanceThumb()
 	common.SuccessResp(c, req.Token)
 }
 
@@ -0,0 +1,114 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ShareLinkReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Expire seconds, 0 for the link expiration of the settings
+	Expire int64 `json:"expire" form:"expire"`
+}
+
+type ShareLinkResp struct {
+	URL      string     `json:"url"`
+	ExpireAt *time.Time `json:"expire_at"`
+}
+
+// FsShareLink returns a signed url that proxies the file through alist, so the link of the driver is not exposed
+func FsShareLink(c *gin.Context) {
+	var req ShareLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Expire < 0 {
+		common.ErrorStrResp(c, "expire must not be negative", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	c.Set("meta", meta)
+	if !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorResp(c, errs.NotFile, 400)
+		return
+	}
+	expire := time.Duration(req.Expire) * time.Second
+	if expire == 0 {
+		expire = time.Duration(setting.GetInt(conf.LinkExpiration, 0)) * time.Hour
+	}
+	var (
+		s        string
+		expireAt *time.Time
+	)
+	if expire > 0 {
+		s = sign.WithDurationShare(reqPath, expire)
+		t := time.Now().Add(expire)
+		expireAt = &t
+	} else {
+		s = sign.NotExpiredShare(reqPath)
+	}
+	common.SuccessResp(c, ShareLinkResp{
+		URL:      fmt.Sprintf("%s/sp%s?sign=%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true), s),
+		ExpireAt: expireAt,
+	})
+}
+
+// ShareProxy proxies the file of a share link whatever the proxy settings of the storage are
+func ShareProxy(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// answer conditional requests before getting the link
+	if obj, err := fs.Get(c, rawPath, &fs.GetArgs{NoLog: true}); err == nil && !obj.IsDir() &&
+		common.CheckNotModified(c.Writer, c.Request, obj) {
+		return
+	}
+	link, file, err := fs.Link(c, rawPath, model.LinkArgs{
+		Header:  c.Request.Header,
+		Type:    c.Query("type"),
+		HttpReq: c.Request,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	localProxy(c, link, file, storage.GetStorage().ProxyRange)
+}
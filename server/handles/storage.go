@@ -3,10 +3,14 @@ package handles
 import (
 	"context"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -27,11 +31,60 @@ func ListStorages(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, common.PageResp{
-		Content: storages,
+		Content: makeStorageResp(c, storages),
 		Total:   total,
 	})
 }
 
+type StorageResp struct {
+	model.Storage
+	MountDetails *model.StorageDetails `json:"mount_details,omitempty"`
+	Capabilities *op.Capabilities      `json:"capabilities,omitempty"`
+}
+
+func getCapabilities(d driver.Driver) *op.Capabilities {
+	c := op.GetCapabilities(d)
+	// the offline download tool named after the driver downloads to the storage directly
+	if _, err := tool.Tools.Get(d.Config().Name); err == nil {
+		c.OfflineDownload = true
+	}
+	return &c
+}
+
+// makeStorageResp attach the capabilities and quota of each storage, a failed quota call is only logged
+func makeStorageResp(ctx context.Context, storages []model.Storage) []*StorageResp {
+	ret := make([]*StorageResp, len(storages))
+	var wg sync.WaitGroup
+	for i, s := range storages {
+		ret[i] = &StorageResp{Storage: s}
+		if s.Disabled {
+			continue
+		}
+		d, err := op.GetStorageByMountPath(s.MountPath)
+		if err != nil {
+			continue
+		}
+		ret[i].Capabilities = getCapabilities(d)
+		if _, ok := d.(driver.WithDetails); !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(resp *StorageResp) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, time.Second*3)
+			defer cancel()
+			details, err := op.GetStorageDetails(ctx, d)
+			if err != nil {
+				log.Warnf("failed get details of storage [%s]: %+v", resp.MountPath, err)
+				return
+			}
+			resp.MountDetails = details
+		}(ret[i])
+	}
+	wg.Wait()
+	return ret
+}
+
 func CreateStorage(c *gin.Context) {
 	var req model.Storage
 	if err := c.ShouldBind(&req); err != nil {
@@ -116,7 +169,13 @@ func GetStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
-	common.SuccessResp(c, storage)
+	resp := &StorageResp{Storage: *storage}
+	if !storage.Disabled {
+		if d, err := op.GetStorageByMountPath(storage.MountPath); err == nil {
+			resp.Capabilities = getCapabilities(d)
+		}
+	}
+	common.SuccessResp(c, resp)
 }
 
 func LoadAllStorages(c *gin.Context) {
@@ -150,3 +209,45 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+const defaultHealthCheckTimeout = 10 * time.Second
+
+// StorageHealth self-test the storage with the id, or all enabled storages if no id
+func StorageHealth(c *gin.Context) {
+	timeout := defaultHealthCheckTimeout
+	if t, err := strconv.Atoi(c.Query("timeout")); err == nil && t > 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+	var storages []driver.Driver
+	if idStr := c.Query("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		storage, err := db.GetStorageById(uint(id))
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		d, err := op.GetStorageByMountPath(storage.MountPath)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		storages = append(storages, d)
+	} else {
+		storages = op.GetAllStorages()
+	}
+	ret := make([]*op.StorageHealth, len(storages))
+	var wg sync.WaitGroup
+	for i, d := range storages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret[i] = op.CheckStorageHealth(c, d, timeout)
+		}()
+	}
+	wg.Wait()
+	common.SuccessResp(c, ret)
+}
@@ -25,6 +25,7 @@ type TaskInfo struct {
 	StartTime   *time.Time  `json:"start_time"`
 	EndTime     *time.Time  `json:"end_time"`
 	TotalBytes  int64       `json:"total_bytes"`
+	Speed       int64       `json:"speed"`
 	Error       string      `json:"error"`
 }
 
@@ -55,6 +56,7 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		StartTime:   task.GetStartTime(),
 		EndTime:     task.GetEndTime(),
 		TotalBytes:  task.GetTotalBytes(),
+		Speed:       task.GetSpeed(),
 		Error:       errMsg,
 	}
 }
@@ -215,12 +217,29 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 	})
 }
 
+// queueRoute adds the routes to bump the queued tasks of a manager whose runs are admitted by queue
+func queueRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manager[T], queue *task.Queue) {
+	g.POST("/bump", getTargetedHandler(manager, func(c *gin.Context, task T) {
+		if !queue.Bump(task.GetID()) {
+			common.ErrorStrResp(c, "task is not queued", 400)
+			return
+		}
+		common.SuccessResp(c)
+	}))
+}
+
 func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager)
 	taskRoute(g.Group("/copy"), fs.CopyTaskManager)
-	taskRoute(g.Group("/offline_download"), tool.DownloadTaskManager)
-	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
+	download := g.Group("/offline_download")
+	taskRoute(download, tool.DownloadTaskManager)
+	queueRoute(download, tool.DownloadTaskManager, tool.DownloadQueue)
+	transfer := g.Group("/offline_download_transfer")
+	taskRoute(transfer, tool.TransferTaskManager)
+	queueRoute(transfer, tool.TransferTaskManager, tool.TransferQueue)
 	taskRoute(g.Group("/s3_transition"), fs.S3TransitionTaskManager)
+	taskRoute(g.Group("/clip"), fs.ClipTaskManager)
+	taskRoute(g.Group("/batch"), fs.BatchTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
 }
@@ -0,0 +1,34 @@
+package handles
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Thumb serves the thumbnail of an image in the width of query w,
+// the link is signed by sign.SignThumb so it can be embedded in other pages
+func Thumb(c *gin.Context) {
+	rawPath := c.MustGet("path").(string)
+	width := fs.DefaultThumbWidth
+	if w := c.Query("w"); w != "" {
+		var err error
+		width, err = strconv.Atoi(w)
+		if err != nil || !slices.Contains(fs.ThumbWidths, width) {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid width, must be one of %v", fs.ThumbWidths), 400)
+			return
+		}
+	}
+	thumbPath, err := fs.Thumb(c, rawPath, width)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// the link doesn't change when the image is modified, so it's only cached for a while
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.File(thumbPath)
+}
@@ -0,0 +1,444 @@
+package handles
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// tus 1.0.0 resumable uploads, with the creation, concatenation and termination extensions.
+// The chunks are appended to a temp file, the file is put to the storage once it's complete,
+// so a dropped connection only needs to resume from the offset on the disk.
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,concatenation,termination"
+)
+
+// tusInfo is saved beside the data file, the offset is the size of the data file
+type tusInfo struct {
+	ID       string    `json:"id"`
+	UserID   uint      `json:"user_id"`
+	Path     string    `json:"path"` // dst path, empty for a partial upload
+	Length   int64     `json:"length"`
+	Partial  bool      `json:"partial"`
+	Mimetype string    `json:"mimetype"`
+	Modified time.Time `json:"modified"`
+	Created  time.Time `json:"created"`
+}
+
+var tusLocks sync.Map // id => *sync.Mutex
+
+// tusDir is in the data dir rather than the temp dir, which is cleaned on startup, so that the uploads can be resumed
+// after a restart
+func tusDir() string {
+	return filepath.Join(flags.DataDir, "tus")
+}
+
+func tusDataPath(id string) string {
+	return filepath.Join(tusDir(), id+".bin")
+}
+
+func tusInfoPath(id string) string {
+	return filepath.Join(tusDir(), id+".json")
+}
+
+func tusLock(id string) func() {
+	l, _ := tusLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := l.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func loadTusInfo(id string) (*tusInfo, error) {
+	// the id is used in file names
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(tusInfoPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var info tusInfo
+	if err = utils.Json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func saveTusInfo(info *tusInfo) error {
+	data, err := utils.Json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tusInfoPath(info.ID), data, 0644)
+}
+
+func tusOffset(id string) (int64, error) {
+	fi, err := os.Stat(tusDataPath(id))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func removeTus(id string) {
+	_ = os.Remove(tusDataPath(id))
+	_ = os.Remove(tusInfoPath(id))
+	tusLocks.Delete(id)
+}
+
+func tusError(c *gin.Context, code int, err error) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.String(code, err.Error())
+	c.Abort()
+}
+
+// parseTusMetadata parses the Upload-Metadata header, "key base64(value),key2 base64(value2)"
+func parseTusMetadata(s string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if k == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(v)
+		if err == nil {
+			m[k] = string(value)
+		}
+	}
+	return m
+}
+
+// getTusUpload loads the upload of :id which must be created by the current user
+func getTusUpload(c *gin.Context) (*tusInfo, bool) {
+	info, err := loadTusInfo(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			tusError(c, http.StatusNotFound, errors.New("upload not found"))
+		} else {
+			tusError(c, http.StatusInternalServerError, err)
+		}
+		return nil, false
+	}
+	if user := c.MustGet("user").(*model.User); info.UserID != user.ID {
+		tusError(c, http.StatusNotFound, errors.New("upload not found"))
+		return nil, false
+	}
+	return info, true
+}
+
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Status(http.StatusNoContent)
+}
+
+// TusCreate creates an upload, the dst path is in the File-Path header as FsStream,
+// except for a partial upload of the concatenation extension
+func TusCreate(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, err)
+		return
+	}
+	if path, err = user.JoinPath(path); err != nil {
+		tusError(c, http.StatusForbidden, err)
+		return
+	}
+	concat := c.GetHeader("Upload-Concat")
+	info := &tusInfo{
+		ID:       uuid.NewString(),
+		UserID:   user.ID,
+		Path:     path,
+		Partial:  concat == "partial",
+		Modified: getLastModified(c),
+		Created:  time.Now(),
+	}
+	metadata := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	info.Mimetype = metadata["filetype"]
+	if info.Mimetype == "" {
+		info.Mimetype = utils.GetMimeType(stdpath.Base(path))
+	}
+	if info.Partial {
+		info.Path = ""
+	} else if c.GetHeader("Overwrite") == "false" {
+		if res, _ := fs.Get(c, path, &fs.GetArgs{NoLog: true}); res != nil {
+			tusError(c, http.StatusConflict, errors.New("file exists"))
+			return
+		}
+	}
+	if err = os.MkdirAll(tusDir(), 0755); err != nil {
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if strings.HasPrefix(concat, "final;") {
+		if err = concatTus(user, info, strings.Fields(strings.TrimPrefix(concat, "final;"))); err != nil {
+			tusError(c, tusErrCode(err, http.StatusBadRequest), err)
+			return
+		}
+	} else {
+		info.Length, err = strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+		if err != nil || info.Length < 0 {
+			tusError(c, http.StatusBadRequest, errors.New("invalid Upload-Length"))
+			return
+		}
+		// refuse it before any byte is written to the disk, the storage of a partial upload is known
+		// only by the final one, which is checked with the total length before concatenating
+		if info.Partial {
+			err = op.CheckUploadQuota(user, info.Length)
+		} else {
+			err = op.CheckUploadSize(user, path, info.Length)
+		}
+		if err != nil {
+			tusError(c, tusErrCode(err, http.StatusBadRequest), err)
+			return
+		}
+		f, err := os.Create(tusDataPath(info.ID))
+		if err != nil {
+			tusError(c, http.StatusInternalServerError, err)
+			return
+		}
+		_ = f.Close()
+	}
+	if err = saveTusInfo(info); err != nil {
+		removeTus(info.ID)
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+info.ID)
+	// a final upload is complete once created, an empty file too
+	if (!info.Partial && info.Length == 0) || strings.HasPrefix(concat, "final;") {
+		if err = commitTus(c, info); err != nil {
+			tusError(c, putErrCode(err), err)
+			return
+		}
+	}
+	c.Status(http.StatusCreated)
+}
+
+// tusErrCode is the status of putErrCode for a rejection by the size or the quota, otherwise def
+func tusErrCode(err error, def int) int {
+	if code := putErrCode(err); code != http.StatusInternalServerError {
+		return code
+	}
+	return def
+}
+
+// concatTus joins the completed partial uploads into the data file of info, the partial uploads are removed.
+// The total length is checked by op.CheckUploadSize before copying
+func concatTus(user *model.User, info *tusInfo, urls []string) error {
+	if len(urls) == 0 {
+		return errors.New("no partial upload to concatenate")
+	}
+	partials := make([]*tusInfo, 0, len(urls))
+	var total int64
+	for _, u := range urls {
+		p, err := loadTusInfo(stdpath.Base(u))
+		if err != nil || p.UserID != info.UserID || !p.Partial {
+			return errors.Errorf("partial upload %s not found", u)
+		}
+		if offset, err := tusOffset(p.ID); err != nil || offset != p.Length {
+			return errors.Errorf("partial upload %s is not completed", u)
+		}
+		partials = append(partials, p)
+		total += p.Length
+	}
+	if err := op.CheckUploadSize(user, info.Path, total); err != nil {
+		return err
+	}
+	dst, err := os.Create(tusDataPath(info.ID))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	for _, p := range partials {
+		unlock := tusLock(p.ID)
+		src, err := os.Open(tusDataPath(p.ID))
+		if err == nil {
+			_, err = utils.CopyWithBuffer(dst, src)
+			_ = src.Close()
+		}
+		unlock()
+		if err != nil {
+			_ = os.Remove(dst.Name())
+			return err
+		}
+		info.Length += p.Length
+	}
+	for _, p := range partials {
+		removeTus(p.ID)
+	}
+	return nil
+}
+
+func TusHead(c *gin.Context) {
+	info, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	offset, err := tusOffset(info.ID)
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(info.Length, 10))
+	if info.Partial {
+		c.Header("Upload-Concat", "partial")
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// TusPatch appends the body at Upload-Offset, the upload is put to the storage when it's complete.
+// A PATCH at the end of a complete upload retries the put if it has failed
+func TusPatch(c *gin.Context) {
+	info, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if c.ContentType() != "application/offset+octet-stream" {
+		tusError(c, http.StatusUnsupportedMediaType, errors.New("content type must be application/offset+octet-stream"))
+		return
+	}
+	unlock := tusLock(info.ID)
+	defer unlock()
+	offset, err := tusOffset(info.ID)
+	if err != nil {
+		tusError(c, http.StatusNotFound, errors.New("upload not found"))
+		return
+	}
+	if c.GetHeader("Upload-Offset") != strconv.FormatInt(offset, 10) {
+		tusError(c, http.StatusConflict, errors.Errorf("offset mismatch, it's %d", offset))
+		return
+	}
+	f, err := os.OpenFile(tusDataPath(info.ID), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, err)
+		return
+	}
+	// keep what is written before the connection drops, the client resumes from there
+	n, err := utils.CopyWithBuffer(f, io.LimitReader(c.Request.Body, info.Length-offset))
+	_ = f.Close()
+	offset += n
+	if err != nil {
+		log.Debugf("tus upload %s is interrupted at %d: %v", info.ID, offset, err)
+		tusError(c, http.StatusBadRequest, err)
+		return
+	}
+	if offset == info.Length && !info.Partial {
+		if err = commitTus(c, info); err != nil {
+			tusError(c, putErrCode(err), err)
+			return
+		}
+	}
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// commitTus puts the complete data file to the dst path, the upload is removed if it succeeds
+func commitTus(c *gin.Context, info *tusInfo) error {
+	f, err := os.Open(tusDataPath(info.ID))
+	if err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(info.Path)
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Length,
+			Modified: info.Modified,
+		},
+		Reader:   f,
+		Mimetype: info.Mimetype,
+	}
+	err = fs.PutDirectly(c, dir, s, true)
+	_ = f.Close()
+	if err != nil {
+		return errors.WithMessage(err, "failed put the upload")
+	}
+	removeTus(info.ID)
+	return nil
+}
+
+func TusDelete(c *gin.Context) {
+	info, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	unlock := tusLock(info.ID)
+	removeTus(info.ID)
+	unlock()
+	c.Header("Tus-Resumable", tusVersion)
+	c.Status(http.StatusNoContent)
+}
+
+// StartTusCleanup removes the uploads which are not updated within tus_expiration hours periodically
+func StartTusCleanup() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			cleanupTus()
+			<-ticker.C
+		}
+	}()
+}
+
+func cleanupTus() {
+	expiration := time.Duration(setting.GetInt(conf.TusExpiration, 24)) * time.Hour
+	if expiration <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(tusDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		// the data file is modified by each PATCH
+		fi, err := os.Stat(tusDataPath(id))
+		if err == nil && time.Since(fi.ModTime()) < expiration {
+			continue
+		}
+		if err != nil {
+			if i, err := e.Info(); err == nil && time.Since(i.ModTime()) < expiration {
+				continue
+			}
+		}
+		log.Infof("remove abandoned tus upload %s", id)
+		unlock := tusLock(id)
+		removeTus(id)
+		unlock()
+	}
+}
@@ -142,6 +142,26 @@ func GetUser(c *gin.Context) {
 	common.SuccessResp(c, user)
 }
 
+// GetUserUploadUsage returns the upload quota and usage of the user
+func GetUserUploadUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	usage, err := op.GetUploadUsage(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, usage)
+}
+
 func Cancel2FAById(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"crypto/subtle"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics serves the driver metrics in the Prometheus format at /metrics
+func Metrics(g *gin.RouterGroup) {
+	if !conf.Conf.Metrics.Enable {
+		return
+	}
+	h := gin.WrapH(metrics.Handler())
+	g.GET("/metrics", func(c *gin.Context) {
+		if token := conf.Conf.Metrics.Token; token != "" &&
+			subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			common.ErrorStrResp(c, "invalid metrics token", 401)
+			return
+		}
+		h(c)
+	})
+}
@@ -16,6 +16,15 @@ import (
 )
 
 func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
+	return down(verifyFunc, false)
+}
+
+// SignedDown same as Down, but the sign is always required
+func SignedDown(verifyFunc func(string, string) error) func(c *gin.Context) {
+	return down(verifyFunc, true)
+}
+
+func down(verifyFunc func(string, string) error, alwaysSign bool) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		rawPath := parsePath(c.Param("path"))
 		c.Set("path", rawPath)
@@ -28,7 +37,7 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 		}
 		c.Set("meta", meta)
 		// verify sign
-		if needSign(meta, rawPath) {
+		if alwaysSign || needSign(meta, rawPath) {
 			s := c.Query("sign")
 			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"))
 			if err != nil {
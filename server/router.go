@@ -40,6 +40,7 @@ func Init(e *gin.Engine) {
 	}
 	WebDav(g.Group("/dav"))
 	S3(g.Group("/s3"))
+	Metrics(g)
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
@@ -47,6 +48,9 @@ func Init(e *gin.Engine) {
 	g.GET("/p/*path", signCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", signCheck, handles.Down)
 	g.HEAD("/p/*path", signCheck, handles.Proxy)
+	shareSignCheck := middlewares.SignedDown(sign.VerifyShare)
+	g.GET("/sp/*path", shareSignCheck, downloadLimiter, handles.ShareProxy)
+	g.HEAD("/sp/*path", shareSignCheck, handles.ShareProxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
 	g.GET("/ad/*path", archiveSignCheck, downloadLimiter, handles.ArchiveDown)
 	g.GET("/ap/*path", archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
@@ -54,6 +58,7 @@ func Init(e *gin.Engine) {
 	g.HEAD("/ad/*path", archiveSignCheck, handles.ArchiveDown)
 	g.HEAD("/ap/*path", archiveSignCheck, handles.ArchiveProxy)
 	g.HEAD("/ae/*path", archiveSignCheck, handles.ArchiveInternalExtract)
+	g.GET("/t/*path", middlewares.SignedDown(sign.VerifyThumb), downloadLimiter, handles.Thumb)
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
@@ -65,6 +70,7 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/register", handles.Register)
 	auth.GET("/me", handles.CurrentUser)
 	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.GET("/me/upload_usage", handles.CurrentUploadUsage)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
@@ -115,9 +121,15 @@ func admin(g *gin.RouterGroup) {
 	meta.POST("/update", handles.UpdateMeta)
 	meta.POST("/delete", handles.DeleteMeta)
 
+	shortcut := g.Group("/shortcut")
+	shortcut.GET("/list", handles.ListShortcuts)
+	shortcut.POST("/create", handles.CreateShortcut)
+	shortcut.POST("/delete", handles.DeleteShortcut)
+
 	user := g.Group("/user")
 	user.GET("/list", handles.ListUsers)
 	user.GET("/get", handles.GetUser)
+	user.GET("/upload_usage", handles.GetUserUploadUsage)
 	user.POST("/create", handles.CreateUser)
 	user.POST("/update", handles.UpdateUser)
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
@@ -142,11 +154,16 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/health", handles.StorageHealth)
+
+	cache := g.Group("/cache")
+	cache.GET("/list_stats", handles.GetListCacheStats)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
 	driver.GET("/names", handles.ListDriverNames)
 	driver.GET("/info", handles.GetDriverInfo)
+	driver.GET("/schema", handles.GetDriverSchema)
 
 	setting := g.Group("/setting")
 	setting.GET("/get", handles.GetSetting)
@@ -161,6 +178,7 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_115", handles.Set115)
 	setting.POST("/set_pikpak", handles.SetPikPak)
 	setting.POST("/set_thunder", handles.SetThunder)
+	setting.POST("/set_baidu", handles.SetBaidu)
 
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
@@ -198,20 +216,40 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/list", handles.FsList)
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
 	g.Any("/get", handles.FsGet)
+	g.Any("/get_by_id", handles.FsGetByID)
+	g.Any("/peek", handles.FsPeek)
+	g.POST("/share_link", handles.FsShareLink)
+	g.GET("/pack", handles.FsPack)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
+	g.Any("/dir_size", handles.FsDirSize)
+	g.Any("/list_recursive", middlewares.AuthAdmin, handles.FsListRecursive)
+	g.POST("/invalidate_cache", handles.FsInvalidateCache)
 	g.POST("/mkdir", handles.FsMkdir)
+	g.POST("/touch", handles.FsTouch)
+	g.POST("/truncate", handles.FsTruncate)
 	g.POST("/rename", handles.FsRename)
 	g.POST("/batch_rename", handles.FsBatchRename)
 	g.POST("/regex_rename", handles.FsRegexRename)
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
+	g.POST("/batch_move", handles.FsBatchMove)
+	g.POST("/batch_copy", handles.FsBatchCopy)
+	g.POST("/diff", handles.FsDiff)
+	g.POST("/sync", handles.FsSync)
+	g.POST("/clip", handles.FsClip)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
+	g.OPTIONS("/tus", handles.TusOptions)
+	g.POST("/tus", middlewares.FsUp, handles.TusCreate)
+	g.HEAD("/tus/:id", handles.TusHead)
+	g.PATCH("/tus/:id", uploadLimiter, handles.TusPatch)
+	g.DELETE("/tus/:id", handles.TusDelete)
+	g.POST("/put_url", handles.FsPutURL)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
 	// g.POST("/add_aria2", handles.AddOfflineDownload)
 	// g.POST("/add_qbit", handles.AddQbittorrent)
@@ -243,6 +281,8 @@ func Cors(r *gin.Engine) {
 	config.AllowOrigins = conf.Conf.Cors.AllowOrigins
 	config.AllowHeaders = conf.Conf.Cors.AllowHeaders
 	config.AllowMethods = conf.Conf.Cors.AllowMethods
+	// read by tus clients
+	config.ExposeHeaders = []string{"Location", "Upload-Offset", "Upload-Length", "Tus-Resumable", "Tus-Version", "Tus-Extension"}
 	r.Use(cors.New(config))
 }
 
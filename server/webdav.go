@@ -52,6 +52,7 @@ func WebDav(dav *gin.RouterGroup) {
 func ServeWebDAV(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	ctx := context.WithValue(c.Request.Context(), "user", user)
+	ctx = context.WithValue(ctx, "meta_password", c.GetString("meta_password"))
 	handler.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
 }
 
@@ -156,6 +157,18 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// same as the http handlers, a directory protected by the meta password needs it, the destination of
+	// a copy or move is checked by the handler. It's 403 since the basic auth is right, a 401 makes the clients loop
+	metaPassword := webdav.MetaPassword(c.Request)
+	if ok, err := webdav.CanAccessMeta(user, reqPath, metaPassword); err != nil {
+		c.Status(http.StatusInternalServerError)
+		c.Abort()
+		return
+	} else if !ok {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
 	key := utils.GetMD5EncodeStr(fmt.Sprintf("%d-%s", user.ID, c.ClientIP()))
 	if err := device.Handle(user.ID, key, c.Request.UserAgent(), c.ClientIP()); err != nil {
 		c.Status(http.StatusForbidden)
@@ -164,5 +177,6 @@ func WebDAVAuth(c *gin.Context) {
 	}
 	c.Set("device_key", key)
 	c.Set("user", user)
+	c.Set("meta_password", metaPassword)
 	c.Next()
 }
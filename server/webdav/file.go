@@ -95,6 +95,11 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	}
 	meta, _ := op.GetNearestMeta(name)
 	user := ctx.Value("user").(*model.User)
+	// don't walk into a directory protected by a meta password the client hasn't supplied
+	metaPassword, _ := ctx.Value("meta_password").(string)
+	if !common.CanAccessWithRoles(user, meta, name, metaPassword) {
+		return nil
+	}
 	// Read directory names.
 	objs, err := fs.List(context.WithValue(ctx, "meta", meta), name, &fs.ListArgs{})
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
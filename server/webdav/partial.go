@@ -0,0 +1,207 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// partialUploadExpire is how long an unfinished ranged PUT is kept since its last chunk
+const partialUploadExpire = time.Hour * 24
+
+// partialUpload assembles the chunks of a ranged PUT in a temp file,
+// the file is committed to the driver when all bytes are present
+type partialUpload struct {
+	mu      sync.Mutex
+	file    *os.File
+	size    int64 // bytes written from offset 0
+	total   int64 // -1 if unknown
+	updated time.Time
+}
+
+var (
+	partialUploadsMu sync.Mutex
+	partialUploads   = map[string]*partialUpload{}
+)
+
+// parseContentRange parses "bytes start-end/total", total is -1 if it's "*"
+func parseContentRange(s string) (start, end, total int64, err error) {
+	start, end, err = http_range.ParseContentRange(s)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if start < 0 || end < start {
+		return 0, 0, 0, http_range.ErrInvalid
+	}
+	totalStr := strings.TrimSpace(s[strings.Index(s, "/")+1:])
+	if totalStr == "*" {
+		return start, end, -1, nil
+	}
+	total, err = strconv.ParseInt(totalStr, 10, 64)
+	if err != nil || total <= end {
+		return 0, 0, 0, http_range.ErrInvalid
+	}
+	return start, end, total, nil
+}
+
+// partialUploadKey keys the upload by the lock token, so that chunks of different lock holders are not mixed
+func partialUploadKey(r *http.Request, reqPath string) string {
+	token := ""
+	if ih, ok := parseIfHeader(r.Header.Get("If")); ok {
+	loop:
+		for _, l := range ih.lists {
+			for _, c := range l.conditions {
+				if c.Token != "" {
+					token = c.Token
+					break loop
+				}
+			}
+		}
+	}
+	return reqPath + "\x00" + token
+}
+
+func getPartialUpload(key string) (*partialUpload, error) {
+	partialUploadsMu.Lock()
+	defer partialUploadsMu.Unlock()
+	now := time.Now()
+	for k, p := range partialUploads {
+		if k != key && p.mu.TryLock() {
+			if now.Sub(p.updated) > partialUploadExpire {
+				p.remove()
+				delete(partialUploads, k)
+			}
+			p.mu.Unlock()
+		}
+	}
+	if p, ok := partialUploads[key]; ok {
+		return p, nil
+	}
+	dir := filepath.Join(conf.Conf.TempDir, "webdav")
+	if err := utils.CreateNestedDirectory(dir); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, "partial-*")
+	if err != nil {
+		return nil, err
+	}
+	p := &partialUpload{file: f, total: -1, updated: now}
+	partialUploads[key] = p
+	return p, nil
+}
+
+func deletePartialUpload(key string, p *partialUpload) {
+	partialUploadsMu.Lock()
+	if partialUploads[key] == p {
+		delete(partialUploads, key)
+	}
+	partialUploadsMu.Unlock()
+	p.remove()
+}
+
+func (p *partialUpload) remove() {
+	_ = p.file.Close()
+	if err := os.Remove(p.file.Name()); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove webdav partial upload file: %+v", err)
+	}
+}
+
+// handlePartialPut handles a PUT with Content-Range, the status is http.StatusNoContent until the file is complete
+func (h *Handler) handlePartialPut(ctx context.Context, r *http.Request, reqPath, contentRange string) (obj *model.Object, status int, err error) {
+	start, end, total, err := parseContentRange(contentRange)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid Content-Range: %s", contentRange)
+	}
+	// refuse it before the chunk is written to the temp file, by the total or the end of the chunk if it's unknown
+	user, _ := ctx.Value("user").(*model.User)
+	if err = op.CheckUploadSize(user, reqPath, max(total, end+1)); err != nil {
+		return nil, uploadErrStatus(err), err
+	}
+	key := partialUploadKey(r, reqPath)
+	p, err := getPartialUpload(key)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// only appending or rewriting the received bytes is supported
+	if start > p.size {
+		return nil, http.StatusRequestedRangeNotSatisfiable, fmt.Errorf("chunk starts at %d, but only %d bytes received", start, p.size)
+	}
+	if total >= 0 {
+		if p.total >= 0 && p.total != total {
+			return nil, http.StatusBadRequest, fmt.Errorf("total size changed from %d to %d", p.total, total)
+		}
+		p.total = total
+	}
+	n, err := io.Copy(io.NewOffsetWriter(p.file, start), io.LimitReader(r.Body, end-start+1))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if n != end-start+1 {
+		return nil, http.StatusBadRequest, fmt.Errorf("chunk size mismatch, expect %d, got %d", end-start+1, n)
+	}
+	if start+n > p.size {
+		p.size = start + n
+	}
+	p.updated = time.Now()
+	if p.total < 0 || p.size < p.total {
+		return nil, http.StatusNoContent, nil
+	}
+
+	// all bytes are present, commit to the driver
+	defer deletePartialUpload(key, p)
+	if _, err = p.file.Seek(0, io.SeekStart); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	obj = &model.Object{
+		Name:     path.Base(reqPath),
+		Size:     p.total,
+		Modified: h.getModTime(r),
+		Ctime:    h.getCreateTime(r),
+	}
+	fsStream := &stream.FileStream{
+		Obj:      obj,
+		Mimetype: r.Header.Get("Content-Type"),
+	}
+	fsStream.SetTmpFile(p.file)
+	if fsStream.Mimetype == "" {
+		fsStream.Mimetype = utils.GetMimeType(reqPath)
+	}
+	err = fs.PutDirectly(ctx, path.Dir(reqPath), fsStream)
+	_ = fsStream.Close()
+	if err != nil {
+		return nil, uploadErrStatus(err), err
+	}
+	return obj, http.StatusCreated, nil
+}
+
+// uploadErrStatus is the status of a put refused by the max upload size or the quota
+func uploadErrStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.UploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errs.UploadQuotaExceeded), errors.Is(err, errs.QuotaExceeded):
+		return StatusInsufficientStorage
+	}
+	return http.StatusMethodNotAllowed
+}
@@ -13,12 +13,17 @@ import (
 	"mime"
 	"net/http"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 // Proppatch describes a property update instruction as defined in RFC 4918.
@@ -161,12 +166,24 @@ var liveProps = map[xml.Name]struct {
 		findFn: findSupportedLock,
 		dir:    true,
 	},
-	{Space: "http://owncloud.org/ns", Local: "checksums"}: {
+	// https://www.rfc-editor.org/rfc/rfc4331
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn: findQuotaAvailableBytes,
+		dir:    true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn: findQuotaUsedBytes,
+		dir:    true,
+	},
+	ocChecksums: {
 		findFn: findChecksums,
 		dir:    false,
 	},
 }
 
+// ocChecksums is the checksums property of OwnCloud, which is used by rclone and other sync clients
+var ocChecksums = xml.Name{Space: "http://owncloud.org/ns", Local: "checksums"}
+
 // TODO(nigeltao) merge props and allprop?
 
 // Props returns the status of the properties named pnames for resource name.
@@ -205,6 +222,12 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
 			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			if errors.Is(err, ErrNotImplemented) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -245,6 +268,10 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
+		// omit the checksums of objects without a known hash
+		if pn == ocChecksums && len(fileChecksums(ctx, fi)) == 0 {
+			continue
+		}
 		if prop.findFn != nil && (prop.dir || !isDir) {
 			pnames = append(pnames, pn)
 		}
@@ -398,6 +425,43 @@ func findCreationDate(ctx context.Context, ls LockSystem, name string, fi model.
 	return fi.CreateTime().UTC().Format(time.RFC3339), nil
 }
 
+// findStorageDetails get the quota of the storage which the requested directory belongs to,
+// the quota is unknown (ErrNotImplemented) if the driver doesn't report it or the call failed
+func findStorageDetails(ctx context.Context) (*model.StorageDetails, error) {
+	reqPath, ok := ctx.Value("reqPath").(string)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+	storage, _, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return nil, ErrNotImplemented
+	}
+	details, err := op.GetStorageDetails(ctx, storage)
+	if err != nil {
+		if !errs.IsNotImplement(err) {
+			log.Warnf("failed get details of storage [%s]: %+v", storage.GetStorage().MountPath, err)
+		}
+		return nil, ErrNotImplemented
+	}
+	return details, nil
+}
+
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := findStorageDetails(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(details.FreeSpace(), 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := findStorageDetails(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(details.UsedSpace, 10), nil
+}
+
 // ErrNotImplemented should be returned by optional interfaces if they
 // want the original implementation to be used.
 var ErrNotImplemented = errors.New("not implemented")
@@ -489,10 +553,28 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`</D:lockentry>`, nil
 }
 
-func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
-	checksums := ""
+// fileChecksums returns the valid hashes of fi in the OwnCloud format, e.g. "MD5:xxx", sorted by the hash name,
+// including the ones computed by fs.Hash before
+func fileChecksums(ctx context.Context, fi model.Obj) []string {
+	if reqPath, ok := ctx.Value("reqPath").(string); ok {
+		fi = fs.CachedHash(reqPath, fi)
+	}
+	var checksums []string
 	for hashType, hashValue := range fi.GetHash().All() {
-		checksums += fmt.Sprintf("<checksum>%s:%s</checksum>", hashType.Name, hashValue)
+		if len(hashValue) != hashType.Width {
+			continue
+		}
+		checksums = append(checksums, fmt.Sprintf("%s:%s", strings.ToUpper(hashType.Name), strings.ToLower(hashValue)))
+	}
+	slices.Sort(checksums)
+	return checksums
+}
+
+func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	checksums := fileChecksums(ctx, fi)
+	if len(checksums) == 0 {
+		return "", ErrNotImplemented
 	}
-	return checksums, nil
+	// OwnCloud puts all the checksums in one element, separated by spaces
+	return fmt.Sprintf("<checksum>%s</checksum>", strings.Join(checksums, " ")), nil
 }
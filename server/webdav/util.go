@@ -1,12 +1,44 @@
 package webdav
 
 import (
-	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MetaPasswordHeader carries the password of the directories protected by meta
+	MetaPasswordHeader = "X-Meta-Password"
+	// MetaPasswordQuery carries it for the clients which can't set a header
+	MetaPasswordQuery = "meta_password"
 )
 
+// MetaPassword is the meta password of the request, from the header or the query
+func MetaPassword(r *http.Request) string {
+	if password := r.Header.Get(MetaPasswordHeader); password != "" {
+		return password
+	}
+	return r.URL.Query().Get(MetaPasswordQuery)
+}
+
+// CanAccessMeta reports whether user can access path with the meta password, same as the http handlers
+func CanAccessMeta(user *model.User, path, password string) (bool, error) {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false, err
+	}
+	return common.CanAccessWithRoles(user, meta, path, password), nil
+}
+
 func (h *Handler) getModTime(r *http.Request) time.Time {
 	return h.getHeaderTime(r, "X-OC-Mtime", "")
 }
@@ -32,3 +64,24 @@ func (h *Handler) getHeaderTime(r *http.Request, header, alternative string) tim
 	}
 	return time.Now()
 }
+
+// getChecksum parses the OC-Checksum header sent by owncloud/nextcloud clients and rclone,
+// such as "SHA1:xxx MD5:xxx", it reports whether any checksum is found
+func getChecksum(r *http.Request) (utils.HashInfo, bool) {
+	h := make(map[*utils.HashType]string)
+	for _, v := range strings.Fields(r.Header.Get("OC-Checksum")) {
+		typ, sum, ok := strings.Cut(v, ":")
+		if !ok || sum == "" {
+			continue
+		}
+		switch strings.ToUpper(typ) {
+		case "MD5":
+			h[utils.MD5] = strings.ToLower(sum)
+		case "SHA1":
+			h[utils.SHA1] = strings.ToLower(sum)
+		case "SHA256":
+			h[utils.SHA256] = strings.ToLower(sum)
+		}
+	}
+	return utils.NewHashInfoByMap(h), len(h) > 0
+}
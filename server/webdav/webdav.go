@@ -13,14 +13,19 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/stream"
 
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 )
@@ -244,13 +249,7 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
-		if storage.GetStorage().ProxyRange {
-			common.ProxyRange(link, fi.GetSize())
-		}
-		err = common.Proxy(w, r, link, fi)
-		if err != nil {
-			return http.StatusInternalServerError, fmt.Errorf("webdav proxy error: %+v", err)
-		}
+		return proxyLink(w, r, storage, link, fi)
 	} else if storage.GetStorage().WebdavProxy() && downProxyUrl != "" {
 		u := common.BuildDownProxyURL(downProxyUrl, reqPath, storage.GetStorage().DownProxySign)
 		w.Header().Set("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
@@ -260,11 +259,28 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
+		if link.MustProxy {
+			// the link carries a credential of the storage, see model.Link.MustProxy
+			return proxyLink(w, r, storage, link, fi)
+		}
 		http.Redirect(w, r, link.URL, http.StatusFound)
 	}
 	return 0, nil
 }
 
+func proxyLink(w http.ResponseWriter, r *http.Request, storage driver.Driver, link *model.Link, fi model.Obj) (int, error) {
+	if storage.GetStorage().ProxyRange {
+		common.ProxyRange(link, fi.GetSize())
+	}
+	ww := &common.WrittenResponseWriter{ResponseWriter: w}
+	err := common.Proxy(ww, r, link, fi)
+	metrics.AddDownloadBytes(storage, ww.Size())
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("webdav proxy error: %+v", err)
+	}
+	return 0, nil
+}
+
 func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status int, err error) {
 	reqPath, status, err := h.stripPrefix(r.URL.Path)
 	if err != nil {
@@ -321,16 +337,36 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	if contentRange := r.Header.Get("Content-Range"); contentRange != "" {
+		obj, status, err := h.handlePartialPut(ctx, r, reqPath, contentRange)
+		if err != nil || status != http.StatusCreated {
+			return status, err
+		}
+		fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+		if err != nil {
+			fi = obj
+		}
+		etag, err := findETag(ctx, h.LockSystem, reqPath, fi)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		w.Header().Set("Etag", etag)
+		return http.StatusCreated, nil
+	}
+	hashInfo, hasChecksum := getChecksum(r)
 	obj := model.Object{
 		Name:     path.Base(reqPath),
 		Size:     r.ContentLength,
 		Modified: h.getModTime(r),
 		Ctime:    h.getCreateTime(r),
+		HashInfo: hashInfo,
 	}
 	fsStream := &stream.FileStream{
 		Obj:      &obj,
 		Reader:   r.Body,
 		Mimetype: r.Header.Get("Content-Type"),
+		// a client sending the checksum can skip uploading the unchanged files
+		SkipIfMatch: hasChecksum,
 	}
 	if fsStream.Mimetype == "" {
 		fsStream.Mimetype = utils.GetMimeType(reqPath)
@@ -443,6 +479,12 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 	if err != nil {
 		return 403, err
 	}
+	metaPassword, _ := ctx.Value("meta_password").(string)
+	if ok, err := CanAccessMeta(user, dst, metaPassword); err != nil {
+		return http.StatusInternalServerError, err
+	} else if !ok {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 
 	if r.Method == "COPY" {
 		// Section 7.5.1 says that a COPY only needs to lock the destination,
@@ -559,17 +601,21 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 			}
 		}()
 
-		// ??? Why create resource here?
-		//// Create the resource if it didn't previously exist.
-		//if _, err := h.FileSystem.Stat(ctx, reqPath); err != nil {
-		//	f, err := h.FileSystem.OpenFile(ctx, reqPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-		//	if err != nil {
-		//		// TODO: detect missing intermediate dirs and return http.StatusConflict?
-		//		return http.StatusInternalServerError, err
-		//	}
-		//	f.Close()
-		//	created = true
-		//}
+		// Create the resource if it didn't previously exist, the editors lock a new file before writing it,
+		// see http://www.webdav.org/specs/rfc4918.html#rfc.section.9.10.4
+		if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true}); errs.IsObjectNotFound(err) {
+			perm := common.MergeRolePermissions(user, reqPath)
+			if !common.HasPermission(perm, common.PermWebdavManage) || !common.HasPermission(perm, common.PermWrite) {
+				return http.StatusForbidden, errs.PermissionDenied
+			}
+			if err := fs.Touch(ctx, reqPath, now); err != nil {
+				if errs.IsNotFoundError(err) {
+					return http.StatusConflict, err
+				}
+				return http.StatusInternalServerError, err
+			}
+			created = true
+		}
 
 		// http://www.webdav.org/specs/rfc4918.html#HEADER_Lock-Token says that the
 		// Lock-Token value is a Coded-URL. We add angle brackets.
@@ -641,6 +687,20 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	if err != nil {
 		return status, err
 	}
+	// Depth: infinity may walk a whole storage, so it's limited in the levels and the number of responses
+	maxDepth, maxNodes := 0, 0
+	if depth == infiniteDepth {
+		maxDepth = setting.GetInt(conf.WebdavPropfindMaxDepth, 16)
+		if maxDepth <= 0 {
+			// Section 9.1 says the server may reject it with the propfind-finite-depth precondition
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+				`<D:error xmlns:D="DAV:"><D:propfind-finite-depth/></D:error>`))
+			return 0, nil
+		}
+		maxNodes = setting.GetInt(conf.WebdavPropfindMaxNodes, 10000)
+	}
 
 	mw := multistatusWriter{w: w}
 
@@ -692,6 +752,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 				infos = append(infos, infoItem{sp, info})
 			}
 			for _, item := range infos {
+				ctx := context.WithValue(ctx, "reqPath", item.path)
 				var pstats []Propstat
 				if pf.Propname != nil {
 					pnames, err := propnames(ctx, h.LockSystem, item.info)
@@ -736,10 +797,16 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 	}
 
+	rootPath, nodes := reqPath, 0
 	walkFn := func(reqPath string, info model.Obj, err error) error {
 		if err != nil {
 			return err
 		}
+		nodes++
+		if maxNodes > 0 && nodes > maxNodes {
+			return errPropfindTruncated
+		}
+		ctx := context.WithValue(ctx, "reqPath", reqPath)
 		var pstats []Propstat
 		if pf.Propname != nil {
 			pnames, err := propnames(ctx, h.LockSystem, info)
@@ -770,10 +837,29 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		if href != "/" && info.IsDir() {
 			href += "/"
 		}
-		return mw.write(makePropstatResponse(href, pstats))
+		if err = mw.write(makePropstatResponse(href, pstats)); err != nil {
+			return err
+		}
+		if maxDepth > 0 && info.IsDir() && propfindLevel(rootPath, reqPath) >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
 	}
 
 	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn)
+	if errors.Is(walkErr, errPropfindTruncated) {
+		// Section 9.1 allows to return a partial result, with a 507 response for the request-URI
+		rel := strings.TrimPrefix(strings.TrimPrefix(utils.FixAndCleanPath(reqPath), utils.FixAndCleanPath(user.BasePath)), "/")
+		href := utils.EncodePath(path.Join("/", h.Prefix, rel), true)
+		if href != "/" && fi.IsDir() {
+			href += "/"
+		}
+		walkErr = mw.write(&response{
+			Href:                []string{href},
+			Status:              fmt.Sprintf("HTTP/1.1 %d %s", http.StatusInsufficientStorage, StatusText(http.StatusInsufficientStorage)),
+			ResponseDescription: fmt.Sprintf("the result is truncated to %d resources", maxNodes),
+		})
+	}
 	closeErr := mw.close()
 	if walkErr != nil {
 		return http.StatusInternalServerError, walkErr
@@ -827,6 +913,15 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	return 0, nil
 }
 
+// propfindLevel returns how many levels p is below the requested root
+func propfindLevel(root, p string) int {
+	rel := strings.Trim(strings.TrimPrefix(p, root), "/")
+	if rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
 func makePropstatResponse(href string, pstats []Propstat) *response {
 	resp := response{
 		Href:     []string{href},
@@ -915,6 +1010,7 @@ var (
 	errNoLockSystem            = errors.New("webdav: no lock system")
 	errNotADirectory           = errors.New("webdav: not a directory")
 	errPrefixMismatch          = errors.New("webdav: prefix mismatch")
+	errPropfindTruncated       = errors.New("webdav: propfind truncated")
 	errRecursionTooDeep        = errors.New("webdav: recursion too deep")
 	errUnsupportedLockInfo     = errors.New("webdav: unsupported lock info")
 	errUnsupportedMethod       = errors.New("webdav: unsupported method")